@@ -0,0 +1,319 @@
+// Command catalogctl imports and exports the catalogue (cinemas, halls, movies and screenings).
+//
+//	go run ./cmd/catalogctl import catalog.json                  # upsert everything in the file
+//	go run ./cmd/catalogctl import movies.csv screenings.csv     # CSV files can be combined
+//	go run ./cmd/catalogctl import -allow-overlaps demo.json     # keep screenings that overlap in a hall
+//	go run ./cmd/catalogctl export -o catalog.json               # future screenings only
+//	go run ./cmd/catalogctl export -from 2025-01-01 -csv ./out   # writes movies.csv and screenings.csv
+//
+// JSON files hold a whole catalog (see models.Catalog). CSV files hold either movies (first column
+// "title") or screenings (first column "movie"); cinemas and halls need JSON because of the seat maps.
+// List columns are separated by "|" and seat type prices are written as PREMIUM=260|VIP=350.
+//
+// Importing is idempotent and never resets seat state, so it is safe against a live database.
+package main
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"movie-ticket-backend/config"
+	"movie-ticket-backend/database"
+	"movie-ticket-backend/models"
+	"movie-ticket-backend/services"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var (
+	movieColumns     = []string{"title", "description", "genres", "age_rating", "release_date", "language", "subtitle_language", "directors", "cast", "duration_min", "poster_url", "trailer_url"}
+	screeningColumns = []string{"movie", "cinema", "hall", "start_time", "price", "prices", "format", "audio"}
+)
+
+func main() {
+	if len(os.Args) < 2 {
+		usage()
+	}
+	switch os.Args[1] {
+	case "import":
+		runImport(os.Args[2:])
+	case "export":
+		runExport(os.Args[2:])
+	default:
+		usage()
+	}
+}
+
+func usage() {
+	fmt.Fprintln(os.Stderr, "usage: catalogctl import [-allow-overlaps] FILE...")
+	fmt.Fprintln(os.Stderr, "       catalogctl export [-from YYYY-MM-DD] [-o FILE.json | -csv DIR]")
+	os.Exit(2)
+}
+
+func connect() {
+	config.LoadConfig()
+	database.ConnectDB()
+	if database.Mongo == nil {
+		log.Fatal("MongoDB is not available")
+	}
+}
+
+func runImport(args []string) {
+	fs := flag.NewFlagSet("import", flag.ExitOnError)
+	allowOverlaps := fs.Bool("allow-overlaps", false, "create screenings even if they overlap another screening in the hall")
+	fs.Parse(args)
+	if fs.NArg() == 0 {
+		usage()
+	}
+
+	// Files are merged first so screenings can refer to movies and halls from any of them
+	var catalog models.Catalog
+	for _, path := range fs.Args() {
+		if err := readFile(path, &catalog); err != nil {
+			log.Fatalf("%s: %v", path, err)
+		}
+	}
+
+	connect()
+	report, err := services.NewCatalogService().Import(context.Background(), &catalog, services.ImportOptions{AllowOverlaps: *allowOverlaps})
+	for _, kind := range []string{"cinemas", "halls", "movies", "screenings"} {
+		log.Printf("%s: %d created, %d updated", kind, report.Created[kind], report.Updated[kind])
+	}
+	for _, reason := range report.Skipped {
+		log.Printf("skipped %s", reason)
+	}
+	if err != nil {
+		log.Fatalf("import stopped: %v", err)
+	}
+}
+
+func runExport(args []string) {
+	fs := flag.NewFlagSet("export", flag.ExitOnError)
+	fromDate := fs.String("from", "", "export screenings starting on or after this date (default: now)")
+	out := fs.String("o", "", "write the catalog as JSON to this file (default: stdout)")
+	csvDir := fs.String("csv", "", "write movies.csv and screenings.csv to this directory instead")
+	fs.Parse(args)
+
+	from := time.Now()
+	if *fromDate != "" {
+		var err error
+		if from, err = time.ParseInLocation("2006-01-02", *fromDate, time.Local); err != nil {
+			log.Fatalf("invalid -from, expected YYYY-MM-DD")
+		}
+	}
+
+	connect()
+	catalog, err := services.NewCatalogService().Export(context.Background(), from)
+	if err != nil {
+		log.Fatalf("export failed: %v", err)
+	}
+
+	if *csvDir != "" {
+		if err := writeCSV(*csvDir, catalog); err != nil {
+			log.Fatalf("export failed: %v", err)
+		}
+		log.Printf("Exported %d movies and %d screenings to %s (cinemas and halls are only exported as JSON)", len(catalog.Movies), len(catalog.Screenings), *csvDir)
+		return
+	}
+
+	w := io.Writer(os.Stdout)
+	if *out != "" {
+		f, err := os.Create(*out)
+		if err != nil {
+			log.Fatalf("export failed: %v", err)
+		}
+		defer f.Close()
+		w = f
+	}
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(catalog); err != nil {
+		log.Fatalf("export failed: %v", err)
+	}
+	if *out != "" {
+		log.Printf("Exported %d cinemas, %d movies and %d screenings to %s", len(catalog.Cinemas), len(catalog.Movies), len(catalog.Screenings), *out)
+	}
+}
+
+// readFile appends the records in a JSON or CSV file to catalog
+func readFile(path string, catalog *models.Catalog) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	if strings.EqualFold(filepath.Ext(path), ".json") {
+		var part models.Catalog
+		dec := json.NewDecoder(f)
+		dec.DisallowUnknownFields()
+		if err := dec.Decode(&part); err != nil {
+			return err
+		}
+		catalog.Cinemas = append(catalog.Cinemas, part.Cinemas...)
+		catalog.Movies = append(catalog.Movies, part.Movies...)
+		catalog.Screenings = append(catalog.Screenings, part.Screenings...)
+		return nil
+	}
+
+	r := csv.NewReader(f)
+	r.FieldsPerRecord = -1
+	header, err := r.Read()
+	if err != nil {
+		return err
+	}
+	columns := make(map[string]int)
+	for i, name := range header {
+		columns[strings.ToLower(strings.TrimSpace(name))] = i
+	}
+	var parseRow func(row func(string) string) error
+	switch strings.ToLower(strings.TrimSpace(header[0])) {
+	case "title":
+		parseRow = func(row func(string) string) error {
+			movie, err := parseMovieRow(row)
+			catalog.Movies = append(catalog.Movies, movie)
+			return err
+		}
+	case "movie":
+		parseRow = func(row func(string) string) error {
+			screening, err := parseScreeningRow(row)
+			catalog.Screenings = append(catalog.Screenings, screening)
+			return err
+		}
+	default:
+		return fmt.Errorf("unknown CSV layout; the first column must be \"title\" (movies) or \"movie\" (screenings)")
+	}
+
+	for line := 2; ; line++ {
+		record, err := r.Read()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		row := func(column string) string {
+			if i, ok := columns[column]; ok && i < len(record) {
+				return strings.TrimSpace(record[i])
+			}
+			return ""
+		}
+		if err := parseRow(row); err != nil {
+			return fmt.Errorf("line %d: %v", line, err)
+		}
+	}
+}
+
+func parseMovieRow(row func(string) string) (models.CatalogMovie, error) {
+	movie := models.CatalogMovie{
+		Title:            row("title"),
+		Description:      row("description"),
+		Genres:           splitList(row("genres")),
+		AgeRating:        models.AgeRating(row("age_rating")),
+		ReleaseDate:      row("release_date"),
+		Language:         row("language"),
+		SubtitleLanguage: row("subtitle_language"),
+		Directors:        splitList(row("directors")),
+		Cast:             splitList(row("cast")),
+		PosterURL:        row("poster_url"),
+		TrailerURL:       row("trailer_url"),
+	}
+	duration, err := strconv.Atoi(row("duration_min"))
+	if err != nil {
+		return movie, fmt.Errorf("invalid duration_min")
+	}
+	movie.DurationMin = duration
+	return movie, nil
+}
+
+func parseScreeningRow(row func(string) string) (models.CatalogScreening, error) {
+	screening := models.CatalogScreening{
+		Movie:  row("movie"),
+		Cinema: row("cinema"),
+		Hall:   row("hall"),
+		Format: models.ScreeningFormat(row("format")),
+		Audio:  models.AudioVersion(row("audio")),
+	}
+	start, err := time.Parse(time.RFC3339, row("start_time"))
+	if err != nil {
+		return screening, fmt.Errorf("invalid start_time, expected RFC 3339 (2025-01-31T19:30:00+07:00)")
+	}
+	screening.StartTime = start
+	if screening.Price, err = strconv.ParseFloat(row("price"), 64); err != nil {
+		return screening, fmt.Errorf("invalid price")
+	}
+	for _, pair := range splitList(row("prices")) {
+		seatType, value, found := strings.Cut(pair, "=")
+		price, err := strconv.ParseFloat(strings.TrimSpace(value), 64)
+		if !found || err != nil {
+			return screening, fmt.Errorf("invalid prices entry %q, expected TYPE=price", pair)
+		}
+		if screening.Prices == nil {
+			screening.Prices = make(map[models.SeatType]float64)
+		}
+		screening.Prices[models.SeatType(strings.ToUpper(strings.TrimSpace(seatType)))] = price
+	}
+	return screening, nil
+}
+
+func splitList(value string) []string {
+	var list []string
+	for _, item := range strings.Split(value, "|") {
+		if item = strings.TrimSpace(item); item != "" {
+			list = append(list, item)
+		}
+	}
+	return list
+}
+
+func writeCSV(dir string, catalog *models.Catalog) error {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return err
+	}
+
+	movies := [][]string{movieColumns}
+	for _, m := range catalog.Movies {
+		movies = append(movies, []string{
+			m.Title, m.Description, strings.Join(m.Genres, "|"), string(m.AgeRating), m.ReleaseDate, m.Language, m.SubtitleLanguage,
+			strings.Join(m.Directors, "|"), strings.Join(m.Cast, "|"), strconv.Itoa(m.DurationMin), m.PosterURL, m.TrailerURL,
+		})
+	}
+	if err := writeCSVFile(filepath.Join(dir, "movies.csv"), movies); err != nil {
+		return err
+	}
+
+	screenings := [][]string{screeningColumns}
+	for _, s := range catalog.Screenings {
+		var prices []string
+		for seatType, price := range s.Prices {
+			prices = append(prices, fmt.Sprintf("%s=%s", seatType, strconv.FormatFloat(price, 'f', -1, 64)))
+		}
+		sort.Strings(prices)
+		screenings = append(screenings, []string{
+			s.Movie, s.Cinema, s.Hall, s.StartTime.Format(time.RFC3339), strconv.FormatFloat(s.Price, 'f', -1, 64),
+			strings.Join(prices, "|"), string(s.Format), string(s.Audio),
+		})
+	}
+	return writeCSVFile(filepath.Join(dir, "screenings.csv"), screenings)
+}
+
+func writeCSVFile(path string, records [][]string) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	w := csv.NewWriter(f)
+	if err := w.WriteAll(records); err != nil {
+		return err
+	}
+	return f.Close()
+}
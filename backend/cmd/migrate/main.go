@@ -0,0 +1,386 @@
+// Command migrate applies one-off data migrations to MongoDB.
+//
+//	go run ./cmd/migrate          # apply pending migrations
+//	go run ./cmd/migrate -list    # show which migrations have been applied
+//
+// Applied migrations are recorded in the "migrations" collection, so running it twice is safe.
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"movie-ticket-backend/config"
+	"movie-ticket-backend/database"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type migration struct {
+	Name string
+	Run  func(ctx context.Context, db *mongo.Database) error
+}
+
+// migrations run in order; never rename or reorder an entry once it has shipped
+var migrations = []migration{
+	{"001_extract_screenings", extractScreenings},
+	{"002_structured_genres", structureGenres},
+	{"003_seat_counters", seatCounters},
+	{"004_unique_user_email", uniqueUserEmail},
+}
+
+func main() {
+	list := flag.Bool("list", false, "list migrations and exit")
+	flag.Parse()
+
+	config.LoadConfig()
+	database.ConnectDB()
+	if database.Mongo == nil {
+		log.Fatal("MongoDB is not available")
+	}
+	db := database.Mongo
+	ctx := context.Background()
+	applied := db.Collection("migrations")
+
+	for _, m := range migrations {
+		var record struct {
+			AppliedAt time.Time `bson:"applied_at"`
+		}
+		err := applied.FindOne(ctx, bson.M{"_id": m.Name}).Decode(&record)
+		if err == nil {
+			log.Printf("%s: applied at %s", m.Name, record.AppliedAt.Format(time.RFC3339))
+			continue
+		}
+		if err != mongo.ErrNoDocuments {
+			log.Fatalf("%s: failed to read migration state: %v", m.Name, err)
+		}
+		if *list {
+			log.Printf("%s: pending", m.Name)
+			continue
+		}
+
+		log.Printf("%s: running...", m.Name)
+		if err := m.Run(ctx, db); err != nil {
+			log.Fatalf("%s: failed: %v", m.Name, err)
+		}
+		if _, err := applied.InsertOne(ctx, bson.M{"_id": m.Name, "applied_at": time.Now()}); err != nil {
+			log.Fatalf("%s: failed to record migration: %v", m.Name, err)
+		}
+		log.Printf("%s: done", m.Name)
+	}
+}
+
+// extractScreenings moves the screenings embedded in movie documents into the screenings
+// collection and points existing bookings at the new screening IDs. Each movie is handled
+// on its own, so the migration can be re-run after a partial failure.
+//
+// Old screening IDs were chosen by the client, so they may be blank or repeat within and
+// across movies. A screening whose ID is not unique gets its own new screening, and only the
+// bookings for its start time are moved to it; bookings that cannot be told apart stop the
+// migration so they can be fixed by hand.
+func extractScreenings(ctx context.Context, db *mongo.Database) error {
+	movies := db.Collection("movies")
+	screenings := db.Collection("screenings")
+	bookings := db.Collection("bookings")
+
+	idCounts, err := legacyScreeningIDCounts(ctx, movies, screenings)
+	if err != nil {
+		return err
+	}
+	if err := checkLegacyScreeningsDistinct(ctx, movies, bookings); err != nil {
+		return err
+	}
+	var ambiguous []string
+	for id, count := range idCounts {
+		if id == "" || count > 1 {
+			ambiguous = append(ambiguous, id)
+		}
+	}
+
+	cursor, err := movies.Find(ctx, bson.M{"screenings": bson.M{"$exists": true}})
+	if err != nil {
+		return err
+	}
+	defer cursor.Close(ctx)
+
+	for cursor.Next(ctx) {
+		var movie struct {
+			ID         primitive.ObjectID `bson:"_id"`
+			Title      string             `bson:"title"`
+			DeletedAt  *time.Time         `bson:"deleted_at"`
+			Screenings []struct {
+				ID        string      `bson:"id"`
+				StartTime time.Time   `bson:"start_time"`
+				Price     float64     `bson:"price"`
+				Seats     interface{} `bson:"seats"`
+			} `bson:"screenings"`
+		}
+		if err := cursor.Decode(&movie); err != nil {
+			return err
+		}
+
+		now := time.Now()
+		remapped := 0
+		for _, s := range movie.Screenings {
+			unique := s.ID != "" && idCounts[s.ID] == 1
+			set := bson.M{
+				"start_time": s.StartTime,
+				"price":      s.Price,
+				"seats":      s.Seats,
+				"updated_at": now,
+			}
+			if movie.DeletedAt != nil {
+				set["deleted_at"] = *movie.DeletedAt
+			}
+			var created struct {
+				ID primitive.ObjectID `bson:"_id"`
+			}
+			filter := bson.M{"movie_id": movie.ID, "legacy_id": s.ID}
+			if !unique {
+				filter["start_time"] = s.StartTime
+			}
+			update := bson.M{"$set": set, "$setOnInsert": bson.M{"created_at": now}}
+			opts := options.FindOneAndUpdate().SetUpsert(true).SetReturnDocument(options.After).SetProjection(bson.M{"_id": 1})
+			if err := screenings.FindOneAndUpdate(ctx, filter, update, opts).Decode(&created); err != nil {
+				return fmt.Errorf("movie %s screening %q: %w", movie.ID.Hex(), s.ID, err)
+			}
+
+			bookingFilter := bson.M{"screening_id": s.ID}
+			if !unique {
+				ids, err := bookingsAt(ctx, bookings, s.ID, s.StartTime)
+				if err != nil {
+					return fmt.Errorf("movie %s screening %q: %w", movie.ID.Hex(), s.ID, err)
+				}
+				bookingFilter = bson.M{"_id": bson.M{"$in": ids}}
+			}
+			res, err := bookings.UpdateMany(ctx, bookingFilter, bson.M{"$set": bson.M{"screening_id": created.ID.Hex()}})
+			if err != nil {
+				return fmt.Errorf("movie %s screening %q: remap bookings: %w", movie.ID.Hex(), s.ID, err)
+			}
+			remapped += int(res.ModifiedCount)
+		}
+
+		if _, err := movies.UpdateOne(ctx, bson.M{"_id": movie.ID}, bson.M{"$unset": bson.M{"screenings": ""}}); err != nil {
+			return fmt.Errorf("movie %s: %w", movie.ID.Hex(), err)
+		}
+		log.Printf("  %s: %d screenings, %d bookings remapped", movie.Title, len(movie.Screenings), remapped)
+	}
+	if err := cursor.Err(); err != nil {
+		return err
+	}
+
+	// Bookings of a repeated ID whose start time matched none of its screenings
+	if len(ambiguous) > 0 {
+		left, err := bookings.CountDocuments(ctx, bson.M{"screening_id": bson.M{"$in": ambiguous}})
+		if err != nil {
+			return err
+		}
+		if left > 0 {
+			return fmt.Errorf("%d bookings still use the repeated screening ids %q and match no screening's start time; point them at the right screening and re-run", left, ambiguous)
+		}
+	}
+	return nil
+}
+
+// legacyScreeningIDCounts counts each old screening ID over the movies still to migrate and the
+// screenings already extracted, so a re-run after a partial failure sees the same repeats
+func legacyScreeningIDCounts(ctx context.Context, movies, screenings *mongo.Collection) (map[string]int, error) {
+	counts := make(map[string]int)
+	add := func(coll *mongo.Collection, pipeline mongo.Pipeline) error {
+		cursor, err := coll.Aggregate(ctx, pipeline)
+		if err != nil {
+			return err
+		}
+		var groups []struct {
+			ID    string `bson:"_id"`
+			Count int    `bson:"count"`
+		}
+		if err := cursor.All(ctx, &groups); err != nil {
+			return err
+		}
+		for _, g := range groups {
+			counts[g.ID] += g.Count
+		}
+		return nil
+	}
+	if err := add(movies, mongo.Pipeline{
+		{{Key: "$match", Value: bson.M{"screenings": bson.M{"$exists": true}}}},
+		{{Key: "$unwind", Value: "$screenings"}},
+		{{Key: "$group", Value: bson.M{"_id": bson.M{"$ifNull": bson.A{"$screenings.id", ""}}, "count": bson.M{"$sum": 1}}}},
+	}); err != nil {
+		return nil, err
+	}
+	if err := add(screenings, mongo.Pipeline{
+		{{Key: "$match", Value: bson.M{"legacy_id": bson.M{"$exists": true}}}},
+		{{Key: "$group", Value: bson.M{"_id": "$legacy_id", "count": bson.M{"$sum": 1}}}},
+	}); err != nil {
+		return nil, err
+	}
+	return counts, nil
+}
+
+// checkLegacyScreeningsDistinct refuses to start when two screenings share both ID and start
+// time and have bookings: those bookings cannot be assigned to either of them
+func checkLegacyScreeningsDistinct(ctx context.Context, movies, bookings *mongo.Collection) error {
+	cursor, err := movies.Aggregate(ctx, mongo.Pipeline{
+		{{Key: "$match", Value: bson.M{"screenings": bson.M{"$exists": true}}}},
+		{{Key: "$unwind", Value: "$screenings"}},
+		{{Key: "$group", Value: bson.M{
+			"_id":   bson.M{"id": bson.M{"$ifNull": bson.A{"$screenings.id", ""}}, "start_time": "$screenings.start_time"},
+			"count": bson.M{"$sum": 1},
+		}}},
+		{{Key: "$match", Value: bson.M{"count": bson.M{"$gt": 1}}}},
+	})
+	if err != nil {
+		return err
+	}
+	var groups []struct {
+		ID struct {
+			ID        string    `bson:"id"`
+			StartTime time.Time `bson:"start_time"`
+		} `bson:"_id"`
+	}
+	if err := cursor.All(ctx, &groups); err != nil {
+		return err
+	}
+	for _, g := range groups {
+		ids, err := bookingsAt(ctx, bookings, g.ID.ID, g.ID.StartTime)
+		if err != nil {
+			return err
+		}
+		if len(ids) > 0 {
+			return fmt.Errorf("screening id %q at %s is used by more than one screening and has %d bookings; give the screenings distinct ids first",
+				g.ID.ID, g.ID.StartTime.Format(time.RFC3339), len(ids))
+		}
+	}
+	return nil
+}
+
+// bookingsAt finds the bookings with the old screening ID whose screen_start_time (as sent by
+// the client) is the given start time
+func bookingsAt(ctx context.Context, bookings *mongo.Collection, legacyID string, start time.Time) ([]primitive.ObjectID, error) {
+	cursor, err := bookings.Find(ctx, bson.M{"screening_id": legacyID}, options.Find().SetProjection(bson.M{"screen_start_time": 1}))
+	if err != nil {
+		return nil, err
+	}
+	var found []struct {
+		ID              primitive.ObjectID `bson:"_id"`
+		ScreenStartTime string             `bson:"screen_start_time"`
+	}
+	if err := cursor.All(ctx, &found); err != nil {
+		return nil, err
+	}
+	ids := []primitive.ObjectID{}
+	for _, b := range found {
+		if t, err := time.Parse(time.RFC3339Nano, b.ScreenStartTime); err == nil && t.Equal(start) {
+			ids = append(ids, b.ID)
+		}
+	}
+	return ids, nil
+}
+
+// structureGenres splits the free-text genre ("Sci-Fi / Action") into the genres array
+func structureGenres(ctx context.Context, db *mongo.Database) error {
+	movies := db.Collection("movies")
+
+	cursor, err := movies.Find(ctx, bson.M{"genre": bson.M{"$exists": true}})
+	if err != nil {
+		return err
+	}
+	defer cursor.Close(ctx)
+
+	for cursor.Next(ctx) {
+		var movie struct {
+			ID     primitive.ObjectID `bson:"_id"`
+			Title  string             `bson:"title"`
+			Genre  string             `bson:"genre"`
+			Genres []string           `bson:"genres"`
+		}
+		if err := cursor.Decode(&movie); err != nil {
+			return err
+		}
+
+		genres := movie.Genres
+		if len(genres) == 0 {
+			genres = []string{}
+			for _, g := range strings.FieldsFunc(movie.Genre, func(r rune) bool { return r == '/' || r == ',' }) {
+				if g = strings.TrimSpace(g); g != "" {
+					genres = append(genres, g)
+				}
+			}
+		}
+		update := bson.M{"$set": bson.M{"genres": genres}, "$unset": bson.M{"genre": ""}}
+		if _, err := movies.UpdateOne(ctx, bson.M{"_id": movie.ID}, update); err != nil {
+			return fmt.Errorf("movie %s: %w", movie.ID.Hex(), err)
+		}
+		log.Printf("  %s: %q -> %v", movie.Title, movie.Genre, genres)
+	}
+	if err := cursor.Err(); err != nil {
+		return err
+	}
+
+	// The old single-field index is no longer used by the catalogue
+	if _, err := movies.Indexes().DropOne(ctx, "genre_1"); err != nil {
+		log.Printf("  genre_1 index not dropped: %v", err)
+	}
+	return nil
+}
+
+// seatCounters fills in seats_total and seats_booked, which listings read instead of the seat
+// arrays. Screenings created since the counters were introduced already have them.
+func seatCounters(ctx context.Context, db *mongo.Database) error {
+	update := mongo.Pipeline{{{Key: "$set", Value: bson.M{
+		"seats_total": bson.M{"$size": "$seats"},
+		"seats_booked": bson.M{"$size": bson.M{"$filter": bson.M{
+			"input": "$seats",
+			"cond":  bson.M{"$eq": bson.A{"$$this.status", "BOOKED"}},
+		}}},
+	}}}}
+	filter := bson.M{"seats_total": bson.M{"$exists": false}, "seats": bson.M{"$type": "array"}}
+	result, err := db.Collection("screenings").UpdateMany(ctx, filter, update)
+	if err != nil {
+		return err
+	}
+	log.Printf("  %d screenings counted", result.ModifiedCount)
+	return nil
+}
+
+// uniqueUserEmail replaces the plain users.email index with the unique one from database.EnsureIndexes.
+// Accounts sharing an email must be merged or renamed by hand first; the migration lists them and stops.
+func uniqueUserEmail(ctx context.Context, db *mongo.Database) error {
+	users := db.Collection("users")
+	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: bson.M{"email": bson.M{"$type": "string", "$gt": ""}}}},
+		{{Key: "$group", Value: bson.M{"_id": "$email", "count": bson.M{"$sum": 1}}}},
+		{{Key: "$match", Value: bson.M{"count": bson.M{"$gt": 1}}}},
+	}
+	cursor, err := users.Aggregate(ctx, pipeline)
+	if err != nil {
+		return err
+	}
+	var duplicates []struct {
+		Email string `bson:"_id"`
+		Count int    `bson:"count"`
+	}
+	if err := cursor.All(ctx, &duplicates); err != nil {
+		return err
+	}
+	for _, d := range duplicates {
+		log.Printf("  %s is used by %d accounts", d.Email, d.Count)
+	}
+	if len(duplicates) > 0 {
+		return fmt.Errorf("%d emails belong to more than one account", len(duplicates))
+	}
+
+	if _, err := users.Indexes().DropOne(ctx, "email_1"); err != nil {
+		log.Printf("  email_1 index not dropped: %v", err)
+	}
+	database.EnsureIndexes()
+	return nil
+}
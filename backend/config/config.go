@@ -3,6 +3,7 @@ package config
 import (
 	"log"
 	"os"
+	"time"
 
 	"github.com/spf13/viper"
 )
@@ -16,17 +17,107 @@ type Config struct {
 	GoogleClientID     string `mapstructure:"GOOGLE_CLIENT_ID"`
 	GoogleClientSecret string `mapstructure:"GOOGLE_CLIENT_SECRET"`
 	GoogleRedirectURL  string `mapstructure:"GOOGLE_REDIRECT_URL"`
+
+	// Reverse proxies whose X-Forwarded-For is believed (comma separated IPs/CIDRs); empty trusts none
+	TrustedProxies []string `mapstructure:"TRUSTED_PROXIES"`
+
+	// JWT signing (HS256 uses JWTSecret, RS256 uses the PEM key files)
+	JWTAlgorithm      string        `mapstructure:"JWT_ALGORITHM"`
+	JWTSecret         string        `mapstructure:"JWT_SECRET"`
+	JWTPrivateKeyPath string        `mapstructure:"JWT_PRIVATE_KEY_PATH"`
+	JWTPublicKeyPath  string        `mapstructure:"JWT_PUBLIC_KEY_PATH"`
+	JWTIssuer         string        `mapstructure:"JWT_ISSUER"`
+	AccessTokenTTL    time.Duration `mapstructure:"ACCESS_TOKEN_TTL"`
+	RefreshTokenTTL   time.Duration `mapstructure:"REFRESH_TOKEN_TTL"`
+
+	// OAuth login flow
+	OAuthStateSecret       string   `mapstructure:"OAUTH_STATE_SECRET"`
+	AllowedRedirectOrigins []string `mapstructure:"ALLOWED_REDIRECT_ORIGINS"` // comma separated, e.g. http://localhost:5173
+	DefaultRedirectURL     string   `mapstructure:"DEFAULT_REDIRECT_URL"`
+	PublicAPIURL           string   `mapstructure:"PUBLIC_API_URL"` // Used to build links in emails
+
+	// Generic OpenID Connect provider (enabled when OIDC_ISSUER_URL is set)
+	OIDCProviderName string   `mapstructure:"OIDC_PROVIDER_NAME"`
+	OIDCIssuerURL    string   `mapstructure:"OIDC_ISSUER_URL"`
+	OIDCClientID     string   `mapstructure:"OIDC_CLIENT_ID"`
+	OIDCClientSecret string   `mapstructure:"OIDC_CLIENT_SECRET"`
+	OIDCRedirectURL  string   `mapstructure:"OIDC_REDIRECT_URL"`
+	OIDCScopes       []string `mapstructure:"OIDC_SCOPES"`
+
+	// Email/password accounts
+	LocalAuthEnabled bool `mapstructure:"LOCAL_AUTH_ENABLED"`
+
+	// Users with these (verified) emails are made ADMIN when they sign in
+	BootstrapAdminEmails []string `mapstructure:"BOOTSTRAP_ADMIN_EMAILS"`
+
+	// Rate limits per route group, written as "<requests>/<period>" (e.g. "30/1m"); empty disables
+	RateLimitEnabled      bool   `mapstructure:"RATE_LIMIT_ENABLED"`
+	RateLimitSeatsUser    string `mapstructure:"RATE_LIMIT_SEATS_USER"`
+	RateLimitSeatsIP      string `mapstructure:"RATE_LIMIT_SEATS_IP"`
+	RateLimitPaymentUser  string `mapstructure:"RATE_LIMIT_PAYMENT_USER"`
+	RateLimitPaymentIP    string `mapstructure:"RATE_LIMIT_PAYMENT_IP"`
+	RateLimitScreeningsIP string `mapstructure:"RATE_LIMIT_SCREENINGS_IP"`
+	RateLimitAuthEmail    string `mapstructure:"RATE_LIMIT_AUTH_EMAIL"` // per submitted email and client IP on login/register
+	RateLimitAuthIP       string `mapstructure:"RATE_LIMIT_AUTH_IP"`
+
+	// Gap kept free in a hall after each screening (cleaning, ads), used by the overlap check
+	ScreeningCleaningBuffer time.Duration `mapstructure:"SCREENING_CLEANING_BUFFER"`
+
+	// Uploaded posters/backdrops. MEDIA_STORAGE selects the backend ("local" is the only one so far).
+	MediaStorage        string `mapstructure:"MEDIA_STORAGE"`
+	MediaLocalDir       string `mapstructure:"MEDIA_LOCAL_DIR"`
+	MediaMaxUploadBytes int64  `mapstructure:"MEDIA_MAX_UPLOAD_BYTES"`
+
+	// Payment provider used for refunds ("mock" is the only one so far; payments themselves are mocked)
+	PaymentGateway string `mapstructure:"PAYMENT_GATEWAY"`
+
+	// Import the demo catalogue on startup (development only)
+	SeedDemoData bool `mapstructure:"SEED_DEMO_DATA"`
 }
 
 var AppConfig Config
 
 func LoadConfig() {
 	viper.SetDefault("PORT", "8080")
+	viper.SetDefault("TRUSTED_PROXIES", "")
 	viper.SetDefault("DB_URI", "mongodb://localhost:27017")
 	viper.SetDefault("DB_NAME", "movie_ticket_db")
 	viper.SetDefault("REDIS_ADDR", "localhost:6379")
 	viper.SetDefault("KAFKA_BROKERS", "localhost:9092")
 	viper.SetDefault("GOOGLE_REDIRECT_URL", "http://localhost:8080/api/auth/google/callback")
+	viper.SetDefault("JWT_ALGORITHM", "HS256")
+	viper.SetDefault("JWT_SECRET", "")
+	viper.SetDefault("JWT_PRIVATE_KEY_PATH", "")
+	viper.SetDefault("JWT_PUBLIC_KEY_PATH", "")
+	viper.SetDefault("JWT_ISSUER", "movie-ticket-backend")
+	viper.SetDefault("ACCESS_TOKEN_TTL", "15m")
+	viper.SetDefault("REFRESH_TOKEN_TTL", "720h")
+	viper.SetDefault("OAUTH_STATE_SECRET", "")
+	viper.SetDefault("ALLOWED_REDIRECT_ORIGINS", "http://localhost:5173")
+	viper.SetDefault("DEFAULT_REDIRECT_URL", "http://localhost:5173/")
+	viper.SetDefault("PUBLIC_API_URL", "http://localhost:8080")
+	viper.SetDefault("OIDC_PROVIDER_NAME", "oidc")
+	viper.SetDefault("OIDC_ISSUER_URL", "")
+	viper.SetDefault("OIDC_CLIENT_ID", "")
+	viper.SetDefault("OIDC_CLIENT_SECRET", "")
+	viper.SetDefault("OIDC_REDIRECT_URL", "http://localhost:8080/api/auth/oidc/callback")
+	viper.SetDefault("OIDC_SCOPES", "openid,email,profile")
+	viper.SetDefault("LOCAL_AUTH_ENABLED", true)
+	viper.SetDefault("BOOTSTRAP_ADMIN_EMAILS", "")
+	viper.SetDefault("RATE_LIMIT_ENABLED", true)
+	viper.SetDefault("RATE_LIMIT_SEATS_USER", "30/1m")
+	viper.SetDefault("RATE_LIMIT_SEATS_IP", "120/1m")
+	viper.SetDefault("RATE_LIMIT_PAYMENT_USER", "10/1m")
+	viper.SetDefault("RATE_LIMIT_PAYMENT_IP", "30/1m")
+	viper.SetDefault("RATE_LIMIT_SCREENINGS_IP", "120/1m")
+	viper.SetDefault("RATE_LIMIT_AUTH_EMAIL", "10/15m")
+	viper.SetDefault("RATE_LIMIT_AUTH_IP", "20/1m")
+	viper.SetDefault("SCREENING_CLEANING_BUFFER", "15m")
+	viper.SetDefault("MEDIA_STORAGE", "local")
+	viper.SetDefault("MEDIA_LOCAL_DIR", "./uploads")
+	viper.SetDefault("MEDIA_MAX_UPLOAD_BYTES", 10<<20)
+	viper.SetDefault("PAYMENT_GATEWAY", "mock")
+	viper.SetDefault("SEED_DEMO_DATA", false)
 
 	// Load from .env file if it exists
 	viper.SetConfigFile(".env")
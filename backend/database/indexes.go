@@ -0,0 +1,87 @@
+package database
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// indexes lists the indexes each collection needs. CreateMany is idempotent, so this
+// runs on every start; changing an existing index's options requires dropping it first.
+var indexes = map[string][]mongo.IndexModel{
+	"movies": {
+		// Catalogue text search (GetMovies ?q=)
+		{
+			Keys: bson.D{{Key: "title", Value: "text"}, {Key: "description", Value: "text"}},
+			Options: options.Index().SetName("movies_text").
+				SetWeights(bson.M{"title": 10, "description": 1}),
+		},
+		{Keys: bson.D{{Key: "genres", Value: 1}}},
+		{Keys: bson.D{{Key: "age_rating", Value: 1}}},
+		{Keys: bson.D{{Key: "language", Value: 1}}},
+		{Keys: bson.D{{Key: "release_date", Value: -1}}},
+		{Keys: bson.D{{Key: "title", Value: 1}}},
+		{Keys: bson.D{{Key: "created_at", Value: -1}}},
+	},
+	"screenings": {
+		{Keys: bson.D{{Key: "movie_id", Value: 1}, {Key: "start_time", Value: 1}}},
+		{Keys: bson.D{{Key: "start_time", Value: 1}}},
+		{Keys: bson.D{{Key: "movie_id", Value: 1}, {Key: "legacy_id", Value: 1}}, Options: options.Index().SetSparse(true)},
+		{Keys: bson.D{{Key: "hall_id", Value: 1}, {Key: "start_time", Value: 1}}},
+		{Keys: bson.D{{Key: "template_id", Value: 1}, {Key: "start_time", Value: 1}}, Options: options.Index().SetSparse(true)},
+	},
+	"halls": {
+		{Keys: bson.D{{Key: "cinema_id", Value: 1}, {Key: "name", Value: 1}}},
+	},
+	"schedule_templates": {
+		{Keys: bson.D{{Key: "movie_id", Value: 1}}},
+	},
+	"pricing_rules": {
+		{Keys: bson.D{{Key: "active", Value: 1}, {Key: "priority", Value: 1}}},
+	},
+	"users": {
+		// One account per email, enforced here because registration and first logins can race.
+		// Replaces the plain email_1 index (dropped by migration 004_unique_user_email).
+		{
+			Keys: bson.D{{Key: "email", Value: 1}},
+			Options: options.Index().SetName("users_email_unique").SetUnique(true).
+				SetPartialFilterExpression(bson.M{"email": bson.M{"$type": "string", "$gt": ""}}),
+		},
+		{Keys: bson.D{{Key: "identities.provider", Value: 1}, {Key: "identities.subject", Value: 1}}},
+		{Keys: bson.D{{Key: "created_at", Value: -1}}},
+	},
+	"bookings": {
+		{Keys: bson.D{{Key: "user_id", Value: 1}, {Key: "created_at", Value: -1}}},
+		{Keys: bson.D{{Key: "screening_id", Value: 1}}},
+		{Keys: bson.D{{Key: "created_at", Value: -1}}},
+	},
+	"audit_logs": {
+		{Keys: bson.D{{Key: "user_id", Value: 1}, {Key: "timestamp", Value: -1}}},
+		{Keys: bson.D{{Key: "details.target_user_id", Value: 1}}},
+	},
+	"api_keys": {
+		{Keys: bson.D{{Key: "key_hash", Value: 1}}, Options: options.Index().SetUnique(true)},
+		{Keys: bson.D{{Key: "user_id", Value: 1}}},
+	},
+}
+
+// EnsureIndexes creates missing indexes. Failures are logged, not fatal (only ?q= search
+// strictly needs movies_text; everything else just gets slower).
+func EnsureIndexes() {
+	if Mongo == nil {
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	for collection, models := range indexes {
+		if _, err := Mongo.Collection(collection).Indexes().CreateMany(ctx, models); err != nil {
+			log.Printf("Failed to create indexes on %s: %v", collection, err)
+		}
+	}
+	log.Println("Indexes ensured")
+}
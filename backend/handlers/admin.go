@@ -2,15 +2,18 @@ package handlers
 
 import (
 	"context"
-	"fmt"
 	"movie-ticket-backend/database"
+	"movie-ticket-backend/middleware"
 	"movie-ticket-backend/models"
+	"movie-ticket-backend/services"
 	"strconv"
 	"strings"
 	"time"
 
 	"github.com/gin-gonic/gin"
 	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo/options"
 )
 
 type AdminBookingResponse struct {
@@ -21,6 +24,7 @@ type AdminBookingResponse struct {
 	PosterURL     string    `json:"poster_url"`
 	ScreeningTime time.Time `json:"screening_time"`
 	SeatID        string    `json:"seat_id"`
+	IDCheck       bool      `json:"requires_id_check"`
 	Status        string    `json:"status"`
 	Amount        float64   `json:"amount"`
 	CreatedAt     time.Time `json:"created_at"`
@@ -69,16 +73,45 @@ func GetAllBookings(c *gin.Context) {
 		Poster     string
 		StartTime  time.Time
 		MovieID    string
+		Location   *time.Location // Cinema timezone
 	}
-	screeningMap := make(map[string]ScreeningInfo)
+	movieMap := make(map[primitive.ObjectID]models.Movie)
 	for _, m := range movies {
-		for _, s := range m.Screenings {
-			screeningMap[s.ID] = ScreeningInfo{
-				MovieTitle: m.Title,
-				Poster:     m.PosterURL,
-				StartTime:  s.StartTime,
-				MovieID:    m.ID.Hex(),
-			}
+		movieMap[m.ID] = m
+	}
+	// Staff scoped to some cinemas only see the bookings of those cinemas' screenings
+	screeningFilter := bson.M{}
+	cinemaIDs, global := middleware.CinemaScope(c, models.PermBookingsRead)
+	if !global {
+		screeningFilter["cinema_id"] = bson.M{"$in": cinemaIDs}
+	}
+	sCursor, _ := database.Mongo.Collection("screenings").Find(context.TODO(), screeningFilter, options.Find().SetProjection(bson.M{"seats": 0, "layout": 0}))
+	var screenings []models.Screening
+	_ = sCursor.All(context.TODO(), &screenings)
+
+	cinemas, _ := services.NewCinemaService().ListCinemas(context.TODO())
+	cinemaLocations := make(map[primitive.ObjectID]*time.Location)
+	for _, cinema := range cinemas {
+		cinemaLocations[cinema.ID] = cinema.Location()
+	}
+
+	screeningMap := make(map[string]ScreeningInfo)
+	for _, s := range screenings {
+		loc, ok := cinemaLocations[s.CinemaID]
+		if !ok {
+			loc = time.Local
+		}
+		m := movieMap[s.MovieID]
+		poster := m.PosterURL
+		if m.PosterThumbURL != "" {
+			poster = m.PosterThumbURL // The dashboard only shows small previews
+		}
+		screeningMap[s.ID.Hex()] = ScreeningInfo{
+			MovieTitle: m.Title,
+			Poster:     poster,
+			StartTime:  s.StartTime,
+			MovieID:    s.MovieID.Hex(),
+			Location:   loc,
 		}
 	}
 
@@ -97,6 +130,9 @@ func GetAllBookings(c *gin.Context) {
 	for _, b := range bookings {
 		scInfo, okSc := screeningMap[b.ScreeningID]
 		user, okUser := userMap[b.UserID]
+		if !okSc && !global {
+			continue
+		}
 
 		// Filter: Movie
 		if filterMovieID != "" {
@@ -105,13 +141,13 @@ func GetAllBookings(c *gin.Context) {
 			}
 		}
 
-		// Filter: Date (Transaction Date)
+		// Filter: Date (Transaction Date, in the cinema's timezone)
 		if filterDate != "" {
-			bangkok := time.FixedZone("UTC+7", 7*60*60)
-			y, m, d := b.CreatedAt.In(bangkok).Date()
-			// Manually format to YYYY-MM-DD for clarity
-			dateStr := fmt.Sprintf("%04d-%02d-%02d", y, int(m), d)
-			if dateStr != filterDate {
+			loc := time.Local
+			if okSc {
+				loc = scInfo.Location
+			}
+			if b.CreatedAt.In(loc).Format("2006-01-02") != filterDate {
 				continue
 			}
 		}
@@ -135,6 +171,7 @@ func GetAllBookings(c *gin.Context) {
 			MovieTitle: "Unknown Movie",
 			PosterURL:  "",
 			SeatID:     b.SeatID,
+			IDCheck:    b.RequiresIDCheck,
 			Status:     b.Status,
 			Amount:     b.Amount,
 			CreatedAt:  b.CreatedAt,
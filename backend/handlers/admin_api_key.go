@@ -0,0 +1,127 @@
+package handlers
+
+import (
+	"movie-ticket-backend/models"
+	"movie-ticket-backend/services"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// CreatePartner creates a PARTNER account that API keys can be issued for
+func CreatePartner(c *gin.Context) {
+	adminID := c.GetString("userID")
+
+	var req struct {
+		Name  string `json:"name" binding:"required"`
+		Email string `json:"email" binding:"required,email"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(400, gin.H{"error": err.Error()})
+		return
+	}
+
+	partner, err := services.NewUserService().CreatePartnerAccount(c.Request.Context(), req.Name, req.Email)
+	if err == services.ErrEmailAlreadyRegistered {
+		c.JSON(409, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		c.JSON(500, gin.H{"error": "Failed to create partner"})
+		return
+	}
+
+	services.LogInfo("PARTNER_CREATED", adminID, map[string]interface{}{
+		"target_user_id": partner.ID.Hex(),
+		"name":           partner.Name,
+	})
+
+	c.JSON(201, partner)
+}
+
+// GetAPIKeys lists API keys, optionally filtered by ?user_id=
+func GetAPIKeys(c *gin.Context) {
+	keys, err := services.NewAPIKeyService().ListKeys(c.Request.Context(), c.Query("user_id"))
+	if err != nil {
+		c.JSON(500, gin.H{"error": "Failed to fetch API keys"})
+		return
+	}
+	c.JSON(200, keys)
+}
+
+// CreateAPIKey issues a key for a partner account. The plaintext key is only returned here.
+func CreateAPIKey(c *gin.Context) {
+	adminID := c.GetString("userID")
+
+	var req struct {
+		Name      string              `json:"name" binding:"required"`
+		UserID    string              `json:"user_id" binding:"required"`
+		Scopes    []models.Permission `json:"scopes" binding:"required,min=1"`
+		ExpiresAt *time.Time          `json:"expires_at"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(400, gin.H{"error": err.Error()})
+		return
+	}
+	if req.ExpiresAt != nil && !req.ExpiresAt.After(time.Now()) {
+		c.JSON(400, gin.H{"error": "expires_at must be in the future"})
+		return
+	}
+
+	partner, err := services.NewUserService().GetUserByID(c.Request.Context(), req.UserID)
+	if err == services.ErrUserNotFound {
+		c.JSON(404, gin.H{"error": "Partner account not found"})
+		return
+	}
+	if err != nil {
+		c.JSON(500, gin.H{"error": "Failed to fetch partner account"})
+		return
+	}
+
+	key, plaintext, err := services.NewAPIKeyService().CreateKey(c.Request.Context(), req.Name, partner, req.Scopes, req.ExpiresAt, adminID)
+	switch err {
+	case nil:
+	case services.ErrNotPartner, services.ErrInvalidScope:
+		c.JSON(400, gin.H{"error": err.Error()})
+		return
+	default:
+		c.JSON(500, gin.H{"error": "Failed to create API key"})
+		return
+	}
+
+	services.LogInfo("API_KEY_CREATED", adminID, map[string]interface{}{
+		"api_key_id":     key.ID.Hex(),
+		"target_user_id": key.UserID,
+		"name":           key.Name,
+		"scopes":         key.Scopes,
+		"expires_at":     key.ExpiresAt,
+	})
+
+	c.JSON(201, gin.H{
+		"api_key": key,
+		"key":     plaintext, // Shown once, cannot be retrieved later
+	})
+}
+
+// RevokeAPIKey disables a key immediately
+func RevokeAPIKey(c *gin.Context) {
+	adminID := c.GetString("userID")
+
+	key, err := services.NewAPIKeyService().RevokeKey(c.Request.Context(), c.Param("id"))
+	if err == services.ErrAPIKeyNotFound {
+		c.JSON(404, gin.H{"error": "API key not found or already revoked"})
+		return
+	}
+	if err != nil {
+		c.JSON(500, gin.H{"error": "Failed to revoke API key"})
+		return
+	}
+
+	services.LogInfo("API_KEY_REVOKED", adminID, map[string]interface{}{
+		"api_key_id":     key.ID.Hex(),
+		"target_user_id": key.UserID,
+		"name":           key.Name,
+	})
+
+	c.JSON(200, gin.H{"message": "API key revoked", "api_key": key})
+}
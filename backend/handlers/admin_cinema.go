@@ -0,0 +1,139 @@
+package handlers
+
+import (
+	"movie-ticket-backend/models"
+	"movie-ticket-backend/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// GetCinemas lists all cinemas
+func GetCinemas(c *gin.Context) {
+	cinemas, err := services.NewCinemaService().ListCinemas(c.Request.Context())
+	if err != nil {
+		c.JSON(500, gin.H{"error": "Failed to fetch cinemas"})
+		return
+	}
+	c.JSON(200, cinemas)
+}
+
+func CreateCinema(c *gin.Context) {
+	adminID := c.GetString("userID")
+
+	var req struct {
+		Name     string `json:"name" binding:"required"`
+		Address  string `json:"address"`
+		Timezone string `json:"timezone" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(400, gin.H{"error": err.Error()})
+		return
+	}
+
+	cinema, err := services.NewCinemaService().CreateCinema(c.Request.Context(), req.Name, req.Address, req.Timezone)
+	if err == services.ErrInvalidTimezone {
+		c.JSON(400, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		c.JSON(500, gin.H{"error": "Failed to create cinema"})
+		return
+	}
+
+	services.LogInfo("CINEMA_CREATED", adminID, map[string]interface{}{
+		"cinema_id": cinema.ID.Hex(),
+		"name":      cinema.Name,
+	})
+
+	c.JSON(201, cinema)
+}
+
+// GetHalls lists the halls of a cinema with their seat maps
+func GetHalls(c *gin.Context) {
+	halls, err := services.NewCinemaService().ListHalls(c.Request.Context(), c.Param("id"))
+	if err == services.ErrCinemaNotFound {
+		c.JSON(404, gin.H{"error": "Cinema not found"})
+		return
+	}
+	if err != nil {
+		c.JSON(500, gin.H{"error": "Failed to fetch halls"})
+		return
+	}
+	c.JSON(200, halls)
+}
+
+// hallRequest is the body of the create/update hall endpoints
+type hallRequest struct {
+	Name    string         `json:"name" binding:"required"`
+	SeatMap models.SeatMap `json:"seat_map"`
+}
+
+func bindHallRequest(c *gin.Context) (*hallRequest, bool) {
+	var req hallRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(400, gin.H{"error": err.Error()})
+		return nil, false
+	}
+	if err := req.SeatMap.Validate(); err != nil {
+		c.JSON(400, gin.H{"error": err.Error()})
+		return nil, false
+	}
+	return &req, true
+}
+
+func CreateHall(c *gin.Context) {
+	adminID := c.GetString("userID")
+
+	req, ok := bindHallRequest(c)
+	if !ok {
+		return
+	}
+
+	hall, err := services.NewCinemaService().CreateHall(c.Request.Context(), c.Param("id"), req.Name, req.SeatMap)
+	if err == services.ErrCinemaNotFound {
+		c.JSON(404, gin.H{"error": "Cinema not found"})
+		return
+	}
+	if err != nil {
+		c.JSON(500, gin.H{"error": "Failed to create hall"})
+		return
+	}
+
+	services.LogInfo("HALL_CREATED", adminID, map[string]interface{}{
+		"cinema_id": hall.CinemaID.Hex(),
+		"hall_id":   hall.ID.Hex(),
+		"name":      hall.Name,
+		"seats":     len(hall.SeatMap.Seats()),
+	})
+
+	c.JSON(201, hall)
+}
+
+// UpdateHall renames a hall or replaces its seat map. Only screenings created afterwards use the new map.
+func UpdateHall(c *gin.Context) {
+	adminID := c.GetString("userID")
+
+	req, ok := bindHallRequest(c)
+	if !ok {
+		return
+	}
+
+	hall, err := services.NewCinemaService().UpdateHall(c.Request.Context(), c.Param("id"), req.Name, req.SeatMap)
+	if err == services.ErrHallNotFound {
+		c.JSON(404, gin.H{"error": "Hall not found"})
+		return
+	}
+	if err != nil {
+		c.JSON(500, gin.H{"error": "Failed to update hall"})
+		return
+	}
+
+	services.LogInfo("HALL_UPDATED", adminID, map[string]interface{}{
+		"cinema_id": hall.CinemaID.Hex(),
+		"hall_id":   hall.ID.Hex(),
+		"name":      hall.Name,
+		"seats":     len(hall.SeatMap.Seats()),
+	})
+
+	c.JSON(200, hall)
+}
@@ -0,0 +1,123 @@
+package handlers
+
+import (
+	"movie-ticket-backend/models"
+	"movie-ticket-backend/services"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// pricingRuleRequest is the body of the create/update pricing rule endpoints
+type pricingRuleRequest struct {
+	Name        string                   `json:"name"`
+	Priority    int                      `json:"priority"`
+	Active      *bool                    `json:"active"` // Defaults to true
+	Conditions  models.PricingConditions `json:"conditions"`
+	Adjustment  models.PriceAdjustment   `json:"adjustment"`
+	StopOnMatch bool                     `json:"stop_on_match"`
+}
+
+func bindPricingRule(c *gin.Context) (models.PricingRule, bool) {
+	var req pricingRuleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(400, gin.H{"error": err.Error()})
+		return models.PricingRule{}, false
+	}
+	rule := models.PricingRule{
+		Name:        strings.TrimSpace(req.Name),
+		Priority:    req.Priority,
+		Active:      req.Active == nil || *req.Active,
+		Conditions:  req.Conditions,
+		Adjustment:  req.Adjustment,
+		StopOnMatch: req.StopOnMatch,
+	}
+	if err := rule.Validate(); err != nil {
+		c.JSON(400, gin.H{"error": err.Error()})
+		return models.PricingRule{}, false
+	}
+	return rule, true
+}
+
+// GetPricingRules lists all rules in evaluation order
+func GetPricingRules(c *gin.Context) {
+	rules, err := services.NewPricingService().ListRules(c.Request.Context(), false)
+	if err != nil {
+		c.JSON(500, gin.H{"error": "Failed to fetch pricing rules"})
+		return
+	}
+	c.JSON(200, rules)
+}
+
+func CreatePricingRule(c *gin.Context) {
+	adminID := c.GetString("userID")
+
+	rule, ok := bindPricingRule(c)
+	if !ok {
+		return
+	}
+
+	created, err := services.NewPricingService().CreateRule(c.Request.Context(), rule)
+	if err != nil {
+		c.JSON(500, gin.H{"error": "Failed to create pricing rule"})
+		return
+	}
+
+	services.LogInfo("PRICING_RULE_CREATED", adminID, map[string]interface{}{
+		"rule_id":    created.ID.Hex(),
+		"name":       created.Name,
+		"priority":   created.Priority,
+		"adjustment": created.Adjustment,
+	})
+
+	c.JSON(201, created)
+}
+
+func UpdatePricingRule(c *gin.Context) {
+	adminID := c.GetString("userID")
+
+	rule, ok := bindPricingRule(c)
+	if !ok {
+		return
+	}
+
+	updated, err := services.NewPricingService().UpdateRule(c.Request.Context(), c.Param("id"), rule)
+	if err == services.ErrPricingRuleNotFound {
+		c.JSON(404, gin.H{"error": "Pricing rule not found"})
+		return
+	}
+	if err != nil {
+		c.JSON(500, gin.H{"error": "Failed to update pricing rule"})
+		return
+	}
+
+	services.LogInfo("PRICING_RULE_UPDATED", adminID, map[string]interface{}{
+		"rule_id":    updated.ID.Hex(),
+		"name":       updated.Name,
+		"priority":   updated.Priority,
+		"active":     updated.Active,
+		"adjustment": updated.Adjustment,
+	})
+
+	c.JSON(200, updated)
+}
+
+func DeletePricingRule(c *gin.Context) {
+	adminID := c.GetString("userID")
+
+	err := services.NewPricingService().DeleteRule(c.Request.Context(), c.Param("id"))
+	if err == services.ErrPricingRuleNotFound {
+		c.JSON(404, gin.H{"error": "Pricing rule not found"})
+		return
+	}
+	if err != nil {
+		c.JSON(500, gin.H{"error": "Failed to delete pricing rule"})
+		return
+	}
+
+	services.LogInfo("PRICING_RULE_DELETED", adminID, map[string]interface{}{
+		"rule_id": c.Param("id"),
+	})
+
+	c.JSON(200, gin.H{"message": "Pricing rule deleted"})
+}
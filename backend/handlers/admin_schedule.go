@@ -0,0 +1,288 @@
+package handlers
+
+import (
+	"movie-ticket-backend/database"
+	"movie-ticket-backend/middleware"
+	"movie-ticket-backend/models"
+	"movie-ticket-backend/services"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// scheduleTemplateRequest is the body of the create/update schedule template endpoints
+type scheduleTemplateRequest struct {
+	Name           string                      `json:"name"`
+	MovieID        string                      `json:"movie_id" binding:"required"`
+	HallID         string                      `json:"hall_id" binding:"required"`
+	Times          []string                    `json:"times"`
+	StartDate      string                      `json:"start_date"`
+	EndDate        string                      `json:"end_date"`
+	ExceptWeekdays []time.Weekday              `json:"except_weekdays"`
+	ExceptDates    []string                    `json:"except_dates"`
+	Format         models.ScreeningFormat      `json:"format"`
+	Audio          models.AudioVersion         `json:"audio"`
+	Price          float64                     `json:"price"`
+	Prices         map[models.SeatType]float64 `json:"prices"`
+	SkipConflicts  bool                        `json:"skip_conflicts"` // Create the free showtimes and leave out the conflicting ones
+}
+
+// scheduleInput is a validated template with the movie and hall it refers to
+type scheduleInput struct {
+	template      models.ScheduleTemplate
+	movie         models.Movie
+	hall          *models.Hall
+	skipConflicts bool
+}
+
+func bindScheduleTemplate(c *gin.Context) (*scheduleInput, bool) {
+	var req scheduleTemplateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(400, gin.H{"error": err.Error()})
+		return nil, false
+	}
+	movieID, err := primitive.ObjectIDFromHex(req.MovieID)
+	if err != nil {
+		c.JSON(400, gin.H{"error": "Invalid Movie ID"})
+		return nil, false
+	}
+	hallID, err := primitive.ObjectIDFromHex(req.HallID)
+	if err != nil {
+		c.JSON(400, gin.H{"error": "Invalid Hall ID"})
+		return nil, false
+	}
+	input := &scheduleInput{
+		template: models.ScheduleTemplate{
+			Name:           strings.TrimSpace(req.Name),
+			MovieID:        movieID,
+			HallID:         hallID,
+			Times:          req.Times,
+			StartDate:      req.StartDate,
+			EndDate:        req.EndDate,
+			ExceptWeekdays: req.ExceptWeekdays,
+			ExceptDates:    req.ExceptDates,
+			Format:         req.Format,
+			Audio:          req.Audio,
+			Price:          req.Price,
+			Prices:         req.Prices,
+		},
+		skipConflicts: req.SkipConflicts,
+	}
+	if err := input.template.Validate(); err != nil {
+		c.JSON(400, gin.H{"error": err.Error()})
+		return nil, false
+	}
+
+	ctx := c.Request.Context()
+	filter := bson.M{"_id": movieID, "deleted_at": bson.M{"$exists": false}}
+	if err := database.Mongo.Collection("movies").FindOne(ctx, filter).Decode(&input.movie); err != nil {
+		c.JSON(404, gin.H{"error": "Movie not found"})
+		return nil, false
+	}
+	input.hall, err = services.NewCinemaService().GetHall(ctx, req.HallID)
+	if err == services.ErrHallNotFound {
+		c.JSON(404, gin.H{"error": "Hall not found"})
+		return nil, false
+	}
+	if err != nil {
+		c.JSON(500, gin.H{"error": "Failed to fetch hall"})
+		return nil, false
+	}
+	if !middleware.AllowCinema(c, input.hall.CinemaID, models.PermScreeningsWrite) {
+		return nil, false
+	}
+	return input, true
+}
+
+// allowTemplate checks the caller may schedule in the cinema of the template's current hall.
+// Returns false when the response has been written.
+func allowTemplate(c *gin.Context, tmpl *models.ScheduleTemplate) bool {
+	hall, err := services.NewCinemaService().GetHall(c.Request.Context(), tmpl.HallID.Hex())
+	if err != nil && err != services.ErrHallNotFound {
+		c.JSON(500, gin.H{"error": "Failed to fetch hall"})
+		return false
+	}
+	cinemaID := primitive.NilObjectID
+	if hall != nil {
+		cinemaID = hall.CinemaID
+	}
+	return middleware.AllowCinema(c, cinemaID, models.PermScreeningsWrite)
+}
+
+// applySchedule plans the template and, unless this is a dry run, saves it and applies the plan.
+// Returns false when the response has already been written.
+func applySchedule(c *gin.Context, input *scheduleInput) (*services.SchedulePlan, bool) {
+	ctx := c.Request.Context()
+	scheduleService := services.NewScheduleService()
+
+	unlock, ok := lockHalls(c, input.hall.ID)
+	if !ok {
+		return nil, false
+	}
+	defer unlock()
+	plan, err := scheduleService.Plan(ctx, &input.template, input.hall, input.movie.DurationMin)
+	if err != nil {
+		c.JSON(500, gin.H{"error": "Failed to plan the schedule"})
+		return nil, false
+	}
+	if c.Query("dry_run") == "true" {
+		c.JSON(200, gin.H{"dry_run": true, "template": input.template, "plan": plan})
+		return nil, false
+	}
+	if plan.Conflicts > 0 && !input.skipConflicts {
+		c.JSON(409, gin.H{"error": "Some showtimes overlap other screenings; adjust the template or set skip_conflicts", "plan": plan})
+		return nil, false
+	}
+
+	if err := scheduleService.SaveTemplate(ctx, &input.template); err != nil {
+		c.JSON(500, gin.H{"error": "Failed to save schedule template"})
+		return nil, false
+	}
+	if err := scheduleService.Apply(ctx, &input.template, input.hall, plan); err != nil {
+		c.JSON(500, gin.H{"error": "Failed to generate screenings"})
+		return nil, false
+	}
+	return plan, true
+}
+
+func scheduleAuditDetails(tmpl models.ScheduleTemplate, plan *services.SchedulePlan) map[string]interface{} {
+	return map[string]interface{}{
+		"template_id": tmpl.ID.Hex(),
+		"movie_id":    tmpl.MovieID.Hex(),
+		"hall_id":     tmpl.HallID.Hex(),
+		"created":     len(plan.Create),
+		"updated":     len(plan.Update),
+		"removed":     len(plan.Remove),
+		"kept_sold":   len(plan.KeptSold),
+		"skipped":     plan.Conflicts,
+	}
+}
+
+// GetScheduleTemplates lists the schedule templates of the cinemas the caller may schedule in
+func GetScheduleTemplates(c *gin.Context) {
+	ctx := c.Request.Context()
+	var hallIDs []primitive.ObjectID
+	if cinemaIDs, global := middleware.CinemaScope(c, models.PermScreeningsWrite); !global {
+		hallIDs = []primitive.ObjectID{}
+		for _, cinemaID := range cinemaIDs {
+			halls, err := services.NewCinemaService().ListHalls(ctx, cinemaID.Hex())
+			if err != nil {
+				c.JSON(500, gin.H{"error": "Failed to fetch halls"})
+				return
+			}
+			for _, h := range halls {
+				hallIDs = append(hallIDs, h.ID)
+			}
+		}
+	}
+	templates, err := services.NewScheduleService().ListTemplates(ctx, hallIDs)
+	if err != nil {
+		c.JSON(500, gin.H{"error": "Failed to fetch schedule templates"})
+		return
+	}
+	c.JSON(200, templates)
+}
+
+// CreateScheduleTemplate generates the template's screenings. With ?dry_run=true nothing is saved
+// and the response only shows the showtimes that would be created and their conflicts.
+func CreateScheduleTemplate(c *gin.Context) {
+	adminID := c.GetString("userID")
+
+	input, ok := bindScheduleTemplate(c)
+	if !ok {
+		return
+	}
+	plan, ok := applySchedule(c, input)
+	if !ok {
+		return
+	}
+
+	services.LogInfo("SCHEDULE_TEMPLATE_CREATED", adminID, scheduleAuditDetails(input.template, plan))
+
+	c.JSON(201, gin.H{"template": input.template, "plan": plan})
+}
+
+// UpdateScheduleTemplate changes a template and brings its unsold future screenings in line:
+// new showtimes are created, dropped ones removed, and the rest take the new hall, prices, format and audio.
+// Screenings with sold seats are left alone. Supports ?dry_run=true.
+func UpdateScheduleTemplate(c *gin.Context) {
+	adminID := c.GetString("userID")
+
+	current, err := services.NewScheduleService().GetTemplate(c.Request.Context(), c.Param("id"))
+	if err == services.ErrScheduleTemplateNotFound {
+		c.JSON(404, gin.H{"error": "Schedule template not found"})
+		return
+	}
+	if err != nil {
+		c.JSON(500, gin.H{"error": "Failed to fetch schedule template"})
+		return
+	}
+	if !allowTemplate(c, current) {
+		return
+	}
+
+	input, ok := bindScheduleTemplate(c)
+	if !ok {
+		return
+	}
+	if input.template.MovieID != current.MovieID {
+		c.JSON(400, gin.H{"error": "movie_id cannot be changed; create a new template instead"})
+		return
+	}
+	input.template.ID = current.ID
+	input.template.CreatedAt = current.CreatedAt
+
+	plan, ok := applySchedule(c, input)
+	if !ok {
+		return
+	}
+
+	services.LogInfo("SCHEDULE_TEMPLATE_UPDATED", adminID, scheduleAuditDetails(input.template, plan))
+
+	c.JSON(200, gin.H{"template": input.template, "plan": plan})
+}
+
+// DeleteScheduleTemplate deletes a template and its unsold future screenings. Supports ?dry_run=true.
+func DeleteScheduleTemplate(c *gin.Context) {
+	adminID := c.GetString("userID")
+	ctx := c.Request.Context()
+	scheduleService := services.NewScheduleService()
+
+	tmpl, err := scheduleService.GetTemplate(ctx, c.Param("id"))
+	if err == services.ErrScheduleTemplateNotFound {
+		c.JSON(404, gin.H{"error": "Schedule template not found"})
+		return
+	}
+	if err != nil {
+		c.JSON(500, gin.H{"error": "Failed to fetch schedule template"})
+		return
+	}
+	if !allowTemplate(c, tmpl) {
+		return
+	}
+
+	plan, err := scheduleService.PlanRemoval(ctx, tmpl.ID)
+	if err != nil {
+		c.JSON(500, gin.H{"error": "Failed to plan the schedule"})
+		return
+	}
+	if c.Query("dry_run") == "true" {
+		c.JSON(200, gin.H{"dry_run": true, "template": tmpl, "plan": plan})
+		return
+	}
+	if err := scheduleService.Apply(ctx, tmpl, nil, plan); err != nil {
+		c.JSON(500, gin.H{"error": "Failed to remove screenings"})
+		return
+	}
+	if err := scheduleService.DeleteTemplate(ctx, tmpl.ID); err != nil {
+		c.JSON(500, gin.H{"error": "Failed to delete schedule template"})
+		return
+	}
+
+	services.LogInfo("SCHEDULE_TEMPLATE_DELETED", adminID, scheduleAuditDetails(*tmpl, plan))
+
+	c.JSON(200, gin.H{"message": "Schedule template deleted", "plan": plan})
+}
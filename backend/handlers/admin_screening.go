@@ -0,0 +1,312 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"movie-ticket-backend/database"
+	"movie-ticket-backend/middleware"
+	"movie-ticket-backend/models"
+	"movie-ticket-backend/services"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// respondIfHallBusy checks the hall's schedule and writes a 409 listing the overlapping screenings.
+// Returns false when the response has been written.
+func respondIfHallBusy(c *gin.Context, hallID primitive.ObjectID, start time.Time, durationMin int, excludeIDs ...primitive.ObjectID) bool {
+	conflicts, err := services.NewScreeningService().FindHallConflicts(c.Request.Context(), hallID, start, durationMin, excludeIDs...)
+	if err != nil {
+		c.JSON(500, gin.H{"error": "Failed to check the hall schedule"})
+		return false
+	}
+	if len(conflicts) > 0 {
+		c.JSON(409, gin.H{"error": "Hall is already booked for this time", "conflicts": conflicts})
+		return false
+	}
+	return true
+}
+
+// lockHalls holds the halls' schedules until the returned function is called. Take it before
+// respondIfHallBusy and release it after the write, so concurrent requests cannot both pass the check.
+// Returns false when the response has been written.
+func lockHalls(c *gin.Context, hallIDs ...primitive.ObjectID) (func(), bool) {
+	unlock, err := services.NewLockService().LockHalls(c.Request.Context(), hallIDs...)
+	if err == services.ErrHallBusy {
+		c.JSON(409, gin.H{"error": "Hall schedule is being changed by another request, try again"})
+		return nil, false
+	}
+	if err != nil {
+		c.JSON(500, gin.H{"error": "Failed to lock the hall schedule"})
+		return nil, false
+	}
+	return unlock, true
+}
+
+// CreateScreening schedules a movie in a hall. Rejected if it overlaps another screening in the hall,
+// counting the movie duration plus the cleaning buffer.
+func CreateScreening(c *gin.Context) {
+	adminID := c.GetString("userID")
+	ctx := c.Request.Context()
+
+	var req struct {
+		MovieID string `json:"movie_id" binding:"required"`
+		screeningRequest
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(400, gin.H{"error": err.Error()})
+		return
+	}
+	if req.HallID == "" {
+		c.JSON(400, gin.H{"error": "hall_id is required"})
+		return
+	}
+	if err := req.screeningRequest.validate(); err != nil {
+		c.JSON(400, gin.H{"error": err.Error()})
+		return
+	}
+
+	movieID, err := primitive.ObjectIDFromHex(req.MovieID)
+	if err != nil {
+		c.JSON(400, gin.H{"error": "Invalid Movie ID"})
+		return
+	}
+	var movie models.Movie
+	filter := bson.M{"_id": movieID, "deleted_at": bson.M{"$exists": false}}
+	if err := database.Mongo.Collection("movies").FindOne(ctx, filter).Decode(&movie); err != nil {
+		c.JSON(404, gin.H{"error": "Movie not found"})
+		return
+	}
+	hall, err := services.NewCinemaService().GetHall(ctx, req.HallID)
+	if err == services.ErrHallNotFound {
+		c.JSON(404, gin.H{"error": "Hall not found"})
+		return
+	}
+	if err != nil {
+		c.JSON(500, gin.H{"error": "Failed to fetch hall"})
+		return
+	}
+	if !middleware.AllowCinema(c, hall.CinemaID, models.PermScreeningsWrite) {
+		return
+	}
+
+	unlock, ok := lockHalls(c, hall.ID)
+	if !ok {
+		return
+	}
+	defer unlock()
+	if !respondIfHallBusy(c, hall.ID, req.StartTime, movie.DurationMin) {
+		return
+	}
+
+	screening := newScreening(movie.ID, req.screeningRequest, map[string]*models.Hall{req.HallID: hall})
+	if err := services.NewScreeningService().Insert(ctx, screening); err != nil {
+		c.JSON(500, gin.H{"error": "Failed to create screening"})
+		return
+	}
+
+	services.LogInfo("SCREENING_CREATED", adminID, map[string]interface{}{
+		"screening_id": screening.ID.Hex(),
+		"movie_id":     movie.ID.Hex(),
+		"hall_id":      hall.ID.Hex(),
+		"start_time":   screening.StartTime,
+	})
+
+	c.JSON(201, screening)
+}
+
+// RescheduleScreening moves a screening to a new, future start time in the same hall.
+// If seats are sold the move needs "notify_customers": true, which emails every ticket holder.
+func RescheduleScreening(c *gin.Context) {
+	adminID := c.GetString("userID")
+	ctx := c.Request.Context()
+
+	var req struct {
+		StartTime       time.Time `json:"start_time" binding:"required"`
+		NotifyCustomers bool      `json:"notify_customers"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(400, gin.H{"error": err.Error()})
+		return
+	}
+	if !req.StartTime.After(time.Now()) {
+		c.JSON(400, gin.H{"error": "start_time must be in the future"})
+		return
+	}
+
+	screeningService := services.NewScreeningService()
+	screening, err := screeningService.GetByID(ctx, c.Param("id"))
+	if err == services.ErrScreeningNotFound {
+		c.JSON(404, gin.H{"error": "Screening not found"})
+		return
+	}
+	if err != nil {
+		c.JSON(500, gin.H{"error": "Failed to fetch screening"})
+		return
+	}
+	if !middleware.AllowCinema(c, screening.CinemaID, models.PermScreeningsWrite) {
+		return
+	}
+	if screening.StartTime.Equal(req.StartTime) {
+		c.JSON(200, screening)
+		return
+	}
+
+	sold := screening.SoldSeats()
+	if sold > 0 && !req.NotifyCustomers {
+		c.JSON(409, gin.H{"error": fmt.Sprintf("Screening has %d sold seats; set notify_customers to move it and email the ticket holders", sold)})
+		return
+	}
+
+	var movie models.Movie
+	if err := database.Mongo.Collection("movies").FindOne(ctx, bson.M{"_id": screening.MovieID}).Decode(&movie); err != nil {
+		c.JSON(500, gin.H{"error": "Failed to fetch movie"})
+		return
+	}
+	unlock, ok := lockHalls(c, screening.HallID)
+	if !ok {
+		return
+	}
+	defer unlock()
+	if !screening.HallID.IsZero() && !respondIfHallBusy(c, screening.HallID, req.StartTime, movie.DurationMin, screening.ID) {
+		return
+	}
+
+	oldStart := screening.StartTime
+	// Without notify_customers a seat sold since the check above must still block the move
+	moved, err := screeningService.Reschedule(ctx, screening, req.StartTime, !req.NotifyCustomers)
+	if err != nil {
+		c.JSON(500, gin.H{"error": "Failed to reschedule screening"})
+		return
+	}
+	if !moved {
+		c.JSON(409, gin.H{"error": "Screening changed while rescheduling (seats sold or removed); set notify_customers to move a sold screening"})
+		return
+	}
+	screening.StartTime = req.StartTime
+
+	if req.NotifyCustomers {
+		services.GetQueueService().PublishEvent("SCREENING_RESCHEDULED", services.ScreeningRescheduledEvent{
+			ScreeningID:  screening.ID.Hex(),
+			MovieID:      screening.MovieID.Hex(),
+			OldStartTime: oldStart,
+			NewStartTime: req.StartTime,
+		})
+	}
+
+	services.LogInfo("SCREENING_RESCHEDULED", adminID, map[string]interface{}{
+		"screening_id":   screening.ID.Hex(),
+		"movie_id":       screening.MovieID.Hex(),
+		"old_start_time": oldStart,
+		"new_start_time": req.StartTime,
+		"sold_seats":     sold,
+	})
+
+	c.JSON(200, screening)
+}
+
+// DeleteScreening soft deletes a screening. Refused once any seat is sold.
+func DeleteScreening(c *gin.Context) {
+	adminID := c.GetString("userID")
+
+	id, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.JSON(400, gin.H{"error": "Invalid Screening ID"})
+		return
+	}
+
+	screeningService := services.NewScreeningService()
+	screening, err := screeningService.GetByID(context.TODO(), id.Hex())
+	if err == services.ErrScreeningNotFound {
+		c.JSON(404, gin.H{"error": "Screening not found"})
+		return
+	}
+	if err != nil {
+		c.JSON(500, gin.H{"error": "Failed to fetch screening"})
+		return
+	}
+	if !middleware.AllowCinema(c, screening.CinemaID, models.PermScreeningsWrite) {
+		return
+	}
+	deleted, err := screeningService.SoftDelete(context.TODO(), id)
+	if err != nil {
+		c.JSON(500, gin.H{"error": "Failed to delete screening"})
+		return
+	}
+	if !deleted {
+		c.JSON(409, gin.H{"error": "Screening has sold seats and cannot be deleted"})
+		return
+	}
+
+	services.LogInfo("SCREENING_DELETED", adminID, map[string]interface{}{
+		"screening_id": id.Hex(),
+	})
+
+	c.JSON(200, gin.H{"message": "Screening deleted"})
+}
+
+// CancelScreening cancels a screening the cinema cannot show (e.g. broken projector): seat and payment
+// locks are released, every ticket is refunded and the customers are emailed. Calling it again on a
+// cancelled screening retries the refunds that failed.
+func CancelScreening(c *gin.Context) {
+	adminID := c.GetString("userID")
+
+	id, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.JSON(400, gin.H{"error": "Invalid Screening ID"})
+		return
+	}
+	var req struct {
+		Reason string `json:"reason" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(400, gin.H{"error": err.Error()})
+		return
+	}
+
+	screeningService := services.NewScreeningService()
+	screening, err := screeningService.GetCancellable(c.Request.Context(), id.Hex())
+	if err == services.ErrScreeningNotFound {
+		c.JSON(404, gin.H{"error": "Screening not found"})
+		return
+	}
+	if err != nil {
+		c.JSON(500, gin.H{"error": "Failed to fetch screening"})
+		return
+	}
+	if !middleware.AllowCinema(c, screening.CinemaID, models.PermScreeningsWrite, models.PermBookingsRefund) {
+		return
+	}
+
+	result, err := screeningService.Cancel(c.Request.Context(), screening, req.Reason, adminID)
+	if err == services.ErrScreeningNotFound {
+		c.JSON(404, gin.H{"error": "Screening not found"})
+		return
+	}
+	if err != nil {
+		services.LogError("SYSTEM_ERROR", adminID, err, map[string]interface{}{"context": "cancel_screening", "screening_id": id.Hex()})
+		if result == nil {
+			c.JSON(500, gin.H{"error": "Failed to cancel screening"})
+			return
+		}
+		c.JSON(500, gin.H{"error": "Screening cancelled but not every booking was processed; call cancel again to retry", "result": result})
+		return
+	}
+
+	services.LogInfo("SCREENING_CANCELLED", adminID, map[string]interface{}{
+		"screening_id":      id.Hex(),
+		"movie_id":          screening.MovieID.Hex(),
+		"start_time":        screening.StartTime,
+		"reason":            screening.CancelReason,
+		"already_cancelled": result.AlreadyCancelled,
+		"released_locks":    result.ReleasedLocks,
+		"refunded_bookings": result.RefundedBookings,
+		"refunded_amount":   result.RefundedAmount,
+		"failed_refunds":    len(result.FailedRefunds),
+	})
+
+	// Failed refunds are listed in the result; the bookings stay SUCCESS until a retry refunds them
+	c.JSON(200, gin.H{"screening": screening, "result": result})
+}
@@ -0,0 +1,330 @@
+package handlers
+
+import (
+	"context"
+	"movie-ticket-backend/database"
+	"movie-ticket-backend/models"
+	"movie-ticket-backend/services"
+	"regexp"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// parsePagination reads page/limit query params (limit capped at 100)
+func parsePagination(c *gin.Context) (page, limit, skip int) {
+	page, _ = strconv.Atoi(c.DefaultQuery("page", "1"))
+	limit, _ = strconv.Atoi(c.DefaultQuery("limit", "10"))
+	if page < 1 {
+		page = 1
+	}
+	if limit < 1 {
+		limit = 10
+	}
+	if limit > 100 {
+		limit = 100
+	}
+	return page, limit, (page - 1) * limit
+}
+
+func paginationMeta(total int64, page, limit int) gin.H {
+	return gin.H{
+		"total": total,
+		"page":  page,
+		"limit": limit,
+		"pages": (int(total) + limit - 1) / limit,
+	}
+}
+
+// loadTargetUser resolves :id and writes the error response itself
+func loadTargetUser(c *gin.Context) (*models.User, bool) {
+	user, err := services.NewUserService().GetUserByID(context.TODO(), c.Param("id"))
+	if err == services.ErrUserNotFound {
+		c.JSON(404, gin.H{"error": "User not found"})
+		return nil, false
+	}
+	if err != nil {
+		c.JSON(500, gin.H{"error": "Failed to fetch user"})
+		return nil, false
+	}
+	return user, true
+}
+
+// GetUsers searches users by name/email with role/status filters and pagination
+func GetUsers(c *gin.Context) {
+	page, limit, skip := parsePagination(c)
+
+	filter := bson.M{}
+	if q := c.Query("q"); q != "" {
+		pattern := primitive.Regex{Pattern: regexp.QuoteMeta(q), Options: "i"}
+		filter["$or"] = bson.A{bson.M{"name": pattern}, bson.M{"email": pattern}}
+	}
+	if role := c.Query("role"); role != "" {
+		// Primary role or any extra assignment
+		filter["$and"] = bson.A{bson.M{"$or": bson.A{
+			bson.M{"role": role},
+			bson.M{"role_assignments.role": role},
+		}}}
+	}
+	switch models.UserStatus(c.Query("status")) {
+	case models.UserSuspended:
+		filter["status"] = models.UserSuspended
+	case models.UserActive:
+		filter["status"] = bson.M{"$ne": models.UserSuspended}
+	}
+
+	collection := database.Mongo.Collection("users")
+	total, err := collection.CountDocuments(context.TODO(), filter)
+	if err != nil {
+		c.JSON(500, gin.H{"error": "Failed to count users"})
+		return
+	}
+
+	opts := options.Find().SetSort(bson.D{{Key: "created_at", Value: -1}}).SetSkip(int64(skip)).SetLimit(int64(limit))
+	cursor, err := collection.Find(context.TODO(), filter, opts)
+	if err != nil {
+		c.JSON(500, gin.H{"error": "Failed to fetch users"})
+		return
+	}
+	users := []models.User{}
+	if err = cursor.All(context.TODO(), &users); err != nil {
+		c.JSON(500, gin.H{"error": "Failed to decode users"})
+		return
+	}
+
+	c.JSON(200, gin.H{
+		"data": users,
+		"meta": paginationMeta(total, page, limit),
+	})
+}
+
+// GetUser returns a single user with the resolved permission list
+func GetUser(c *gin.Context) {
+	user, ok := loadTargetUser(c)
+	if !ok {
+		return
+	}
+	c.JSON(200, gin.H{
+		"user":        user,
+		"permissions": user.Permissions(),
+	})
+}
+
+// UpdateUserRoles promotes/demotes a user and sets per-cinema role assignments
+func UpdateUserRoles(c *gin.Context) {
+	adminID := c.GetString("userID")
+
+	var req struct {
+		Role            models.Role             `json:"role" binding:"required"`
+		RoleAssignments []models.RoleAssignment `json:"role_assignments"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(400, gin.H{"error": err.Error()})
+		return
+	}
+	if !models.IsValidRole(req.Role) {
+		c.JSON(400, gin.H{"error": "Invalid role"})
+		return
+	}
+	for _, a := range req.RoleAssignments {
+		if !models.IsValidRole(a.Role) {
+			c.JSON(400, gin.H{"error": "Invalid role in role_assignments"})
+			return
+		}
+	}
+
+	user, ok := loadTargetUser(c)
+	if !ok {
+		return
+	}
+	if user.IsDeleted() {
+		c.JSON(409, gin.H{"error": "User has been deleted"})
+		return
+	}
+
+	// Prevent an admin from locking themselves out
+	if user.ID.Hex() == adminID && user.Role == models.RoleAdmin && req.Role != models.RoleAdmin {
+		c.JSON(409, gin.H{"error": "You cannot remove your own ADMIN role"})
+		return
+	}
+
+	if err := services.NewUserService().UpdateRoles(context.TODO(), user.ID, req.Role, req.RoleAssignments); err != nil {
+		c.JSON(500, gin.H{"error": "Failed to update roles"})
+		return
+	}
+
+	services.LogInfo("USER_ROLE_CHANGED", adminID, map[string]interface{}{
+		"target_user_id":       user.ID.Hex(),
+		"old_role":             user.Role,
+		"new_role":             req.Role,
+		"old_role_assignments": user.RoleAssignments,
+		"new_role_assignments": req.RoleAssignments,
+	})
+
+	c.JSON(200, gin.H{"message": "Roles updated", "role": req.Role, "role_assignments": req.RoleAssignments})
+}
+
+// SuspendUser blocks an account; RequireAuth rejects suspended users on the next request
+func SuspendUser(c *gin.Context) {
+	adminID := c.GetString("userID")
+
+	var req struct {
+		Reason string `json:"reason"`
+	}
+	_ = c.ShouldBindJSON(&req)
+
+	user, ok := loadTargetUser(c)
+	if !ok {
+		return
+	}
+	if user.IsDeleted() {
+		c.JSON(409, gin.H{"error": "User has been deleted"})
+		return
+	}
+	if user.ID.Hex() == adminID {
+		c.JSON(409, gin.H{"error": "You cannot suspend your own account"})
+		return
+	}
+
+	if err := services.NewUserService().SetStatus(context.TODO(), user.ID, models.UserSuspended, req.Reason); err != nil {
+		c.JSON(500, gin.H{"error": "Failed to suspend user"})
+		return
+	}
+
+	// Kick the user out right away instead of waiting for the next request
+	revoked, err := services.NewSessionService().RevokeAllSessions(user.ID.Hex())
+	if err != nil {
+		services.LogError("SYSTEM_ERROR", adminID, err, map[string]interface{}{"context": "revoke_sessions", "target_user_id": user.ID.Hex()})
+	}
+
+	services.LogInfo("USER_SUSPENDED", adminID, map[string]interface{}{
+		"target_user_id":   user.ID.Hex(),
+		"reason":           req.Reason,
+		"sessions_revoked": revoked,
+	})
+
+	c.JSON(200, gin.H{"message": "User suspended", "status": models.UserSuspended})
+}
+
+// ReactivateUser lifts a suspension
+func ReactivateUser(c *gin.Context) {
+	adminID := c.GetString("userID")
+
+	user, ok := loadTargetUser(c)
+	if !ok {
+		return
+	}
+	if user.IsDeleted() {
+		c.JSON(409, gin.H{"error": "User has been deleted"})
+		return
+	}
+
+	if err := services.NewUserService().SetStatus(context.TODO(), user.ID, models.UserActive, ""); err != nil {
+		c.JSON(500, gin.H{"error": "Failed to reactivate user"})
+		return
+	}
+
+	services.LogInfo("USER_REACTIVATED", adminID, map[string]interface{}{
+		"target_user_id": user.ID.Hex(),
+	})
+
+	c.JSON(200, gin.H{"message": "User reactivated", "status": models.UserActive})
+}
+
+// RevokeUserSessions logs a user out of every device
+func RevokeUserSessions(c *gin.Context) {
+	adminID := c.GetString("userID")
+
+	user, ok := loadTargetUser(c)
+	if !ok {
+		return
+	}
+
+	count, err := services.NewSessionService().RevokeAllSessions(user.ID.Hex())
+	if err != nil {
+		c.JSON(500, gin.H{"error": "Failed to revoke sessions"})
+		return
+	}
+
+	services.LogInfo("USER_SESSIONS_REVOKED", adminID, map[string]interface{}{
+		"target_user_id":   user.ID.Hex(),
+		"sessions_revoked": count,
+	})
+
+	c.JSON(200, gin.H{"message": "Sessions revoked", "sessions_revoked": count})
+}
+
+// GetUserBookings lists one user's bookings, newest first
+func GetUserBookings(c *gin.Context) {
+	user, ok := loadTargetUser(c)
+	if !ok {
+		return
+	}
+	page, limit, skip := parsePagination(c)
+
+	filter := bson.M{"user_id": user.ID.Hex()}
+	collection := database.Mongo.Collection("bookings")
+	total, err := collection.CountDocuments(context.TODO(), filter)
+	if err != nil {
+		c.JSON(500, gin.H{"error": "Failed to count bookings"})
+		return
+	}
+
+	opts := options.Find().SetSort(bson.D{{Key: "created_at", Value: -1}}).SetSkip(int64(skip)).SetLimit(int64(limit))
+	cursor, err := collection.Find(context.TODO(), filter, opts)
+	if err != nil {
+		c.JSON(500, gin.H{"error": "Failed to fetch bookings"})
+		return
+	}
+	bookings := []models.Booking{}
+	if err = cursor.All(context.TODO(), &bookings); err != nil {
+		c.JSON(500, gin.H{"error": "Failed to decode bookings"})
+		return
+	}
+
+	c.JSON(200, gin.H{
+		"data": bookings,
+		"meta": paginationMeta(total, page, limit),
+	})
+}
+
+// GetUserAuditTrail lists audit entries where the user acted or was the target of an admin action
+func GetUserAuditTrail(c *gin.Context) {
+	user, ok := loadTargetUser(c)
+	if !ok {
+		return
+	}
+	page, limit, skip := parsePagination(c)
+
+	userIDHex := user.ID.Hex()
+	filter := bson.M{"$or": bson.A{
+		bson.M{"user_id": userIDHex},
+		bson.M{"details.target_user_id": userIDHex},
+	}}
+	collection := database.Mongo.Collection("audit_logs")
+	total, err := collection.CountDocuments(context.TODO(), filter)
+	if err != nil {
+		c.JSON(500, gin.H{"error": "Failed to count audit logs"})
+		return
+	}
+
+	opts := options.Find().SetSort(bson.D{{Key: "timestamp", Value: -1}}).SetSkip(int64(skip)).SetLimit(int64(limit))
+	cursor, err := collection.Find(context.TODO(), filter, opts)
+	if err != nil {
+		c.JSON(500, gin.H{"error": "Failed to fetch audit logs"})
+		return
+	}
+	logs := []models.AuditLog{}
+	if err = cursor.All(context.TODO(), &logs); err != nil {
+		c.JSON(500, gin.H{"error": "Failed to decode audit logs"})
+		return
+	}
+
+	c.JSON(200, gin.H{
+		"data": logs,
+		"meta": paginationMeta(total, page, limit),
+	})
+}
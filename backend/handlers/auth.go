@@ -2,172 +2,347 @@ package handlers
 
 import (
 	"context"
-	"encoding/json"
-	"fmt"
-	"io"
 	"movie-ticket-backend/config"
-	"movie-ticket-backend/database"
+	"movie-ticket-backend/middleware"
 	"movie-ticket-backend/models"
+	"movie-ticket-backend/services"
 	"net/http"
 	"net/url"
-	"strings"
 	"time"
 
 	"github.com/gin-gonic/gin"
-	"go.mongodb.org/mongo-driver/bson"
-	"go.mongodb.org/mongo-driver/bson/primitive"
-	"golang.org/x/oauth2"
-	"golang.org/x/oauth2/google"
 )
 
-// Google OAuth
-var googleOauthConfig *oauth2.Config
+// oauthNonceCookie binds the OAuth state to the browser that started the login (CSRF protection)
+const oauthNonceCookie = "oauth_nonce"
 
-func getOAuthConfig() *oauth2.Config {
-	if googleOauthConfig == nil {
-		googleOauthConfig = &oauth2.Config{
-			RedirectURL:  config.AppConfig.GoogleRedirectURL,
-			ClientID:     config.AppConfig.GoogleClientID,
-			ClientSecret: config.AppConfig.GoogleClientSecret,
-			Scopes:       []string{"https://www.googleapis.com/auth/userinfo.email", "https://www.googleapis.com/auth/userinfo.profile"},
-			Endpoint:     google.Endpoint,
-		}
-	}
-	return googleOauthConfig
+// GetIdentityProviders lists the enabled login methods
+func GetIdentityProviders(c *gin.Context) {
+	c.JSON(200, gin.H{"providers": services.IdentityProviderNames()})
 }
 
-func GoogleLogin(c *gin.Context) {
+// OAuthLogin redirects to a browser based identity provider (google, oidc, ...)
+func OAuthLogin(c *gin.Context) {
+	provider, ok := getRedirectProvider(c)
+	if !ok {
+		return
+	}
+
 	redirectTo := c.Query("redirect_to")
-	if redirectTo == "" {
-		redirectTo = "http://localhost:5173/"
+	if redirectTo == "" || !services.IsAllowedRedirect(redirectTo) {
+		redirectTo = config.AppConfig.DefaultRedirectURL
+	}
+
+	state, nonce, err := services.NewOAuthState(provider.Name(), redirectTo)
+	if err != nil {
+		c.JSON(500, gin.H{"error": "Failed to create login state"})
+		return
 	}
-	// Use redirectTo as state. In production, sign/encrypt this!
-	url := getOAuthConfig().AuthCodeURL(redirectTo)
-	c.Redirect(http.StatusTemporaryRedirect, url)
+
+	// Lax so the cookie is sent on the top-level redirect back from the provider
+	c.SetSameSite(http.SameSiteLaxMode)
+	c.SetCookie(oauthNonceCookie, nonce, 600, "/api/auth", "", c.Request.TLS != nil, true)
+
+	c.Redirect(http.StatusTemporaryRedirect, provider.AuthCodeURL(state))
 }
 
-func GoogleCallback(c *gin.Context) {
-	state := c.Query("state")
-	if state == "" {
+// OAuthCallback completes the login started by OAuthLogin
+func OAuthCallback(c *gin.Context) {
+	provider, ok := getRedirectProvider(c)
+	if !ok {
+		return
+	}
+
+	nonce, _ := c.Cookie(oauthNonceCookie)
+	state, err := services.VerifyOAuthState(c.Query("state"), nonce)
+	if err != nil || state.Provider != provider.Name() {
+		services.LogWarn("LOGIN_STATE_INVALID", "", map[string]interface{}{"ip": c.ClientIP(), "provider": provider.Name()})
 		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid state"})
 		return
 	}
+	// Nonce is single use
+	c.SetCookie(oauthNonceCookie, "", -1, "/api/auth", "", c.Request.TLS != nil, true)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 15*time.Second)
+	defer cancel()
 
-	code := c.Query("code")
-	oauthConfig := getOAuthConfig()
-	token, err := oauthConfig.Exchange(context.Background(), code)
+	identity, err := provider.Exchange(ctx, c.Query("code"))
 	if err != nil {
+		services.LogError("LOGIN_FAILED", "", err, map[string]interface{}{"provider": provider.Name()})
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Code exchange failed"})
 		return
 	}
 
-	resp, err := http.Get("https://www.googleapis.com/oauth2/v2/userinfo?access_token=" + token.AccessToken)
+	user, err := services.NewUserService().UpsertUserFromIdentity(ctx, identity)
+	if err == services.ErrUnverifiedEmailConflict {
+		c.JSON(409, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		c.JSON(500, gin.H{"error": "DB create error"})
+		return
+	}
+	if user.IsSuspended() {
+		c.JSON(403, gin.H{"error": "Account suspended"})
+		return
+	}
+	services.LogInfo("LOGIN_SUCCESS", user.ID.Hex(), map[string]interface{}{"provider": provider.Name(), "ip": c.ClientIP()})
+
+	// Issue signed access token + refresh token
+	tokens, err := services.NewTokenService().IssueTokenPair(user)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "User data fetch failed"})
+		services.LogError("SYSTEM_ERROR", user.ID.Hex(), err, map[string]interface{}{"context": "issue_token"})
+		c.JSON(500, gin.H{"error": "Failed to issue token"})
 		return
 	}
-	defer resp.Body.Close()
 
-	userData, err := io.ReadAll(resp.Body)
+	// Hand the tokens over through a one-time code instead of the redirect URL
+	loginCode, err := services.NewTokenService().CreateLoginCode(*tokens, user)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "JSON parse failed"})
+		c.JSON(500, gin.H{"error": "Failed to create login code"})
 		return
 	}
 
-	// Parse Google User Data
-	var googleUser struct {
-		ID            string `json:"id"`
-		Email         string `json:"email"`
-		VerifiedEmail bool   `json:"verified_email"`
-		Name          string `json:"name"`
-		Picture       string `json:"picture"`
+	// redirect_to was allowlisted at login time and is covered by the state signature
+	parsedRedirect, err := url.Parse(state.RedirectTo)
+	if err != nil || !services.IsAllowedRedirect(state.RedirectTo) {
+		parsedRedirect, _ = url.Parse(config.AppConfig.DefaultRedirectURL)
 	}
 
-	if err := json.Unmarshal(userData, &googleUser); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "JSON parse failed"})
+	// Set (not Add) so stale auth params from a previous login are replaced
+	q := parsedRedirect.Query()
+	q.Set("auth", "success")
+	q.Set("code", loginCode)
+	parsedRedirect.RawQuery = q.Encode()
+
+	c.Redirect(http.StatusTemporaryRedirect, parsedRedirect.String())
+}
+
+func getRedirectProvider(c *gin.Context) (services.RedirectIdentityProvider, bool) {
+	p, err := services.GetIdentityProvider(c.Param("provider"))
+	if err != nil {
+		c.JSON(404, gin.H{"error": "Unknown identity provider"})
+		return nil, false
+	}
+	provider, ok := p.(services.RedirectIdentityProvider)
+	if !ok {
+		c.JSON(400, gin.H{"error": "Provider does not support redirect login"})
+		return nil, false
+	}
+	return provider, true
+}
+
+func getLocalProvider(c *gin.Context) (*services.LocalProvider, bool) {
+	p, err := services.GetIdentityProvider("local")
+	if err != nil {
+		c.JSON(404, gin.H{"error": "Email/password login is disabled"})
+		return nil, false
+	}
+	return p.(*services.LocalProvider), true
+}
+
+// LocalRegister creates an email/password account (must be verified before login)
+func LocalRegister(c *gin.Context) {
+	local, ok := getLocalProvider(c)
+	if !ok {
+		return
+	}
+
+	var req struct {
+		Email    string `json:"email" binding:"required,email"`
+		Password string `json:"password" binding:"required"`
+		Name     string `json:"name" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(400, gin.H{"error": err.Error()})
+		return
+	}
+	if !middleware.RateLimitAccount(c, req.Email) {
+		return
+	}
+
+	user, err := local.Register(c.Request.Context(), req.Email, req.Password, req.Name)
+	switch err {
+	case nil:
+	case services.ErrPasswordPolicy:
+		c.JSON(400, gin.H{"error": err.Error()})
+		return
+	case services.ErrEmailAlreadyRegistered:
+		c.JSON(409, gin.H{"error": err.Error()})
+		return
+	default:
+		c.JSON(500, gin.H{"error": "Failed to register"})
 		return
 	}
 
-	// Dump Data to Console (as requested)
-	fmt.Printf("--- Google User Log ---\nID: %s\nEmail: %s\nName: %s\nPicture: %s\n-----------------------\n",
-		googleUser.ID, googleUser.Email, googleUser.Name, googleUser.Picture)
+	services.LogInfo("USER_REGISTERED", user.ID.Hex(), map[string]interface{}{"provider": "local"})
+	c.JSON(201, gin.H{"message": "Registered. Please check your email to verify your account."})
+}
 
-	// Upsert User in DB
-	collection := database.Mongo.Collection("users")
-	var user models.User
+// LocalVerifyEmail is the link sent in the verification email
+func LocalVerifyEmail(c *gin.Context) {
+	local, ok := getLocalProvider(c)
+	if !ok {
+		return
+	}
 
-	// Check if user exists
-	err = collection.FindOne(context.TODO(), bson.M{"email": googleUser.Email}).Decode(&user)
+	target, _ := url.Parse(config.AppConfig.DefaultRedirectURL)
+	q := target.Query()
 
+	user, err := local.VerifyEmail(c.Request.Context(), c.Query("token"))
 	if err != nil {
-		// New User logic: Check if first user
-		count, _ := collection.CountDocuments(context.TODO(), bson.M{})
-		role := models.RoleUser
-		if count == 0 {
-			role = models.RoleAdmin // First user gets ADMIN
-		}
-
-		user = models.User{
-			ID:         primitive.NewObjectID(),
-			Email:      googleUser.Email,
-			Name:       googleUser.Name,
-			PictureURL: googleUser.Picture,
-			Role:       role,
-			CreatedAt:  time.Now(),
-			UpdatedAt:  time.Now(),
-		}
-		_, err := collection.InsertOne(context.TODO(), user)
-		if err != nil {
-			c.JSON(500, gin.H{"error": "DB create error"})
-			return
-		}
-	} else {
-		// Update existing user info
-		update := bson.M{
-			"$set": bson.M{
-				"name":        googleUser.Name,
-				"picture_url": googleUser.Picture,
-				"updated_at":  time.Now(),
-			},
-		}
-		collection.UpdateOne(context.TODO(), bson.M{"_id": user.ID}, update)
-
-		// Refresh struct fields
-		user.Name = googleUser.Name
-		user.PictureURL = googleUser.Picture
-	}
-
-	// Generate Redirect (passing real data)
-	appToken := "real-jwt-" + user.ID.Hex() // Use specific name to avoid collision
-
-	// Parse the state back to a URL
-	parsedState, err := url.Parse(state)
-	var finalRedirectBase string
-	if err != nil || parsedState.Host == "" {
-		finalRedirectBase = "http://localhost:5173/"
+		q.Set("email_verified", "failed")
 	} else {
-		// Clean existing auth params to avoid duplicates
-		q := parsedState.Query()
-		q.Del("google_auth")
-		q.Del("token")
-		q.Del("user_id")
-		q.Del("role")
-		q.Del("name")
-		q.Del("picture")
-		q.Del("email")
-		parsedState.RawQuery = q.Encode()
-		finalRedirectBase = parsedState.String()
-	}
-
-	// Join with data params
-	separator := "?"
-	if strings.Contains(finalRedirectBase, "?") {
-		separator = "&"
-	}
-
-	redirectURL := fmt.Sprintf("%s%sgoogle_auth=success&token=%s&user_id=%s&role=%s&name=%s&picture=%s&email=%s",
-		finalRedirectBase, separator, appToken, user.ID.Hex(), user.Role,
-		url.QueryEscape(user.Name), url.QueryEscape(user.PictureURL), url.QueryEscape(user.Email))
-
-	c.Redirect(http.StatusTemporaryRedirect, redirectURL)
+		services.LogInfo("EMAIL_VERIFIED", user.ID.Hex(), nil)
+		q.Set("email_verified", "success")
+	}
+	target.RawQuery = q.Encode()
+	c.Redirect(http.StatusTemporaryRedirect, target.String())
+}
+
+// LocalLogin signs in with email/password and returns the token pair directly
+func LocalLogin(c *gin.Context) {
+	local, ok := getLocalProvider(c)
+	if !ok {
+		return
+	}
+
+	var req struct {
+		Email    string `json:"email" binding:"required"`
+		Password string `json:"password" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(400, gin.H{"error": err.Error()})
+		return
+	}
+	if !middleware.RateLimitAccount(c, req.Email) {
+		return
+	}
+
+	identity, err := local.Authenticate(c.Request.Context(), req.Email, req.Password)
+	switch err {
+	case nil:
+	case services.ErrInvalidCredentials:
+		services.LogWarn("LOGIN_FAILED", "", map[string]interface{}{"provider": "local", "ip": c.ClientIP()})
+		c.JSON(401, gin.H{"error": err.Error()})
+		return
+	case services.ErrEmailNotVerified:
+		c.JSON(403, gin.H{"error": err.Error()})
+		return
+	default:
+		c.JSON(500, gin.H{"error": "Login failed"})
+		return
+	}
+
+	user, err := services.NewUserService().UpsertUserFromIdentity(c.Request.Context(), identity)
+	if err != nil {
+		c.JSON(500, gin.H{"error": "Login failed"})
+		return
+	}
+	if user.IsSuspended() {
+		c.JSON(403, gin.H{"error": "Account suspended"})
+		return
+	}
+
+	tokens, err := services.NewTokenService().IssueTokenPair(user)
+	if err != nil {
+		services.LogError("SYSTEM_ERROR", user.ID.Hex(), err, map[string]interface{}{"context": "issue_token"})
+		c.JSON(500, gin.H{"error": "Failed to issue token"})
+		return
+	}
+
+	c.JSON(200, loginResponse(*tokens, user))
+}
+
+// RefreshToken exchanges a refresh token for a new token pair (the old refresh token is revoked)
+func RefreshToken(c *gin.Context) {
+	var req struct {
+		RefreshToken string `json:"refresh_token" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(400, gin.H{"error": err.Error()})
+		return
+	}
+
+	tokens, user, err := services.NewTokenService().RotateRefreshToken(req.RefreshToken)
+	if err == services.ErrInvalidRefreshToken {
+		c.JSON(401, gin.H{"error": "Invalid or expired refresh token"})
+		return
+	}
+	if err != nil {
+		services.LogError("SYSTEM_ERROR", "", err, map[string]interface{}{"context": "refresh_token"})
+		c.JSON(500, gin.H{"error": "Failed to refresh token"})
+		return
+	}
+
+	c.JSON(200, gin.H{
+		"token":         tokens.AccessToken,
+		"refresh_token": tokens.RefreshToken,
+		"expires_at":    tokens.ExpiresAt,
+		"role":          user.Role,
+	})
+}
+
+// Logout revokes the current session (its access and refresh tokens)
+func Logout(c *gin.Context) {
+	userID := c.GetString("userID")
+	if err := services.NewSessionService().RevokeSession(c.GetString("sessionID"), userID); err != nil {
+		c.JSON(500, gin.H{"error": "Failed to log out"})
+		return
+	}
+
+	services.LogInfo("LOGOUT", userID, nil)
+	c.JSON(200, gin.H{"message": "Logged out"})
+}
+
+// LogoutAll revokes every session of the current user ("log out all devices")
+func LogoutAll(c *gin.Context) {
+	userID := c.GetString("userID")
+	count, err := services.NewSessionService().RevokeAllSessions(userID)
+	if err != nil {
+		c.JSON(500, gin.H{"error": "Failed to log out"})
+		return
+	}
+
+	services.LogInfo("LOGOUT_ALL", userID, map[string]interface{}{"sessions_revoked": count})
+	c.JSON(200, gin.H{"message": "Logged out of all devices", "sessions_revoked": count})
+}
+
+// ExchangeLoginCode trades the one-time code from the OAuth redirect for the token pair
+func ExchangeLoginCode(c *gin.Context) {
+	var req struct {
+		Code string `json:"code" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(400, gin.H{"error": err.Error()})
+		return
+	}
+
+	result, err := services.NewTokenService().ExchangeLoginCode(req.Code)
+	if err == services.ErrInvalidLoginCode {
+		c.JSON(401, gin.H{"error": "Invalid or expired login code"})
+		return
+	}
+	if err != nil {
+		c.JSON(500, gin.H{"error": "Failed to exchange login code"})
+		return
+	}
+
+	c.JSON(200, loginResponse(result.Tokens, result.User))
+}
+
+func loginResponse(tokens services.TokenPair, user models.User) gin.H {
+	return gin.H{
+		"token":         tokens.AccessToken,
+		"refresh_token": tokens.RefreshToken,
+		"expires_at":    tokens.ExpiresAt,
+		"user": gin.H{
+			"user_id":     user.ID.Hex(),
+			"role":        user.Role,
+			"name":        user.Name,
+			"email":       user.Email,
+			"picture":     user.PictureURL,
+			"permissions": user.Permissions(),
+		},
+	}
 }
@@ -0,0 +1,128 @@
+package handlers
+
+import (
+	"fmt"
+	"movie-ticket-backend/models"
+	"movie-ticket-backend/services"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// GetMyProfile returns the caller's account
+func GetMyProfile(c *gin.Context) {
+	user, _ := c.Get("user")
+	c.JSON(200, user)
+}
+
+// UpdateMyProfile changes the caller's name and date of birth.
+// The date of birth is needed to book age-restricted screenings.
+func UpdateMyProfile(c *gin.Context) {
+	userID := c.GetString("userID")
+	current, _ := c.Get("user")
+	user, _ := current.(models.User)
+
+	var req struct {
+		Name        *string `json:"name"`
+		DateOfBirth *string `json:"date_of_birth"` // YYYY-MM-DD, "" clears it
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(400, gin.H{"error": err.Error()})
+		return
+	}
+
+	name, dob := user.Name, user.DateOfBirth
+	var changed []string
+	if req.Name != nil {
+		name = strings.TrimSpace(*req.Name)
+		if name == "" {
+			c.JSON(400, gin.H{"error": "name cannot be empty"})
+			return
+		}
+		changed = append(changed, "name")
+	}
+	if req.DateOfBirth != nil {
+		dob = strings.TrimSpace(*req.DateOfBirth)
+		if dob != "" {
+			parsed, err := time.Parse("2006-01-02", dob)
+			if err != nil {
+				c.JSON(400, gin.H{"error": "Invalid date_of_birth, expected YYYY-MM-DD"})
+				return
+			}
+			if parsed.After(time.Now()) || parsed.Year() < 1900 {
+				c.JSON(400, gin.H{"error": "date_of_birth is out of range"})
+				return
+			}
+		}
+		changed = append(changed, "date_of_birth")
+	}
+
+	updated, err := services.NewUserService().UpdateProfile(c.Request.Context(), user.ID, name, dob)
+	if err != nil {
+		c.JSON(500, gin.H{"error": "Failed to update profile"})
+		return
+	}
+
+	// Only the field names are logged, never the values
+	services.LogInfo("PROFILE_UPDATED", userID, map[string]interface{}{"fields": changed})
+
+	c.JSON(200, updated)
+}
+
+// ExportMyData returns the caller's personal data as JSON, or as a zip with ?format=zip
+func ExportMyData(c *gin.Context) {
+	userID := c.GetString("userID")
+
+	export, err := services.NewPrivacyService().Export(c.Request.Context(), userID)
+	if err != nil {
+		services.LogError("SYSTEM_ERROR", userID, err, map[string]interface{}{"context": "data_export"})
+		c.JSON(500, gin.H{"error": "Failed to export data"})
+		return
+	}
+
+	services.LogInfo("DATA_EXPORTED", userID, map[string]interface{}{"format": c.DefaultQuery("format", "json")})
+
+	filename := fmt.Sprintf("my-data-%s", export.ExportedAt.Format("20060102-150405"))
+	if c.Query("format") == "zip" {
+		c.Header("Content-Type", "application/zip")
+		c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="%s.zip"`, filename))
+		if err := export.WriteZip(c.Writer); err != nil {
+			services.LogError("SYSTEM_ERROR", userID, err, map[string]interface{}{"context": "data_export_zip"})
+		}
+		return
+	}
+
+	c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="%s.json"`, filename))
+	c.IndentedJSON(200, export)
+}
+
+// DeleteMyAccount anonymizes the caller's account. Requires {"confirm": "DELETE"}.
+func DeleteMyAccount(c *gin.Context) {
+	userID := c.GetString("userID")
+
+	var req struct {
+		Confirm string `json:"confirm"`
+	}
+	_ = c.ShouldBindJSON(&req)
+	if req.Confirm != "DELETE" {
+		c.JSON(400, gin.H{"error": `Send {"confirm": "DELETE"} to delete your account`})
+		return
+	}
+
+	pseudonym, err := services.NewPrivacyService().DeleteAccount(c.Request.Context(), userID)
+	if err == services.ErrAccountDeleted {
+		c.JSON(409, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		services.LogError("SYSTEM_ERROR", userID, err, map[string]interface{}{"context": "account_deletion"})
+		c.JSON(500, gin.H{"error": "Failed to delete account"})
+		return
+	}
+
+	// Logged under the pseudonym so the entry cannot be tied back to the person
+	services.LogInfo("ACCOUNT_DELETED", pseudonym, map[string]interface{}{"deleted_at": time.Now()})
+
+	c.JSON(200, gin.H{"message": "Your account has been deleted"})
+}
@@ -0,0 +1,97 @@
+package handlers
+
+import (
+	"fmt"
+	"movie-ticket-backend/config"
+	"movie-ticket-backend/models"
+	"movie-ticket-backend/services"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// UploadMedia stores a poster or backdrop image (multipart fields "file" and "kind") and its thumbnails.
+// The returned ID is what movies reference as poster_media_id / backdrop_media_id.
+func UploadMedia(c *gin.Context) {
+	adminID := c.GetString("userID")
+
+	// Leave room for the multipart envelope; the service enforces the exact file limit
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, config.AppConfig.MediaMaxUploadBytes+1<<20)
+
+	kind := models.MediaKind(strings.ToUpper(strings.TrimSpace(c.PostForm("kind"))))
+	if !models.IsValidMediaKind(kind) {
+		c.JSON(400, gin.H{"error": "kind must be POSTER or BACKDROP"})
+		return
+	}
+	fileHeader, err := c.FormFile("file")
+	if err != nil {
+		c.JSON(400, gin.H{"error": "file is required"})
+		return
+	}
+	if fileHeader.Size > config.AppConfig.MediaMaxUploadBytes {
+		c.JSON(413, gin.H{"error": fmt.Sprintf("File is too large, the limit is %d bytes", config.AppConfig.MediaMaxUploadBytes)})
+		return
+	}
+	file, err := fileHeader.Open()
+	if err != nil {
+		c.JSON(400, gin.H{"error": "Failed to read file"})
+		return
+	}
+	defer file.Close()
+
+	media, err := services.NewMediaService().Upload(c.Request.Context(), kind, fileHeader.Filename, file, adminID)
+	switch err {
+	case nil:
+	case services.ErrMediaTooLarge:
+		c.JSON(413, gin.H{"error": fmt.Sprintf("File is too large, the limit is %d bytes", config.AppConfig.MediaMaxUploadBytes)})
+		return
+	case services.ErrUnsupportedImage:
+		c.JSON(415, gin.H{"error": err.Error()})
+		return
+	case services.ErrImageDimensions:
+		c.JSON(400, gin.H{"error": err.Error()})
+		return
+	default:
+		c.JSON(500, gin.H{"error": "Failed to store file"})
+		return
+	}
+
+	services.LogInfo("MEDIA_UPLOADED", adminID, map[string]interface{}{
+		"media_id": media.ID.Hex(),
+		"kind":     media.Kind,
+		"size":     media.Size,
+		"width":    media.Width,
+		"height":   media.Height,
+	})
+
+	c.JSON(201, media)
+}
+
+// ServeMedia streams a stored image variant. Media is never changed after upload,
+// so responses can be cached forever and the ETag only depends on the URL.
+func ServeMedia(c *gin.Context) {
+	etag := fmt.Sprintf(`"%s-%s"`, c.Param("id"), c.Param("variant"))
+	if match := c.GetHeader("If-None-Match"); match != "" && (match == etag || match == "*") {
+		c.Header("ETag", etag)
+		c.Status(http.StatusNotModified)
+		return
+	}
+
+	rc, variant, err := services.NewMediaService().Open(c.Request.Context(), c.Param("id"), c.Param("variant"))
+	if err == services.ErrMediaNotFound {
+		c.JSON(404, gin.H{"error": "Media not found"})
+		return
+	}
+	if err != nil {
+		c.JSON(500, gin.H{"error": "Failed to read media"})
+		return
+	}
+	defer rc.Close()
+
+	c.DataFromReader(200, variant.Size, variant.ContentType, rc, map[string]string{
+		"Cache-Control":          "public, max-age=31536000, immutable",
+		"ETag":                   etag,
+		"X-Content-Type-Options": "nosniff",
+	})
+}
@@ -2,51 +2,843 @@ package handlers
 
 import (
 	"context"
+	"fmt"
+	"movie-ticket-backend/config"
 	"movie-ticket-backend/database"
 	"movie-ticket-backend/models"
+	"movie-ticket-backend/services"
 	"net/http"
+	"regexp"
+	"strings"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
 )
 
+// nowShowingWindow: a movie is "now showing" when it has a screening within this window
+const nowShowingWindow = 7 * 24 * time.Hour
+
+// movieSorts maps the ?sort= values to Mongo sort documents
+var movieSorts = map[string]bson.D{
+	"title":     {{Key: "title", Value: 1}},
+	"-title":    {{Key: "title", Value: -1}},
+	"duration":  {{Key: "duration_min", Value: 1}, {Key: "title", Value: 1}},
+	"-duration": {{Key: "duration_min", Value: -1}, {Key: "title", Value: 1}},
+	"newest":    {{Key: "created_at", Value: -1}, {Key: "title", Value: 1}},
+	"release":   {{Key: "release_date", Value: 1}, {Key: "title", Value: 1}},
+	"-release":  {{Key: "release_date", Value: -1}, {Key: "title", Value: 1}},
+}
+
 // --- Movie Handler ---
+
+// GetMovies lists the catalogue.
+// Query: q (text search on title/description), genre, age_rating, language, subtitle (comma separated lists),
+// cast, director (name contains), release_from, release_to (YYYY-MM-DD),
+// date (YYYY-MM-DD, in each cinema's timezone), format, audio (only movies with matching screenings; only those screenings are returned),
+// status (now_showing | coming_soon), sort (relevance | title | -title | duration | -duration | newest | release | -release), page, limit
 func GetMovies(c *gin.Context) {
-	collection := database.Mongo.Collection("movies")
-	cursor, err := collection.Find(context.TODO(), bson.M{})
+	page, limit, skip := parsePagination(c)
+	now := time.Now()
+
+	match := bson.M{"deleted_at": bson.M{"$exists": false}}
+	q := strings.TrimSpace(c.Query("q"))
+	if q != "" {
+		match["$text"] = bson.M{"$search": q}
+	}
+	if genres := queryList(c, "genre"); len(genres) > 0 {
+		var patterns bson.A
+		for _, genre := range genres {
+			patterns = append(patterns, primitive.Regex{Pattern: "^" + regexp.QuoteMeta(genre) + "$", Options: "i"})
+		}
+		match["genres"] = bson.M{"$in": patterns}
+	}
+	if ratings := queryList(c, "age_rating"); len(ratings) > 0 {
+		for _, rating := range ratings {
+			if !models.IsValidAgeRating(models.AgeRating(rating)) {
+				c.JSON(400, gin.H{"error": fmt.Sprintf("Unknown age_rating %s", rating)})
+				return
+			}
+		}
+		match["age_rating"] = bson.M{"$in": ratings}
+	}
+	if languages := queryList(c, "language"); len(languages) > 0 {
+		match["language"] = bson.M{"$in": lowerAll(languages)}
+	}
+	if subtitles := queryList(c, "subtitle"); len(subtitles) > 0 {
+		match["subtitle_language"] = bson.M{"$in": lowerAll(subtitles)}
+	}
+	if cast := strings.TrimSpace(c.Query("cast")); cast != "" {
+		match["cast"] = primitive.Regex{Pattern: regexp.QuoteMeta(cast), Options: "i"}
+	}
+	if director := strings.TrimSpace(c.Query("director")); director != "" {
+		match["directors"] = primitive.Regex{Pattern: regexp.QuoteMeta(director), Options: "i"}
+	}
+	release := bson.M{}
+	for param, op := range map[string]string{"release_from": "$gte", "release_to": "$lte"} {
+		value := c.Query(param)
+		if value == "" {
+			continue
+		}
+		if _, err := time.Parse("2006-01-02", value); err != nil {
+			c.JSON(400, gin.H{"error": fmt.Sprintf("Invalid %s, expected YYYY-MM-DD", param)})
+			return
+		}
+		release[op] = value // YYYY-MM-DD strings compare in date order
+	}
+	if len(release) > 0 {
+		match["release_date"] = release
+	}
+
+	// Screening filters are resolved to movie IDs first, since screenings live in their own collection
+	screeningService := services.NewScreeningService()
+	var idFilters bson.A
+	screeningMatch := bson.M{"$expr": bson.M{"$eq": bson.A{"$movie_id", "$$movie_id"}}, "deleted_at": bson.M{"$exists": false}}
+	screeningFilter := bson.M{}
+
+	if date := c.Query("date"); date != "" {
+		if _, err := time.Parse("2006-01-02", date); err != nil {
+			c.JSON(400, gin.H{"error": "Invalid date, expected YYYY-MM-DD"})
+			return
+		}
+		dayFilter, err := screeningDayFilter(c.Request.Context(), date)
+		if err != nil {
+			c.JSON(500, gin.H{"error": "Failed to load cinemas"})
+			return
+		}
+		screeningFilter["$or"] = dayFilter
+	}
+	if formats := queryList(c, "format"); len(formats) > 0 {
+		values := bson.A{}
+		for _, f := range formats {
+			format := models.ScreeningFormat(strings.ToUpper(f))
+			if !models.IsValidFormat(format) {
+				c.JSON(400, gin.H{"error": fmt.Sprintf("Unknown format %s", f)})
+				return
+			}
+			values = append(values, format)
+			if format == models.Format2D {
+				values = append(values, nil) // Screenings without a format are 2D
+			}
+		}
+		screeningFilter["format"] = bson.M{"$in": values}
+	}
+	if audio := strings.ToUpper(c.Query("audio")); audio != "" {
+		if !models.IsValidAudio(models.AudioVersion(audio)) {
+			c.JSON(400, gin.H{"error": "audio must be ORIGINAL or DUBBED"})
+			return
+		}
+		values := bson.A{audio}
+		if audio == string(models.AudioOriginal) {
+			values = append(values, nil)
+		}
+		screeningFilter["audio"] = bson.M{"$in": values}
+	}
+	if len(screeningFilter) > 0 {
+		ids, err := screeningService.MovieIDsWithScreenings(context.TODO(), screeningFilter)
+		if err != nil {
+			c.JSON(500, gin.H{"error": err.Error()})
+			return
+		}
+		idFilters = append(idFilters, bson.M{"_id": bson.M{"$in": ids}})
+		// Only return the matching showtimes
+		for k, v := range screeningFilter {
+			screeningMatch[k] = v
+		}
+	}
+
+	status := c.Query("status")
+	switch status {
+	case "":
+	case "now_showing", "coming_soon":
+		ids, err := screeningService.MovieIDsWithScreenings(context.TODO(), bson.M{"start_time": bson.M{"$gte": now, "$lt": now.Add(nowShowingWindow)}})
+		if err != nil {
+			c.JSON(500, gin.H{"error": err.Error()})
+			return
+		}
+		op := "$in"
+		if status == "coming_soon" {
+			op = "$nin"
+		}
+		idFilters = append(idFilters, bson.M{"_id": bson.M{op: ids}})
+	default:
+		c.JSON(400, gin.H{"error": "status must be now_showing or coming_soon"})
+		return
+	}
+	if len(idFilters) > 0 {
+		match["$and"] = idFilters
+	}
+
+	sortKey := c.DefaultQuery("sort", "title")
+	var sort bson.D
+	if sortKey == "relevance" {
+		if q == "" {
+			c.JSON(400, gin.H{"error": "sort=relevance requires q"})
+			return
+		}
+		sort = bson.D{{Key: "score", Value: bson.M{"$meta": "textScore"}}, {Key: "title", Value: 1}}
+	} else if s, ok := movieSorts[sortKey]; ok {
+		sort = s
+	} else {
+		c.JSON(400, gin.H{"error": "Invalid sort"})
+		return
+	}
+
+	pipeline := mongo.Pipeline{{{Key: "$match", Value: match}}}
+	if sortKey == "relevance" {
+		pipeline = append(pipeline, bson.D{{Key: "$addFields", Value: bson.M{"score": bson.M{"$meta": "textScore"}}}})
+	}
+	// Screenings are joined after paging; seat maps are only needed on the booking page,
+	// listings use the seats_total/seats_booked counters instead
+	lookup := bson.M{
+		"from": "screenings",
+		"let":  bson.M{"movie_id": "$_id"},
+		"pipeline": bson.A{
+			bson.M{"$match": screeningMatch},
+			bson.M{"$project": bson.M{"seats": 0, "layout": 0}},
+			bson.M{"$sort": bson.M{"start_time": 1}},
+		},
+		"as": "screenings",
+	}
+	pipeline = append(pipeline,
+		bson.D{{Key: "$facet", Value: bson.M{
+			"data":  bson.A{bson.M{"$sort": sort}, bson.M{"$skip": skip}, bson.M{"$limit": limit}, bson.M{"$lookup": lookup}},
+			"total": bson.A{bson.M{"$count": "count"}},
+		}}},
+	)
+
+	cursor, err := database.Mongo.Collection("movies").Aggregate(context.TODO(), pipeline)
 	if err != nil {
 		c.JSON(500, gin.H{"error": err.Error()})
 		return
 	}
-	var movies []models.Movie
-	if err = cursor.All(context.TODO(), &movies); err != nil {
+	var result []struct {
+		Data  []movieWithScreenings `bson:"data"`
+		Total []struct {
+			Count int64 `bson:"count"`
+		} `bson:"total"`
+	}
+	if err = cursor.All(context.TODO(), &result); err != nil {
 		c.JSON(500, gin.H{"error": err.Error()})
 		return
 	}
 
-	// Remove seats from all screenings in the list
-	for i := range movies {
-		for j := range movies[i].Screenings {
-			movies[i].Screenings[j].Seats = nil
+	movies := []models.Movie{}
+	var total int64
+	if len(result) > 0 {
+		for _, m := range result[0].Data {
+			movie := m.withScreenings()
+			// Counts come from the screenings' counters and the lock index; a Redis outage only drops the hint
+			if err := services.AttachAvailability(c.Request.Context(), movie.Screenings); err != nil {
+				fmt.Printf("Failed to count seat locks for movie %s: %v\n", movie.ID.Hex(), err)
+			}
+			movies = append(movies, movie)
+		}
+		if len(result[0].Total) > 0 {
+			total = result[0].Total[0].Count
+		}
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data": movies,
+		"meta": paginationMeta(total, page, limit),
+	})
+}
+
+// screeningDayFilter matches screenings that start on date (YYYY-MM-DD) in their cinema's
+// timezone, so a midnight showing in Bangkok is not listed under the previous day when the
+// server runs in UTC. Screenings without a cinema use the server zone, as Cinema.Location does.
+func screeningDayFilter(ctx context.Context, date string) (bson.A, error) {
+	cinemas, err := services.NewCinemaService().ListCinemas(ctx)
+	if err != nil {
+		return nil, err
+	}
+	dayIn := func(loc *time.Location) bson.M {
+		day, _ := time.ParseInLocation("2006-01-02", date, loc)
+		return bson.M{"$gte": day, "$lt": day.AddDate(0, 0, 1)}
+	}
+
+	zones := make(map[string]*time.Location)
+	cinemasByZone := make(map[string][]primitive.ObjectID)
+	known := make([]primitive.ObjectID, 0, len(cinemas))
+	for _, cinema := range cinemas {
+		loc := cinema.Location()
+		zones[loc.String()] = loc
+		cinemasByZone[loc.String()] = append(cinemasByZone[loc.String()], cinema.ID)
+		known = append(known, cinema.ID)
+	}
+
+	or := bson.A{bson.M{"cinema_id": bson.M{"$nin": known}, "start_time": dayIn(time.Local)}}
+	for zone, ids := range cinemasByZone {
+		or = append(or, bson.M{"cinema_id": bson.M{"$in": ids}, "start_time": dayIn(zones[zone])})
+	}
+	return or, nil
+}
+
+// queryList splits a comma separated query parameter, dropping blanks
+func queryList(c *gin.Context, key string) []string {
+	var values []string
+	for _, v := range strings.Split(c.Query(key), ",") {
+		if v = strings.TrimSpace(v); v != "" {
+			values = append(values, v)
+		}
+	}
+	return values
+}
+
+func lowerAll(values []string) []string {
+	lowered := make([]string, len(values))
+	for i, v := range values {
+		lowered[i] = strings.ToLower(v)
+	}
+	return lowered
+}
+
+// movieWithScreenings decodes a movie joined with its screenings
+type movieWithScreenings struct {
+	models.Movie `bson:",inline"`
+	Screenings   []models.Screening `bson:"screenings"`
+}
+
+func (m movieWithScreenings) withScreenings() models.Movie {
+	movie := m.Movie
+	movie.Screenings = m.Screenings
+	return movie
+}
+
+// movieRequest is the body of the admin create/update endpoints
+type movieRequest struct {
+	Title            string             `json:"title"`
+	Description      string             `json:"description"`
+	Genres           []string           `json:"genres"`
+	AgeRating        models.AgeRating   `json:"age_rating"`
+	ReleaseDate      string             `json:"release_date"`
+	Language         string             `json:"language"`
+	SubtitleLanguage string             `json:"subtitle_language"`
+	Directors        []string           `json:"directors"`
+	Cast             []string           `json:"cast"`
+	DurationMin      int                `json:"duration_min"`
+	PosterMediaID    string             `json:"poster_media_id"`   // From POST /admin/media; on update, empty keeps the current poster
+	BackdropMediaID  string             `json:"backdrop_media_id"` // Same for the backdrop
+	TrailerURL       string             `json:"trailer_url"`
+	Screenings       []screeningRequest `json:"screenings"` // On update, nil keeps the current screenings
+}
+
+type screeningRequest struct {
+	ID        string                      `json:"id"`      // Empty = new screening
+	HallID    string                      `json:"hall_id"` // Empty = default 5x8 layout without a hall
+	StartTime time.Time                   `json:"start_time"`
+	Price     float64                     `json:"price"`  // STANDARD seats, and any type without its own price
+	Prices    map[models.SeatType]float64 `json:"prices"` // e.g. {"PREMIUM": 260, "VIP": 350}
+	Format    models.ScreeningFormat      `json:"format"` // 2D (default), 3D, IMAX, 4DX
+	Audio     models.AudioVersion         `json:"audio"`  // ORIGINAL (default) or DUBBED
+}
+
+func (r *movieRequest) validate() error {
+	r.Title = strings.TrimSpace(r.Title)
+	r.Genres = cleanList(r.Genres)
+	r.Directors = cleanList(r.Directors)
+	r.Cast = cleanList(r.Cast)
+	r.Language = strings.ToLower(strings.TrimSpace(r.Language))
+	r.SubtitleLanguage = strings.ToLower(strings.TrimSpace(r.SubtitleLanguage))
+	details := models.MovieDetails{
+		Title:            r.Title,
+		DurationMin:      r.DurationMin,
+		AgeRating:        r.AgeRating,
+		ReleaseDate:      r.ReleaseDate,
+		Language:         r.Language,
+		SubtitleLanguage: r.SubtitleLanguage,
+		TrailerURL:       r.TrailerURL,
+	}
+	if err := details.Validate(); err != nil {
+		return err
+	}
+
+	ids := make(map[string]bool)
+	starts := make(map[int64]bool)
+	for i, s := range r.Screenings {
+		if err := s.validate(); err != nil {
+			return fmt.Errorf("screenings[%d]: %w", i, err)
+		}
+		if starts[s.StartTime.Unix()] {
+			return fmt.Errorf("screenings[%d]: duplicate start_time", i)
+		}
+		starts[s.StartTime.Unix()] = true
+		if s.ID != "" {
+			if ids[s.ID] {
+				return fmt.Errorf("screenings[%d]: duplicate id", i)
+			}
+			ids[s.ID] = true
 		}
 	}
 
-	c.JSON(http.StatusOK, movies)
+	// The hall checks only see saved screenings, so the request's own must not overlap each other
+	occupied := time.Duration(r.DurationMin)*time.Minute + config.AppConfig.ScreeningCleaningBuffer
+	for i, a := range r.Screenings {
+		for j, b := range r.Screenings[:i] {
+			if a.HallID == "" || a.HallID != b.HallID {
+				continue
+			}
+			if a.StartTime.Before(b.StartTime.Add(occupied)) && b.StartTime.Before(a.StartTime.Add(occupied)) {
+				return fmt.Errorf("screenings[%d]: overlaps screenings[%d] in the same hall", i, j)
+			}
+		}
+	}
+	return nil
+}
+
+func (s screeningRequest) validate() error {
+	if s.StartTime.IsZero() {
+		return fmt.Errorf("start_time is required")
+	}
+	if s.Price <= 0 {
+		return fmt.Errorf("price must be positive")
+	}
+	if s.Format != "" && !models.IsValidFormat(s.Format) {
+		return fmt.Errorf("unknown format %s", s.Format)
+	}
+	if s.Audio != "" && !models.IsValidAudio(s.Audio) {
+		return fmt.Errorf("unknown audio %s", s.Audio)
+	}
+	for seatType, price := range s.Prices {
+		if !models.IsValidSeatType(seatType) {
+			return fmt.Errorf("unknown seat type %s", seatType)
+		}
+		if price <= 0 {
+			return fmt.Errorf("price for %s must be positive", seatType)
+		}
+	}
+	return nil
+}
+
+// cleanList trims entries and drops blanks and duplicates, keeping the order
+func cleanList(list []string) []string {
+	cleaned := []string{}
+	seen := make(map[string]bool)
+	for _, item := range list {
+		item = strings.TrimSpace(item)
+		key := strings.ToLower(item)
+		if item == "" || seen[key] {
+			continue
+		}
+		seen[key] = true
+		cleaned = append(cleaned, item)
+	}
+	return cleaned
+}
+
+// loadHalls fetches every hall referenced by the request
+func (r *movieRequest) loadHalls(ctx context.Context) (map[string]*models.Hall, error) {
+	halls := make(map[string]*models.Hall)
+	for i, s := range r.Screenings {
+		if s.HallID == "" || halls[s.HallID] != nil {
+			continue
+		}
+		hall, err := services.NewCinemaService().GetHall(ctx, s.HallID)
+		if err == services.ErrHallNotFound {
+			return nil, fmt.Errorf("screenings[%d]: unknown hall_id %s", i, s.HallID)
+		}
+		if err != nil {
+			return nil, err
+		}
+		halls[s.HallID] = hall
+	}
+	return halls, nil
+}
+
+func hallIDs(halls map[string]*models.Hall) []primitive.ObjectID {
+	ids := make([]primitive.ObjectID, 0, len(halls))
+	for _, hall := range halls {
+		ids = append(ids, hall.ID)
+	}
+	return ids
+}
+
+// loadMedia fetches the uploaded poster and backdrop the request refers to (nil when not sent)
+func (r *movieRequest) loadMedia(ctx context.Context) (poster, backdrop *models.Media, err error) {
+	mediaService := services.NewMediaService()
+	if r.PosterMediaID != "" {
+		if poster, err = mediaService.GetOfKind(ctx, r.PosterMediaID, models.MediaPoster); err != nil {
+			return nil, nil, fmt.Errorf("poster_media_id: %w", err)
+		}
+	}
+	if r.BackdropMediaID != "" {
+		if backdrop, err = mediaService.GetOfKind(ctx, r.BackdropMediaID, models.MediaBackdrop); err != nil {
+			return nil, nil, fmt.Errorf("backdrop_media_id: %w", err)
+		}
+	}
+	return poster, backdrop, nil
+}
+
+// applyMovieMedia points the movie at new media and denormalizes the variant URLs so listing
+// movies needs no extra lookups. Returns the changed fields for a $set.
+func applyMovieMedia(movie *models.Movie, poster, backdrop *models.Media) bson.M {
+	set := bson.M{}
+	if poster != nil {
+		movie.PosterMediaID = poster.ID
+		movie.PosterURL = services.MediaURL(poster.ID, "medium")
+		movie.PosterThumbURL = services.MediaURL(poster.ID, "thumb")
+		set["poster_media_id"], set["poster_url"], set["poster_thumb_url"] = movie.PosterMediaID, movie.PosterURL, movie.PosterThumbURL
+	}
+	if backdrop != nil {
+		movie.BackdropMediaID = backdrop.ID
+		movie.BackdropURL = services.MediaURL(backdrop.ID, "large")
+		set["backdrop_media_id"], set["backdrop_url"] = movie.BackdropMediaID, movie.BackdropURL
+	}
+	return set
+}
+
+func newScreening(movieID primitive.ObjectID, s screeningRequest, halls map[string]*models.Hall) models.Screening {
+	screening := services.NewScreening(movieID, halls[s.HallID], s.StartTime, s.Price, s.Prices)
+	screening.Format = s.Format
+	screening.Audio = s.Audio
+	return screening
 }
 
+// hallIDOf returns the hall as sent by clients ("" for screenings without a hall)
+func hallIDOf(s models.Screening) string {
+	if s.HallID.IsZero() {
+		return ""
+	}
+	return s.HallID.Hex()
+}
+
+func insertScreenings(ctx context.Context, screenings []models.Screening) error {
+	if len(screenings) == 0 {
+		return nil
+	}
+	docs := make([]interface{}, len(screenings))
+	for i := range screenings {
+		docs[i] = screenings[i]
+	}
+	_, err := database.Mongo.Collection("screenings").InsertMany(ctx, docs)
+	return err
+}
+
+// CreateMovie adds a movie with its screenings (each gets the default seat layout)
 func CreateMovie(c *gin.Context) {
-	var movie models.Movie
-	if err := c.ShouldBindJSON(&movie); err != nil {
+	adminID := c.GetString("userID")
+
+	var req movieRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(400, gin.H{"error": err.Error()})
+		return
+	}
+	if err := req.validate(); err != nil {
+		c.JSON(400, gin.H{"error": err.Error()})
+		return
+	}
+
+	halls, err := req.loadHalls(context.TODO())
+	if err != nil {
+		c.JSON(400, gin.H{"error": err.Error()})
+		return
+	}
+	poster, backdrop, err := req.loadMedia(context.TODO())
+	if err != nil {
 		c.JSON(400, gin.H{"error": err.Error()})
 		return
 	}
-	movie.ID = primitive.NewObjectID()
+
+	unlock, ok := lockHalls(c, hallIDs(halls)...)
+	if !ok {
+		return
+	}
+	defer unlock()
+	for _, s := range req.Screenings {
+		if hall := halls[s.HallID]; hall != nil && !respondIfHallBusy(c, hall.ID, s.StartTime, req.DurationMin) {
+			return
+		}
+	}
+
+	now := time.Now()
+	movie := models.Movie{
+		ID:               primitive.NewObjectID(),
+		Title:            req.Title,
+		Description:      req.Description,
+		Genres:           req.Genres,
+		AgeRating:        req.AgeRating,
+		ReleaseDate:      req.ReleaseDate,
+		Language:         req.Language,
+		SubtitleLanguage: req.SubtitleLanguage,
+		Directors:        req.Directors,
+		Cast:             req.Cast,
+		DurationMin:      req.DurationMin,
+		TrailerURL:       req.TrailerURL,
+		Screenings:       []models.Screening{},
+		CreatedAt:        now,
+		UpdatedAt:        now,
+	}
+	applyMovieMedia(&movie, poster, backdrop)
+	for _, s := range req.Screenings {
+		movie.Screenings = append(movie.Screenings, newScreening(movie.ID, s, halls))
+	}
+
 	collection := database.Mongo.Collection("movies")
-	_, err := collection.InsertOne(context.TODO(), movie)
+	_, err = collection.InsertOne(context.TODO(), movie)
 	if err != nil {
 		c.JSON(500, gin.H{"error": err.Error()})
 		return
 	}
-	c.JSON(200, movie)
+	if err := insertScreenings(context.TODO(), movie.Screenings); err != nil {
+		c.JSON(500, gin.H{"error": err.Error()})
+		return
+	}
+
+	services.LogInfo("MOVIE_CREATED", adminID, map[string]interface{}{
+		"movie_id":   movie.ID.Hex(),
+		"title":      movie.Title,
+		"screenings": len(movie.Screenings),
+	})
+
+	c.JSON(201, movie)
+}
+
+// UpdateMovie changes movie details and, when "screenings" is sent, adds/edits/removes screenings.
+// Screenings with sold seats cannot be removed or moved to another time.
+func UpdateMovie(c *gin.Context) {
+	adminID := c.GetString("userID")
+
+	movie, ok := loadActiveMovie(c)
+	if !ok {
+		return
+	}
+
+	var req movieRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(400, gin.H{"error": err.Error()})
+		return
+	}
+	if err := req.validate(); err != nil {
+		c.JSON(400, gin.H{"error": err.Error()})
+		return
+	}
+
+	collection := database.Mongo.Collection("movies")
+	screeningsColl := database.Mongo.Collection("screenings")
+	ctx := context.TODO()
+	now := time.Now()
+
+	halls, err := req.loadHalls(ctx)
+	if err != nil {
+		c.JSON(400, gin.H{"error": err.Error()})
+		return
+	}
+	poster, backdrop, err := req.loadMedia(ctx)
+	if err != nil {
+		c.JSON(400, gin.H{"error": err.Error()})
+		return
+	}
+	unlock, ok := lockHalls(c, hallIDs(halls)...)
+	if !ok {
+		return
+	}
+	defer unlock()
+
+	// Plan the screening changes first so nothing is written if any of them is refused
+	var added []models.Screening
+	var changed []screeningRequest
+	var removed []primitive.ObjectID
+	existing := make(map[string]models.Screening)
+	if req.Screenings != nil {
+		current, err := services.NewScreeningService().ListByMovie(ctx, movie.ID, true)
+		if err != nil {
+			c.JSON(500, gin.H{"error": "Failed to load screenings"})
+			return
+		}
+		for _, s := range current {
+			existing[s.ID.Hex()] = s
+		}
+		kept := make(map[string]bool)
+		for _, s := range req.Screenings {
+			if s.ID == "" {
+				if hall := halls[s.HallID]; hall != nil && !respondIfHallBusy(c, hall.ID, s.StartTime, req.DurationMin) {
+					return
+				}
+				added = append(added, newScreening(movie.ID, s, halls))
+				continue
+			}
+			current, found := existing[s.ID]
+			if !found {
+				c.JSON(400, gin.H{"error": fmt.Sprintf("Unknown screening id %s", s.ID)})
+				return
+			}
+			kept[s.ID] = true
+			moved := !current.StartTime.Equal(s.StartTime) || hallIDOf(current) != s.HallID
+			if moved && current.SoldSeats() > 0 {
+				c.JSON(409, gin.H{"error": fmt.Sprintf("Screening %s has sold seats and cannot be moved", s.ID)})
+				return
+			}
+			if hall := halls[s.HallID]; moved && hall != nil && !respondIfHallBusy(c, hall.ID, s.StartTime, req.DurationMin, current.ID) {
+				return
+			}
+			if moved || current.Price != s.Price || !models.SamePrices(current.Prices, s.Prices) || current.Format != s.Format || current.Audio != s.Audio {
+				changed = append(changed, s)
+			}
+		}
+		for _, s := range current {
+			if kept[s.ID.Hex()] {
+				continue
+			}
+			if s.SoldSeats() > 0 {
+				c.JSON(409, gin.H{"error": fmt.Sprintf("Screening %s has sold seats and cannot be removed", s.ID.Hex())})
+				return
+			}
+			removed = append(removed, s.ID)
+		}
+	}
+
+	set := bson.M{
+		"title":             req.Title,
+		"description":       req.Description,
+		"genres":            req.Genres,
+		"age_rating":        req.AgeRating,
+		"release_date":      req.ReleaseDate,
+		"language":          req.Language,
+		"subtitle_language": req.SubtitleLanguage,
+		"directors":         req.Directors,
+		"cast":              req.Cast,
+		"duration_min":      req.DurationMin,
+		"trailer_url":       req.TrailerURL,
+		"updated_at":        now,
+	}
+	for field, value := range applyMovieMedia(movie, poster, backdrop) {
+		set[field] = value
+	}
+	_, err = collection.UpdateOne(ctx, bson.M{"_id": movie.ID}, bson.M{"$set": set})
+	if err != nil {
+		c.JSON(500, gin.H{"error": "Failed to update movie"})
+		return
+	}
+
+	// Each write re-checks its precondition in the filter; a miss means a seat was sold (or the
+	// screening removed) after the plan above was made
+	unsold := bson.M{"$not": bson.M{"$elemMatch": bson.M{"status": models.SeatBooked}}}
+	for _, s := range changed {
+		id, _ := primitive.ObjectIDFromHex(s.ID)
+		set := bson.M{"start_time": s.StartTime, "price": s.Price, "prices": s.Prices, "format": s.Format, "audio": s.Audio, "updated_at": now}
+		filter := bson.M{"_id": id, "deleted_at": bson.M{"$exists": false}}
+		current := existing[s.ID]
+		if !current.StartTime.Equal(s.StartTime) || hallIDOf(current) != s.HallID {
+			filter["seats"] = unsold
+		}
+		if hallIDOf(current) != s.HallID {
+			// New hall, new seat map
+			fresh := newScreening(movie.ID, s, halls)
+			set["cinema_id"], set["hall_id"], set["layout"], set["seats"] = fresh.CinemaID, fresh.HallID, fresh.Layout, fresh.Seats
+			set["seats_total"], set["seats_booked"] = fresh.SeatsTotal, 0
+		}
+		res, err := screeningsColl.UpdateOne(ctx, filter, bson.M{"$set": set})
+		if err != nil {
+			c.JSON(500, gin.H{"error": "Failed to update screening"})
+			return
+		}
+		if res.MatchedCount == 0 {
+			c.JSON(409, gin.H{"error": fmt.Sprintf("Screening %s changed while saving (seats sold or removed); reload and try again", s.ID)})
+			return
+		}
+	}
+	for _, id := range removed {
+		res, err := screeningsColl.DeleteOne(ctx, bson.M{"_id": id, "seats": unsold})
+		if err != nil {
+			c.JSON(500, gin.H{"error": "Failed to remove screening"})
+			return
+		}
+		if res.DeletedCount == 0 {
+			c.JSON(409, gin.H{"error": fmt.Sprintf("Screening %s changed while saving (seats sold or removed); reload and try again", id.Hex())})
+			return
+		}
+	}
+	if err := insertScreenings(ctx, added); err != nil {
+		c.JSON(500, gin.H{"error": "Failed to add screenings"})
+		return
+	}
+
+	removedIDs := make([]string, len(removed))
+	for i, id := range removed {
+		removedIDs[i] = id.Hex()
+	}
+	services.LogInfo("MOVIE_UPDATED", adminID, map[string]interface{}{
+		"movie_id":           movie.ID.Hex(),
+		"title":              req.Title,
+		"screenings_added":   len(added),
+		"screenings_changed": len(changed),
+		"screenings_removed": removedIDs,
+	})
+
+	var updated models.Movie
+	if err := collection.FindOne(ctx, bson.M{"_id": movie.ID}).Decode(&updated); err != nil {
+		c.JSON(500, gin.H{"error": "Failed to reload movie"})
+		return
+	}
+	if updated.Screenings, err = services.NewScreeningService().ListByMovie(ctx, movie.ID, false); err != nil {
+		c.JSON(500, gin.H{"error": "Failed to reload screenings"})
+		return
+	}
+	c.JSON(200, updated)
+}
+
+// DeleteMovie soft deletes a movie. Refused while a future screening has sold seats.
+func DeleteMovie(c *gin.Context) {
+	adminID := c.GetString("userID")
+
+	movie, ok := loadActiveMovie(c)
+	if !ok {
+		return
+	}
+
+	ctx := context.TODO()
+	sold, err := services.NewScreeningService().HasFutureSoldSeats(ctx, movie.ID)
+	if err != nil {
+		c.JSON(500, gin.H{"error": "Failed to check screenings"})
+		return
+	}
+	if sold {
+		c.JSON(409, gin.H{"error": "Movie has sold seats in future screenings; refund or move them before deleting"})
+		return
+	}
+
+	now := time.Now()
+	filter := bson.M{"_id": movie.ID, "deleted_at": bson.M{"$exists": false}}
+	if _, err := database.Mongo.Collection("movies").UpdateOne(ctx, filter, bson.M{"$set": bson.M{"deleted_at": now, "updated_at": now}}); err != nil {
+		c.JSON(500, gin.H{"error": "Failed to delete movie"})
+		return
+	}
+	// Past screenings are kept (soft deleted) so booking history still resolves
+	screeningFilter := bson.M{"movie_id": movie.ID, "deleted_at": bson.M{"$exists": false}}
+	if _, err := database.Mongo.Collection("screenings").UpdateMany(ctx, screeningFilter, bson.M{"$set": bson.M{"deleted_at": now, "updated_at": now}}); err != nil {
+		c.JSON(500, gin.H{"error": "Failed to delete screenings"})
+		return
+	}
+
+	services.LogInfo("MOVIE_DELETED", adminID, map[string]interface{}{
+		"movie_id": movie.ID.Hex(),
+		"title":    movie.Title,
+	})
+
+	c.JSON(200, gin.H{"message": "Movie deleted"})
+}
+
+// loadActiveMovie resolves :id to a movie that is not soft deleted
+func loadActiveMovie(c *gin.Context) (*models.Movie, bool) {
+	objID, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.JSON(400, gin.H{"error": "Invalid Movie ID"})
+		return nil, false
+	}
+
+	var movie models.Movie
+	filter := bson.M{"_id": objID, "deleted_at": bson.M{"$exists": false}}
+	err = database.Mongo.Collection("movies").FindOne(context.TODO(), filter).Decode(&movie)
+	if err == mongo.ErrNoDocuments {
+		c.JSON(404, gin.H{"error": "Movie not found"})
+		return nil, false
+	}
+	if err != nil {
+		c.JSON(500, gin.H{"error": "Failed to fetch movie"})
+		return nil, false
+	}
+	return &movie, true
 }
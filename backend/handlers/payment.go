@@ -20,9 +20,10 @@ func StartPayment(c *gin.Context) {
 	userID := val.(string)
 
 	var req struct {
-		MovieID   string   `json:"movie_id"`
-		StartTime string   `json:"start_time"`
-		SeatIDs   []string `json:"seat_ids"`
+		ScreeningID string   `json:"screening_id"`
+		MovieID     string   `json:"movie_id"`
+		StartTime   string   `json:"start_time"`
+		SeatIDs     []string `json:"seat_ids"`
 	}
 	if err := c.ShouldBindJSON(&req); err != nil {
 		c.JSON(400, gin.H{"error": err.Error()})
@@ -30,24 +31,37 @@ func StartPayment(c *gin.Context) {
 	}
 
 	lockService := services.NewLockService()
+	holder := services.LockHolder(userID, c.GetString("apiKeyID"))
 
 	// 1. Check if already paying
-	if lockService.HasPaymentLock(userID) {
+	if lockService.HasPaymentLock(holder) {
 		c.JSON(409, gin.H{"error": "Payment already in progress. Please try again in 5 minutes."})
 		return
 	}
 
 	// 2. Resolve Screening (Reusing helper from seat.go in same package)
-	screeningID, err := resolveScreeningID(req.MovieID, req.StartTime)
+	screening, err := resolveScreening(req.ScreeningID, &req.MovieID, &req.StartTime)
 	if err != nil {
 		c.JSON(404, gin.H{"error": err.Error()})
 		return
 	}
+	screeningID := screening.ID.Hex()
+
+	if !enforceAgeRestriction(c, userID, screening, "payment_start") {
+		return
+	}
+
+	// Price the seats before touching any lock, so a bad seat list changes nothing
+	breakdown, err := services.NewPricingService().Quote(c.Request.Context(), screening, req.SeatIDs)
+	if err != nil {
+		c.JSON(400, gin.H{"error": err.Error()})
+		return
+	}
 
 	// 3. Extend Seat Locks FIRST (Ensure validity)
 	extendedCount := 0
 	for _, seatID := range req.SeatIDs {
-		success, err := lockService.ExtendSeatLock(req.MovieID, req.StartTime, seatID, userID, 5*time.Minute)
+		success, err := lockService.ExtendSeatLock(screeningID, seatID, holder, 5*time.Minute)
 		if err != nil {
 			fmt.Printf("Error extending lock for seat %s: %v\n", seatID, err)
 			continue
@@ -65,12 +79,13 @@ func StartPayment(c *gin.Context) {
 	// 4. Set Payment Lock
 	lockDuration := 5 * time.Minute
 	expireAt := time.Now().Add(lockDuration)
-	err = lockService.SetPaymentLock(userID, services.PaymentLockDetails{
+	err = lockService.SetPaymentLock(holder, services.PaymentLockDetails{
 		UserID:      userID,
 		MovieID:     req.MovieID,
 		ScreeningID: screeningID,
 		StartTime:   req.StartTime,
 		SeatIDs:     req.SeatIDs,
+		Quote:       breakdown,
 	}, lockDuration)
 
 	if err != nil {
@@ -83,6 +98,7 @@ func StartPayment(c *gin.Context) {
 		"message":        "Payment started",
 		"extended_count": extendedCount,
 		"expire_at":      expireAt,
+		"breakdown":      breakdown,
 	})
 }
 
@@ -98,7 +114,7 @@ func CancelPayment(c *gin.Context) {
 	lockService := services.NewLockService()
 
 	// 1. Release Lock (Deleting the key prevents the 'expired' event, so no log will be written)
-	lockService.ReleasePaymentLock(userID)
+	lockService.ReleasePaymentLock(services.LockHolder(userID, c.GetString("apiKeyID")))
 
 	c.JSON(200, gin.H{"message": "Payment processed"})
 }
@@ -6,85 +6,81 @@ import (
 	"movie-ticket-backend/database"
 	"movie-ticket-backend/models"
 	"movie-ticket-backend/services"
-	"time"
 
 	"github.com/gin-gonic/gin"
 	"go.mongodb.org/mongo-driver/bson"
-	"go.mongodb.org/mongo-driver/bson/primitive"
 )
 
 // --- Screening Handler ---
 func GetScreeningDetails(c *gin.Context) {
 	fmt.Println("GetScreeningDetails")
 	var req struct {
-		MovieID   string `json:"movie_id"`
-		StartTime string `json:"start_time"`
+		ScreeningID string `json:"screening_id"`
+		MovieID     string `json:"movie_id"`
+		StartTime   string `json:"start_time"`
 	}
 	if err := c.ShouldBindJSON(&req); err != nil {
 		c.JSON(400, gin.H{"error": err.Error()})
 		return
 	}
 
-	movieObjID, err := primitive.ObjectIDFromHex(req.MovieID)
-	if err != nil {
-		c.JSON(400, gin.H{"error": "Invalid Movie ID"})
+	screening, err := services.NewScreeningService().Resolve(context.TODO(), req.ScreeningID, req.MovieID, req.StartTime)
+	if err == services.ErrScreeningNotFound {
+		c.JSON(404, gin.H{"error": "Screening not found"})
 		return
 	}
-
-	// Parse incoming time
-	reqTime, err := time.Parse(time.RFC3339, req.StartTime)
 	if err != nil {
-		fmt.Printf("Error parsing time: %v\n", err)
-		c.JSON(400, gin.H{"error": "Invalid Start Time format"})
+		c.JSON(500, gin.H{"error": "Failed to load screening"})
 		return
 	}
 
-	fmt.Printf("Searching for MovieID: %s, StartTime: %v\n", req.MovieID, reqTime)
-
 	collection := database.Mongo.Collection("movies")
 	var movie models.Movie
-	err = collection.FindOne(context.TODO(), bson.M{"_id": movieObjID}).Decode(&movie)
+	err = collection.FindOne(context.TODO(), bson.M{"_id": screening.MovieID, "deleted_at": bson.M{"$exists": false}}).Decode(&movie)
 	if err != nil {
 		fmt.Println("Movie not found in DB")
 		c.JSON(404, gin.H{"error": "Movie not found"})
 		return
 	}
 
-	var screening *models.Screening
-	for _, s := range movie.Screenings {
-		fmt.Printf("Checking screening time: %v vs Req: %v\n", s.StartTime, reqTime)
-		// Compare times (ignoring small differences if needed, but exact match preferred)
-		if s.StartTime.Equal(reqTime) || s.StartTime.Format(time.RFC3339) == req.StartTime {
-			screening = &s
-			break
-		}
-	}
-
-	if screening == nil {
-		c.JSON(404, gin.H{"error": "Screening not found"})
-		return
-	}
-
 	// Redis Lock check
 	lockService := services.NewLockService()
-	lockedSeatsMap, _ := lockService.GetLockedSeats(req.MovieID, req.StartTime)
+	lockedSeatsMap, _ := lockService.GetLockedSeats(screening.ID.Hex())
 
 	// Merge Status
 	seatsCopy := make([]models.Seat, len(screening.Seats))
 	copy(seatsCopy, screening.Seats)
 
 	for i := range seatsCopy {
-		if userID, ok := lockedSeatsMap[seatsCopy[i].ID]; ok {
+		if holder, ok := lockedSeatsMap[seatsCopy[i].ID]; ok {
 			if seatsCopy[i].Status == models.SeatAvailable {
 				seatsCopy[i].Status = "LOCKED"
-				seatsCopy[i].LockedBy = userID
+				seatsCopy[i].LockedBy = services.LockHolderUserID(holder)
 			}
 		}
 	}
 	screening.Seats = seatsCopy
 
+	// Screenings created before halls existed have no stored layout
+	if screening.Layout == nil {
+		layout := models.DefaultSeatMap()
+		screening.Layout = &layout
+	}
+	var hallInfo, cinemaInfo gin.H
+	if !screening.HallID.IsZero() {
+		cinemaService := services.NewCinemaService()
+		if hall, err := cinemaService.GetHall(context.TODO(), screening.HallID.Hex()); err == nil {
+			hallInfo = gin.H{"id": hall.ID, "name": hall.Name}
+		}
+		if cinema, err := cinemaService.GetCinema(context.TODO(), screening.CinemaID.Hex()); err == nil {
+			cinemaInfo = gin.H{"id": cinema.ID, "name": cinema.Name, "timezone": cinema.Timezone}
+		}
+	}
+
 	c.JSON(200, gin.H{
 		"screening": screening,
+		"hall":      hallInfo,
+		"cinema":    cinemaInfo,
 		"movie": gin.H{
 			"id":           movie.ID,
 			"title":        movie.Title,
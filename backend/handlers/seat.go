@@ -3,14 +3,12 @@ package handlers
 import (
 	"context"
 	"fmt"
-	"movie-ticket-backend/database"
+	"movie-ticket-backend/middleware"
 	"movie-ticket-backend/models"
 	"movie-ticket-backend/services"
 	"time"
 
 	"github.com/gin-gonic/gin"
-	"go.mongodb.org/mongo-driver/bson"
-	"go.mongodb.org/mongo-driver/bson/primitive"
 )
 
 // --- Seat Handlers ---
@@ -23,41 +21,44 @@ func LockSeat(c *gin.Context) {
 	userID := val.(string)
 
 	var req struct {
-		MovieID   string `json:"movie_id"`
-		StartTime string `json:"start_time"`
-		SeatID    string `json:"seat_id"`
+		ScreeningID string `json:"screening_id"`
+		MovieID     string `json:"movie_id"`
+		StartTime   string `json:"start_time"`
+		SeatID      string `json:"seat_id"`
 	}
 	if err := c.ShouldBindJSON(&req); err != nil {
 		c.JSON(400, gin.H{"error": err.Error()})
 		return
 	}
 
-	// Resolve Screening ID from MovieID + StartTime
-	screeningID, err := resolveScreeningID(req.MovieID, req.StartTime)
+	// Resolve Screening ID (or MovieID + StartTime for older clients)
+	screening, err := resolveScreening(req.ScreeningID, &req.MovieID, &req.StartTime)
 	if err != nil {
 		c.JSON(404, gin.H{"error": err.Error()})
 		return
 	}
+	screeningID := screening.ID.Hex()
 
 	// 2. Lock Redis
 	lockService := services.NewLockService()
+	holder := services.LockHolder(userID, c.GetString("apiKeyID"))
 
 	// Check for Payment Lock (Block changes if paying for THIS screening)
-	paymentLock, _ := lockService.GetPaymentLock(userID)
+	paymentLock, _ := lockService.GetPaymentLock(holder)
 	if paymentLock != nil {
-		if paymentLock.MovieID == req.MovieID && paymentLock.StartTime == req.StartTime {
+		if paymentLock.ScreeningID == screeningID {
 			c.JSON(409, gin.H{"error": "Cannot change seats while payment is in progress"})
 			return
 		}
 	}
 
 	// Check if already locked
-	isLocked, holderID := lockService.IsSeatLocked(req.MovieID, req.StartTime, req.SeatID)
+	isLocked, holderID := lockService.IsSeatLocked(screeningID, req.SeatID)
 
 	if isLocked {
-		if holderID == userID {
-			// Same user -> Unlock (Toggle)
-			err := lockService.UnlockSeat(req.MovieID, req.StartTime, req.SeatID)
+		if holderID == holder {
+			// Same holder -> Unlock (Toggle)
+			err := lockService.UnlockSeat(screeningID, req.SeatID)
 			if err != nil {
 				c.JSON(500, gin.H{"error": "Failed to unlock"})
 				return
@@ -65,12 +66,13 @@ func LockSeat(c *gin.Context) {
 
 			// WS Broadcast UNLOCK
 			services.WSHub.Broadcast <- services.SeatUpdateMessage{
-				ScreeningID: screeningID, // Internal ID used for WS room/topic
+				ScreeningID: screeningID,
 				MovieID:     req.MovieID,
 				StartTime:   req.StartTime,
 				SeatID:      req.SeatID,
 				Status:      "AVAILABLE",
 			}
+			services.PublishSeatCounts(screeningID)
 
 			// [AUDIT LOG] Seat Manually Released (Unlocked)
 			services.LogInfo("SEAT_RELEASED", userID, map[string]interface{}{
@@ -90,8 +92,19 @@ func LockSeat(c *gin.Context) {
 		}
 	}
 
-	// Not locked -> Lock it
-	locked, err := lockService.LockSeat(req.MovieID, req.StartTime, req.SeatID, userID, 5*time.Minute)
+	if !enforceAgeRestriction(c, userID, screening, "seat_lock") {
+		return
+	}
+
+	// Not locked -> Quote the seat (also rejects unknown seats), then lock it.
+	// The quote is informational; the price is frozen when payment starts.
+	quote, err := services.NewPricingService().Quote(c.Request.Context(), screening, []string{req.SeatID})
+	if err != nil {
+		c.JSON(400, gin.H{"error": err.Error()})
+		return
+	}
+
+	locked, err := lockService.LockSeat(screeningID, req.SeatID, holder, 5*time.Minute)
 	if err != nil {
 		services.LogError("SYSTEM_ERROR", userID, err, map[string]interface{}{"context": "redis_lock_seat"})
 		c.JSON(500, gin.H{"error": "Redis error"})
@@ -105,15 +118,16 @@ func LockSeat(c *gin.Context) {
 
 	// WS Broadcast LOCK
 	services.WSHub.Broadcast <- services.SeatUpdateMessage{
-		ScreeningID: screeningID, // Internal ID used for WS room/topic
+		ScreeningID: screeningID,
 		MovieID:     req.MovieID,
 		StartTime:   req.StartTime,
 		SeatID:      req.SeatID,
 		UserID:      userID,
 		Status:      "LOCKED",
 	}
+	services.PublishSeatCounts(screeningID)
 
-	c.JSON(200, gin.H{"message": "Seat locked", "status": "LOCKED"})
+	c.JSON(200, gin.H{"message": "Seat locked", "status": "LOCKED", "price": quote.Lines[0]})
 }
 
 func BookSeat(c *gin.Context) {
@@ -125,10 +139,11 @@ func BookSeat(c *gin.Context) {
 	userID := val.(string)
 
 	var req struct {
-		MovieID   string   `json:"movie_id"`
-		StartTime string   `json:"start_time"`
-		SeatIDs   []string `json:"seat_ids"`
-		PaymentID string   `json:"payment_id"` // [NEW] Payment Reference
+		ScreeningID string   `json:"screening_id"`
+		MovieID     string   `json:"movie_id"`
+		StartTime   string   `json:"start_time"`
+		SeatIDs     []string `json:"seat_ids"`
+		PaymentID   string   `json:"payment_id"` // [NEW] Payment Reference
 	}
 	if err := c.ShouldBindJSON(&req); err != nil {
 		c.JSON(400, gin.H{"error": err.Error()})
@@ -136,7 +151,7 @@ func BookSeat(c *gin.Context) {
 	}
 
 	// Resolve Screening ID
-	screeningID, err := resolveScreeningID(req.MovieID, req.StartTime)
+	screeningID, err := resolveScreeningID(req.ScreeningID, &req.MovieID, &req.StartTime)
 	if err != nil {
 		c.JSON(404, gin.H{"error": err.Error()})
 		return
@@ -144,7 +159,7 @@ func BookSeat(c *gin.Context) {
 
 	// Delegate to BookingService
 	bookingService := services.NewBookingService()
-	result, err := bookingService.ProcessBooking(userID, req.MovieID, screeningID, req.StartTime, req.SeatIDs, req.PaymentID)
+	result, err := bookingService.ProcessBooking(userID, req.MovieID, screeningID, req.StartTime, req.SeatIDs, req.PaymentID, c.GetString("apiKeyID"))
 
 	if err != nil {
 		// Differentiate error types if needed, for now general 500 or 409
@@ -170,22 +185,28 @@ func ExtendSeatLock(c *gin.Context) {
 	userID := val.(string)
 
 	var req struct {
-		MovieID   string   `json:"movie_id"`
-		StartTime string   `json:"start_time"`
-		SeatIDs   []string `json:"seat_ids"`
+		ScreeningID string   `json:"screening_id"`
+		MovieID     string   `json:"movie_id"`
+		StartTime   string   `json:"start_time"`
+		SeatIDs     []string `json:"seat_ids"`
 	}
 	if err := c.ShouldBindJSON(&req); err != nil {
 		c.JSON(400, gin.H{"error": err.Error()})
 		return
 	}
 
-	// screeningID resolution removed as it is not needed for ExtendSeatLock with new key format
+	screeningID, err := resolveScreeningID(req.ScreeningID, &req.MovieID, &req.StartTime)
+	if err != nil {
+		c.JSON(404, gin.H{"error": err.Error()})
+		return
+	}
 
 	lockService := services.NewLockService()
+	holder := services.LockHolder(userID, c.GetString("apiKeyID"))
 	extendedCount := 0
 
 	for _, seatID := range req.SeatIDs {
-		success, err := lockService.ExtendSeatLock(req.MovieID, req.StartTime, seatID, userID, 5*time.Minute)
+		success, err := lockService.ExtendSeatLock(screeningID, seatID, holder, 5*time.Minute)
 		if err != nil {
 			fmt.Printf("Error extending lock for seat %s: %v\n", seatID, err)
 			continue
@@ -203,28 +224,68 @@ func ExtendSeatLock(c *gin.Context) {
 	c.JSON(200, gin.H{"message": "Locks extended", "count": extendedCount})
 }
 
-// Helper to find internal Screening ID
-func resolveScreeningID(movieIDHex, startTimeStr string) (string, error) {
-	movieObjID, err := primitive.ObjectIDFromHex(movieIDHex)
-	if err != nil {
-		return "", fmt.Errorf("invalid Movie ID")
+// Helper to find the screening a request refers to. Clients may send screening_id, or
+// movie_id + start_time; whichever pair is missing is filled in so WS broadcasts and
+// audit logs keep carrying movie_id/start_time.
+func resolveScreening(screeningID string, movieID, startTime *string) (*models.Screening, error) {
+	screening, err := services.NewScreeningService().Resolve(context.TODO(), screeningID, *movieID, *startTime)
+	if err == services.ErrScreeningNotFound {
+		return nil, fmt.Errorf("screening not found")
 	}
-	reqTime, err := time.Parse(time.RFC3339, startTimeStr)
 	if err != nil {
-		return "", fmt.Errorf("invalid Start Time")
+		return nil, err
+	}
+
+	if *movieID == "" || screeningID != "" {
+		*movieID = screening.MovieID.Hex()
 	}
+	if *startTime == "" {
+		*startTime = screening.StartTime.Format(time.RFC3339)
+	}
+	return screening, nil
+}
 
-	collection := database.Mongo.Collection("movies")
-	var movie models.Movie
-	err = collection.FindOne(context.TODO(), bson.M{"_id": movieObjID}).Decode(&movie)
+func resolveScreeningID(screeningID string, movieID, startTime *string) (string, error) {
+	screening, err := resolveScreening(screeningID, movieID, startTime)
 	if err != nil {
-		return "", fmt.Errorf("movie not found")
+		return "", err
 	}
+	return screening.ID.Hex(), nil
+}
 
-	for _, s := range movie.Screenings {
-		if s.StartTime.Equal(reqTime) || s.StartTime.Format(time.RFC3339) == startTimeStr {
-			return s.ID, nil // Return internal ID (e.g., s1)
-		}
+// enforceAgeRestriction refuses age-restricted screenings to users who are too young or have no
+// date of birth, and audits the denial. A partner account has no date of birth, so its API keys
+// are refused too unless an admin granted the key the age:attested scope, recording that the
+// partner checks the buyer's age at its own till. Their tickets are still flagged for an ID check
+// at entry.
+func enforceAgeRestriction(c *gin.Context, userID string, screening *models.Screening, step string) bool {
+	if middleware.APIKeyHasScope(c, models.PermAgeAttested) {
+		return true
+	}
+	user, _ := c.Get("user")
+	u, _ := user.(models.User)
+
+	rating, err := services.CheckAgeRestriction(c.Request.Context(), &u, screening)
+	if err == services.ErrAgeRestricted || err == services.ErrDateOfBirthRequired {
+		services.LogWarn("AGE_RESTRICTION_DENIED", userID, map[string]interface{}{
+			"screening_id": screening.ID.Hex(),
+			"movie_id":     screening.MovieID.Hex(),
+			"age_rating":   rating,
+			"min_age":      rating.MinAge(),
+			"reason":       err.Error(),
+			"step":         step,
+		})
+		c.JSON(403, gin.H{
+			"error":               err.Error(),
+			"age_rating":          rating,
+			"min_age":             rating.MinAge(),
+			"needs_date_of_birth": err == services.ErrDateOfBirthRequired,
+		})
+		return false
+	}
+	if err != nil {
+		c.JSON(500, gin.H{"error": "Failed to check the age rating"})
+		return false
 	}
-	return "", fmt.Errorf("screening not found")
+	return true
 }
@@ -0,0 +1,63 @@
+package handlers
+
+import (
+	"movie-ticket-backend/models"
+	"movie-ticket-backend/services"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// GetShowtimes returns the programme of a day grouped by cinema, movie and hall.
+// ?date=YYYY-MM-DD is read in each cinema's timezone (default: today there); ?cinema=<id> limits it to one cinema.
+func GetShowtimes(c *gin.Context) {
+	ctx := c.Request.Context()
+	date := c.Query("date")
+	if date != "" {
+		if _, err := time.Parse("2006-01-02", date); err != nil {
+			c.JSON(400, gin.H{"error": "Invalid date, expected YYYY-MM-DD"})
+			return
+		}
+	}
+
+	cinemaService := services.NewCinemaService()
+	var cinemas []models.Cinema
+	if cinemaID := c.Query("cinema"); cinemaID != "" {
+		cinema, err := cinemaService.GetCinema(ctx, cinemaID)
+		if err == services.ErrCinemaNotFound {
+			c.JSON(404, gin.H{"error": "Cinema not found"})
+			return
+		}
+		if err != nil {
+			c.JSON(500, gin.H{"error": "Failed to fetch cinema"})
+			return
+		}
+		cinemas = append(cinemas, *cinema)
+	} else {
+		var err error
+		if cinemas, err = cinemaService.ListCinemas(ctx); err != nil {
+			c.JSON(500, gin.H{"error": "Failed to fetch cinemas"})
+			return
+		}
+	}
+
+	// Loaded once so every price range is computed with the same rules
+	rules, err := services.NewPricingService().ListRules(ctx, true)
+	if err != nil {
+		c.JSON(500, gin.H{"error": "Failed to fetch pricing rules"})
+		return
+	}
+
+	showtimeService := services.NewShowtimeService()
+	result := []services.CinemaShowtimes{}
+	for _, cinema := range cinemas {
+		grid, err := showtimeService.ForDay(ctx, cinema, date, rules)
+		if err != nil {
+			c.JSON(500, gin.H{"error": "Failed to fetch showtimes"})
+			return
+		}
+		result = append(result, *grid)
+	}
+
+	c.JSON(200, gin.H{"cinemas": result})
+}
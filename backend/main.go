@@ -2,7 +2,6 @@ package main
 
 import (
 	"context"
-	"fmt"
 	"log"
 	"movie-ticket-backend/config"
 	"movie-ticket-backend/database"
@@ -14,8 +13,6 @@ import (
 
 	"github.com/gin-contrib/cors"
 	"github.com/gin-gonic/gin"
-	"go.mongodb.org/mongo-driver/bson"
-	"go.mongodb.org/mongo-driver/mongo/options"
 )
 
 func main() {
@@ -24,67 +21,149 @@ func main() {
 
 	// Connect DB
 	database.ConnectDB()
+	database.EnsureIndexes()
 
 	// Init Services
-	services.InitQueueService()   // Connect Kafka
-	services.StartQueueConsumer() // Listen event from kafka
-	services.InitWSHub()          // Init WebSocket Hub
-	services.InitAuditService()   // Init Audit log Service
+	services.InitQueueService()      // Connect Kafka
+	services.StartQueueConsumer()    // Listen event from kafka
+	services.InitWSHub()             // Init WebSocket Hub
+	services.InitAuditService()      // Init Audit log Service
+	services.InitTokenService()      // Load JWT signing keys
+	services.InitUserCache()         // LRU + Redis cache for authenticated users
+	services.InitIdentityProviders() // Google / OIDC / local accounts
+	services.InitStorage()           // Poster/backdrop storage
+	services.InitPaymentGateway()    // Refunds
 
 	// Start Redis Expiration Listener
 	lockService := services.NewLockService()
 	go lockService.ListenForExpireRedis()
 
-	// Seed Data (if needed)
-	if database.Mongo != nil {
+	// Demo data for local development; real catalogues are loaded with cmd/catalogctl
+	if database.Mongo != nil && config.AppConfig.SeedDemoData {
 		SeedData()
 	}
 
 	r := gin.Default()
+	// Without this gin believes any X-Forwarded-For, which would let clients pick their own rate-limit IP
+	if err := r.SetTrustedProxies(config.AppConfig.TrustedProxies); err != nil {
+		log.Fatalf("Invalid TRUSTED_PROXIES: %v", err)
+	}
 	r.Use(middleware.ErrorLogger())
 
 	r.Use(cors.New(cors.Config{
 		AllowOrigins:     []string{"*"},
 		AllowMethods:     []string{"GET", "POST", "PUT", "DELETE"},
-		AllowHeaders:     []string{"Origin", "Content-Type", "Authorization"},
-		ExposeHeaders:    []string{"Content-Length"},
+		AllowHeaders:     []string{"Origin", "Content-Type", "Authorization", middleware.APIKeyHeader},
+		ExposeHeaders:    []string{"Content-Length", "Content-Disposition", "Retry-After"},
 		AllowCredentials: true,
 	}))
 
 	api := r.Group("/api")
 	{
-		api.GET("/auth/google/login", handlers.GoogleLogin)
-		api.GET("/auth/google/callback", handlers.GoogleCallback)
+		authGroup := api.Group("/auth")
+		authGroup.Use(middleware.RateLimit(middleware.RateGroupAuth))
+		{
+			authGroup.GET("/providers", handlers.GetIdentityProviders)
+			authGroup.POST("/local/register", handlers.LocalRegister)
+			authGroup.POST("/local/login", handlers.LocalLogin)
+			authGroup.GET("/local/verify", handlers.LocalVerifyEmail)
+			authGroup.GET("/:provider/login", handlers.OAuthLogin)
+			authGroup.GET("/:provider/callback", handlers.OAuthCallback)
+			authGroup.POST("/exchange", handlers.ExchangeLoginCode)
+			authGroup.POST("/refresh", handlers.RefreshToken)
+			authGroup.POST("/logout", middleware.RequireAuth(), handlers.Logout)
+			authGroup.POST("/logout-all", middleware.RequireAuth(), handlers.LogoutAll)
+		}
 		api.GET("/movies", handlers.GetMovies)
-		api.POST("/movies", handlers.CreateMovie)
-		api.POST("/screenings/details", handlers.GetScreeningDetails)
+		api.GET("/showtimes", handlers.GetShowtimes)
+		api.POST("/screenings/details", middleware.RateLimit(middleware.RateGroupScreenings), handlers.GetScreeningDetails)
+
+		// Profile and personal data (export / account deletion)
+		meGroup := api.Group("/me")
+		meGroup.Use(middleware.RequireAuth())
+		{
+			meGroup.GET("", handlers.GetMyProfile)
+			meGroup.PUT("", handlers.UpdateMyProfile)
+			meGroup.GET("/export", handlers.ExportMyData)
+			meGroup.DELETE("", handlers.DeleteMyAccount)
+		}
 
 		// Protected Booking Routes
 		bookingGroup := api.Group("/seats")
-		bookingGroup.Use(middleware.RequireAuth())
+		bookingGroup.Use(middleware.RequireAuthOrAPIKey(), middleware.RateLimit(middleware.RateGroupSeats))
 		{
-			bookingGroup.POST("/lock", handlers.LockSeat)
-			bookingGroup.POST("/book", handlers.BookSeat)
-			bookingGroup.POST("/extend", handlers.ExtendSeatLock)
+			bookingGroup.POST("/lock", middleware.RequirePermission(models.PermSeatsLock), handlers.LockSeat)
+			bookingGroup.POST("/book", middleware.RequirePermission(models.PermBookingsCreate), handlers.BookSeat)
+			bookingGroup.POST("/extend", middleware.RequirePermission(models.PermSeatsLock), handlers.ExtendSeatLock)
 		}
 
 		// Protected Payment Routes
 		paymentGroup := api.Group("/payment")
-		paymentGroup.Use(middleware.RequireAuth())
+		paymentGroup.Use(middleware.RequireAuthOrAPIKey(), middleware.RateLimit(middleware.RateGroupPayment), middleware.RequirePermission(models.PermBookingsCreate))
 		{
 			paymentGroup.POST("/start", handlers.StartPayment)
 			paymentGroup.POST("/cancel", handlers.CancelPayment)
 		}
 		// api.POST("/seats/unlock", handlers.UnlockSeat) // Implement if needed
 
+		api.POST("/ws/ticket", middleware.RequireAuth(), services.IssueWSTicket)
 		api.GET("/ws", services.ServeWS)
 	}
 
+	// Uploaded images, served outside /api so the URLs stay stable and cacheable
+	r.GET("/media/:id/:variant", handlers.ServeMedia)
+
 	// Admin API Group (Protected)
 	adminAPI := r.Group("/api/admin")
 	adminAPI.Use(middleware.AdminAuth())
 	{
-		adminAPI.GET("/bookings", handlers.GetAllBookings)
+		// RequireCinemaPermission routes also admit staff scoped to one cinema; their handlers check the cinema
+		adminAPI.GET("/bookings", middleware.RequireCinemaPermission(models.PermBookingsRead), handlers.GetAllBookings)
+
+		// Catalogue
+		adminAPI.POST("/movies", middleware.RequirePermission(models.PermMoviesWrite), handlers.CreateMovie)
+		adminAPI.PUT("/movies/:id", middleware.RequirePermission(models.PermMoviesWrite), handlers.UpdateMovie)
+		adminAPI.DELETE("/movies/:id", middleware.RequirePermission(models.PermMoviesWrite), handlers.DeleteMovie)
+		adminAPI.POST("/media", middleware.RequirePermission(models.PermMoviesWrite), handlers.UploadMedia)
+
+		// Scheduling
+		adminAPI.POST("/screenings", middleware.RequireCinemaPermission(models.PermScreeningsWrite), handlers.CreateScreening)
+		adminAPI.PUT("/screenings/:id", middleware.RequireCinemaPermission(models.PermScreeningsWrite), handlers.RescheduleScreening)
+		adminAPI.DELETE("/screenings/:id", middleware.RequireCinemaPermission(models.PermScreeningsWrite), handlers.DeleteScreening)
+		adminAPI.POST("/screenings/:id/cancel", middleware.RequireCinemaPermission(models.PermScreeningsWrite, models.PermBookingsRefund), handlers.CancelScreening)
+		adminAPI.GET("/schedule-templates", middleware.RequireCinemaPermission(models.PermScreeningsWrite), handlers.GetScheduleTemplates)
+		adminAPI.POST("/schedule-templates", middleware.RequireCinemaPermission(models.PermScreeningsWrite), handlers.CreateScheduleTemplate)
+		adminAPI.PUT("/schedule-templates/:id", middleware.RequireCinemaPermission(models.PermScreeningsWrite), handlers.UpdateScheduleTemplate)
+		adminAPI.DELETE("/schedule-templates/:id", middleware.RequireCinemaPermission(models.PermScreeningsWrite), handlers.DeleteScheduleTemplate)
+
+		// Cinemas & halls
+		adminAPI.GET("/cinemas", handlers.GetCinemas)
+		adminAPI.POST("/cinemas", middleware.RequirePermission(models.PermCinemasWrite), handlers.CreateCinema)
+		adminAPI.GET("/cinemas/:id/halls", handlers.GetHalls)
+		adminAPI.POST("/cinemas/:id/halls", middleware.RequirePermission(models.PermCinemasWrite), handlers.CreateHall)
+		adminAPI.PUT("/halls/:id", middleware.RequirePermission(models.PermCinemasWrite), handlers.UpdateHall)
+
+		// Dynamic pricing
+		adminAPI.GET("/pricing-rules", middleware.RequirePermission(models.PermPricingWrite), handlers.GetPricingRules)
+		adminAPI.POST("/pricing-rules", middleware.RequirePermission(models.PermPricingWrite), handlers.CreatePricingRule)
+		adminAPI.PUT("/pricing-rules/:id", middleware.RequirePermission(models.PermPricingWrite), handlers.UpdatePricingRule)
+		adminAPI.DELETE("/pricing-rules/:id", middleware.RequirePermission(models.PermPricingWrite), handlers.DeletePricingRule)
+
+		// User management
+		adminAPI.GET("/users", middleware.RequirePermission(models.PermUsersRead), handlers.GetUsers)
+		adminAPI.GET("/users/:id", middleware.RequirePermission(models.PermUsersRead), handlers.GetUser)
+		adminAPI.PUT("/users/:id/roles", middleware.RequirePermission(models.PermUsersWrite), handlers.UpdateUserRoles)
+		adminAPI.POST("/users/:id/suspend", middleware.RequirePermission(models.PermUsersWrite), handlers.SuspendUser)
+		adminAPI.POST("/users/:id/reactivate", middleware.RequirePermission(models.PermUsersWrite), handlers.ReactivateUser)
+		adminAPI.POST("/users/:id/revoke-sessions", middleware.RequirePermission(models.PermUsersWrite), handlers.RevokeUserSessions)
+		adminAPI.GET("/users/:id/bookings", middleware.RequirePermission(models.PermUsersRead, models.PermBookingsRead), handlers.GetUserBookings)
+		adminAPI.GET("/users/:id/audit", middleware.RequirePermission(models.PermUsersRead, models.PermAuditRead), handlers.GetUserAuditTrail)
+
+		// Partner integrations
+		adminAPI.POST("/partners", middleware.RequirePermission(models.PermUsersWrite, models.PermAPIKeysManage), handlers.CreatePartner)
+		adminAPI.GET("/api-keys", middleware.RequirePermission(models.PermAPIKeysManage), handlers.GetAPIKeys)
+		adminAPI.POST("/api-keys", middleware.RequirePermission(models.PermAPIKeysManage), handlers.CreateAPIKey)
+		adminAPI.DELETE("/api-keys/:id", middleware.RequirePermission(models.PermAPIKeysManage), handlers.RevokeAPIKey)
 	}
 
 	r.Run(":" + config.AppConfig.Port)
@@ -96,18 +175,20 @@ func getTime(hour, min int) time.Time {
 	return time.Date(now.Year(), now.Month(), now.Day()+1, hour, min, 0, 0, now.Location())
 }
 
+// SeedData imports the demo catalogue through the same upserts as cmd/catalogctl, so restarting
+// never touches seats already sold. Only runs with SEED_DEMO_DATA=true.
 func SeedData() {
-	moviesColl := database.Mongo.Collection("movies")
-
 	// Define Mock Data to match Frontend
 	mockMovies := []struct {
 		Title          string
 		Description    string
-		Genre          string
+		Genres         []string
+		AgeRating      models.AgeRating
+		ReleaseDate    string
+		Directors      []string
 		DurationMin    int
 		PosterURL      string
 		ScreeningTimes []struct {
-			ID   string
 			Hour int
 			Min  int
 		}
@@ -115,178 +196,242 @@ func SeedData() {
 		{
 			"Avatar: The Way of Water",
 			"Jake Sully lives with his newfound family formed on the extrasolar moon Pandora...",
-			"Sci-Fi / Action",
+			[]string{"Sci-Fi", "Action"},
+			models.RatingPG13,
+			"2022-12-16",
+			[]string{"James Cameron"},
 			192,
 			"https://upload.wikimedia.org/wikipedia/en/5/54/Avatar_The_Way_of_Water_poster.jpg",
 			[]struct {
-				ID   string
 				Hour int
 				Min  int
-			}{{"s1", 10, 0}, {"s2", 14, 0}, {"s3", 18, 0}},
+			}{{10, 0}, {14, 0}, {18, 0}},
 		},
 		{
 			"Oppenheimer",
 			"The story of American scientist J. Robert Oppenheimer...",
-			"Biography / Drama",
+			[]string{"Biography", "Drama"},
+			models.RatingR,
+			"2023-07-21",
+			[]string{"Christopher Nolan"},
 			180,
 			"https://upload.wikimedia.org/wikipedia/en/4/4a/Oppenheimer_%28film%29.jpg",
 			[]struct {
-				ID   string
 				Hour int
 				Min  int
-			}{{"s4", 11, 30}, {"s5", 15, 30}},
+			}{{11, 30}, {15, 30}},
 		},
 		{
 			"Spider-Man: Across the Spider-Verse",
 			"Miles Morales catapults across the Multiverse...",
-			"Animation / Action",
+			[]string{"Animation", "Action"},
+			models.RatingPG,
+			"2023-06-02",
+			[]string{"Joaquim Dos Santos", "Kemp Powers", "Justin K. Thompson"},
 			140,
 			"https://upload.wikimedia.org/wikipedia/en/b/b4/Spider-Man-_Across_the_Spider-Verse_poster.jpg",
 			[]struct {
-				ID   string
 				Hour int
 				Min  int
-			}{{"s6", 12, 0}, {"s7", 16, 0}},
+			}{{12, 0}, {16, 0}},
 		},
 		{
 			"The Batman",
 			"When a sadistic serial killer begins murdering key political figures in Gotham...",
-			"Action / Crime",
+			[]string{"Action", "Crime"},
+			models.RatingPG13,
+			"2022-03-04",
+			[]string{"Matt Reeves"},
 			176,
 			"https://upload.wikimedia.org/wikipedia/en/f/ff/The_Batman_%28film%29_poster.jpg",
 			[]struct {
-				ID   string
 				Hour int
 				Min  int
-			}{{"s8", 19, 0}, {"s9", 22, 0}},
+			}{{19, 0}, {22, 0}},
 		},
 		{
 			"Guardians of the Galaxy Vol. 3",
 			"Still reeling from the loss of Gamora...",
-			"Action / Adventure",
+			[]string{"Action", "Adventure"},
+			models.RatingPG13,
+			"2023-05-05",
+			[]string{"James Gunn"},
 			150,
 			"https://upload.wikimedia.org/wikipedia/en/7/74/Guardians_of_the_Galaxy_Vol._3_poster.jpg",
 			[]struct {
-				ID   string
 				Hour int
 				Min  int
-			}{{"s10", 13, 0}},
+			}{{13, 0}},
 		},
 		{
 			"Dune: Part Two",
 			"Paul Atreides unites with Chani and the Fremen...",
-			"Sci-Fi / Adventure",
+			[]string{"Sci-Fi", "Adventure"},
+			models.RatingPG13,
+			"2024-03-01",
+			[]string{"Denis Villeneuve"},
 			166,
 			"https://www.siamzone.com/movie/pic/2024/duneparttwo/poster1.jpg",
 			[]struct {
-				ID   string
 				Hour int
 				Min  int
-			}{{"s11", 14, 30}, {"s12", 18, 30}},
+			}{{14, 30}, {18, 30}},
 		},
 		{
 			"Mission: Impossible - Dead Reckoning",
 			"Ethan Hunt and his IMF team must track down a dangerous new weapon...",
-			"Action / Thriller",
+			[]string{"Action", "Thriller"},
+			models.RatingPG13,
+			"2023-07-12",
+			[]string{"Christopher McQuarrie"},
 			163,
 			"https://theatrgwaun.com/wp-content/uploads/2023/07/TG-Aug23-web-700px-mission-768x768.jpg",
 			[]struct {
-				ID   string
 				Hour int
 				Min  int
-			}{{"s13", 10, 30}, {"s14", 15, 0}},
+			}{{10, 30}, {15, 0}},
 		},
 		{
 			"Barbie",
 			"Barbie suffers a crisis that leads her to question her world and her existence.",
-			"Adventure / Comedy",
+			[]string{"Adventure", "Comedy"},
+			models.RatingPG13,
+			"2023-07-21",
+			[]string{"Greta Gerwig"},
 			114,
 			"https://upload.wikimedia.org/wikipedia/en/0/0b/Barbie_2023_poster.jpg",
 			[]struct {
-				ID   string
 				Hour int
 				Min  int
-			}{{"s15", 11, 0}, {"s16", 13, 30}, {"s17", 16, 0}},
+			}{{11, 0}, {13, 30}, {16, 0}},
 		},
 		{
 			"John Wick: Chapter 4",
 			"John Wick uncovers a path to defeating The High Table.",
-			"Action / Crime",
+			[]string{"Action", "Crime"},
+			models.RatingR,
+			"2023-03-24",
+			[]string{"Chad Stahelski"},
 			169,
 			"https://assets-prd.ignimgs.com/2023/02/08/jw4-2025x3000-online-character-1sht-keanu-v187-1675886090936.jpg",
 			[]struct {
-				ID   string
 				Hour int
 				Min  int
-			}{{"s18", 20, 0}},
+			}{{20, 0}},
 		},
 		{
 			"Inside Out 2",
 			"Joy, Sadness, Anger, Fear and Disgust have been running a successful operation...",
-			"Animation / Family",
+			[]string{"Animation", "Family"},
+			models.RatingPG,
+			"2024-06-14",
+			[]string{"Kelsey Mann"},
 			100,
 			"https://upload.wikimedia.org/wikipedia/en/f/f7/Inside_Out_2_poster.jpg",
 			[]struct {
-				ID   string
 				Hour int
 				Min  int
-			}{{"s19", 9, 0}, {"s20", 11, 0}},
+			}{{9, 0}, {11, 0}},
 		},
 	}
 
+	// STANDARD and WHEELCHAIR seats use the base price
+	seedPrices := map[models.SeatType]float64{models.SeatPremium: 260, models.SeatVIP: 350, models.SeatCouple: 500}
+
+	cinema := seedCinema()
+	catalog := models.Catalog{Cinemas: []models.CatalogCinema{cinema}}
+	screeningCount := 0
+
 	for _, m := range mockMovies {
-		var screenings []models.Screening
+		catalog.Movies = append(catalog.Movies, models.CatalogMovie{
+			Title:            m.Title,
+			Description:      m.Description,
+			Genres:           m.Genres,
+			AgeRating:        m.AgeRating,
+			ReleaseDate:      m.ReleaseDate,
+			Language:         "en",
+			SubtitleLanguage: "th",
+			Directors:        m.Directors,
+			DurationMin:      m.DurationMin,
+			PosterURL:        m.PosterURL,
+		})
+		// Showtimes are tomorrow, so each day's first start adds a fresh set
 		for _, st := range m.ScreeningTimes {
-			// Create Seats
-			var seats []models.Seat
-			for r := 0; r < 5; r++ { // 5 Rows
-				rowChar := string(rune('A' + r))
-				for n := 1; n <= 8; n++ { // 8 Cols
-					status := models.SeatAvailable
-					seats = append(seats, models.Seat{
-						ID:     fmt.Sprintf("%s%d", rowChar, n),
-						Row:    rowChar,
-						Number: n,
-						Status: status,
-					})
-				}
-			}
-
-			screenings = append(screenings, models.Screening{
-				ID:        st.ID,
+			hall := cinema.Halls[screeningCount%len(cinema.Halls)]
+			screeningCount++
+			catalog.Screenings = append(catalog.Screenings, models.CatalogScreening{
+				Movie:     m.Title,
+				Cinema:    cinema.Name,
+				Hall:      hall.Name,
 				StartTime: getTime(st.Hour, st.Min),
 				Price:     200,
-				Seats:     seats,
+				Prices:    seedPrices,
 			})
 		}
+	}
 
-		newMovie := models.Movie{
-			Title:       m.Title,
-			Description: m.Description,
-			Genre:       m.Genre,
-			DurationMin: m.DurationMin,
-			PosterURL:   m.PosterURL,
-			Screenings:  screenings,
-		}
+	// The demo schedule predates the hall overlap check, so overlaps are allowed here
+	report, err := services.NewCatalogService().Import(context.TODO(), &catalog, services.ImportOptions{AllowOverlaps: true})
+	if err != nil {
+		log.Printf("Failed to seed demo data: %v", err)
+		return
+	}
+	for _, reason := range report.Skipped {
+		log.Printf("Seed skipped %s", reason)
+	}
+	log.Printf("Demo data seeded: %d movies and %d screenings created", report.Created["movies"], report.Created["screenings"])
+}
 
-		// Use Upsert to either Insert or Update existing by title
-		filter := bson.M{"title": m.Title}
-		update := bson.M{
-			"$set": bson.M{
-				"description":  newMovie.Description,
-				"genre":        newMovie.Genre,
-				"duration_min": newMovie.DurationMin,
-				"poster_url":   newMovie.PosterURL,
-				"screenings":   newMovie.Screenings,
-			},
+// seedCinema describes the demo cinema and its halls
+func seedCinema() models.CatalogCinema {
+	// Hall 1 keeps the original A1-E8 seat IDs, split by a centre aisle
+	hall1 := seedSeatMap([]string{"A", "B", "C", "D", "E"}, 4, 4)
+
+	// Hall 4: wheelchair spaces at the front, a pillar in row D, premium row G, VIP row H
+	// and couple sofas at the back
+	hall4 := seedSeatMap([]string{"A", "B", "C", "D", "E", "F", "G", "H"}, 3, 6, 3)
+	hall4.Rows[0].Cells[0].Type = models.SeatWheelchair
+	hall4.Rows[0].Cells[len(hall4.Rows[0].Cells)-1].Type = models.SeatWheelchair
+	hall4.Rows[3].Cells[0] = models.SeatMapCell{Kind: models.CellGap}
+	for rowIdx, seatType := range map[int]models.SeatType{6: models.SeatPremium, 7: models.SeatVIP} {
+		cells := hall4.Rows[rowIdx].Cells
+		for i := range cells {
+			if cells[i].Kind == models.CellSeat {
+				cells[i].Type = seatType
+			}
 		}
+	}
+	sofas := seedSeatMap([]string{"J"}, 2, 2, 2)
+	for i := range sofas.Rows[0].Cells {
+		if sofas.Rows[0].Cells[i].Kind == models.CellSeat {
+			sofas.Rows[0].Cells[i].Type = models.SeatCouple
+		}
+	}
+	hall4.Rows = append(hall4.Rows, sofas.Rows...)
+
+	return models.CatalogCinema{
+		Name:     "Movie Ticket Cinema",
+		Timezone: "Asia/Bangkok",
+		Halls:    []models.CatalogHall{{Name: "Hall 1", SeatMap: hall1}, {Name: "Hall 4 (Laser)", SeatMap: hall4}},
+	}
+}
 
-		_, err := moviesColl.UpdateOne(context.TODO(), filter, update, options.Update().SetUpsert(true))
-		if err != nil {
-			log.Printf("Failed to seed %s: %v", m.Title, err)
-		} else {
-			log.Printf("Seeded/Updated %s (Price: 200)", m.Title)
+// seedSeatMap builds rows of seat blocks separated by aisles, numbering seats left to right
+func seedSeatMap(rows []string, blocks ...int) models.SeatMap {
+	var m models.SeatMap
+	for _, label := range rows {
+		row := models.SeatMapRow{Label: label}
+		number := 1
+		for b, size := range blocks {
+			if b > 0 {
+				row.Cells = append(row.Cells, models.SeatMapCell{Kind: models.CellAisle})
+			}
+			for i := 0; i < size; i++ {
+				row.Cells = append(row.Cells, models.SeatMapCell{Kind: models.CellSeat, Number: number})
+				number++
+			}
 		}
+		m.Rows = append(m.Rows, row)
 	}
-	log.Println("All Mock Data Seeded!")
+	return m
 }
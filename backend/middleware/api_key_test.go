@@ -0,0 +1,33 @@
+package middleware
+
+import (
+	"net/http/httptest"
+	"testing"
+
+	"movie-ticket-backend/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestAPIKeyHasScope(t *testing.T) {
+	tests := []struct {
+		name   string
+		scopes []models.Permission // nil = bearer token, no key
+		want   bool
+	}{
+		{"bearer token", nil, false},
+		{"key without the scope", []models.Permission{models.PermSeatsLock, models.PermBookingsCreate}, false},
+		{"key with the scope", []models.Permission{models.PermSeatsLock, models.PermAgeAttested}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, _ := gin.CreateTestContext(httptest.NewRecorder())
+			if tt.scopes != nil {
+				c.Set("apiKeyScopes", tt.scopes)
+			}
+			if got := APIKeyHasScope(c, models.PermAgeAttested); got != tt.want {
+				t.Errorf("APIKeyHasScope = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
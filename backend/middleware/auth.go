@@ -1,117 +1,175 @@
 package middleware
 
 import (
-	"context"
 	"fmt"
-	"movie-ticket-backend/database"
 	"movie-ticket-backend/models"
+	"movie-ticket-backend/services"
 	"strings"
 
 	"github.com/gin-gonic/gin"
-	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 )
 
 // RequireAuth middleware for general user access
 func RequireAuth() gin.HandlerFunc {
 	return func(c *gin.Context) {
-		authHeader := c.GetHeader("Authorization")
-		if authHeader == "" {
-			c.JSON(401, gin.H{"error": "Authorization header required"})
-			c.Abort()
-			return
-		}
-
-		parts := strings.Split(authHeader, " ")
-		if len(parts) != 2 || parts[0] != "Bearer" {
-			c.JSON(401, gin.H{"error": "Invalid authorization format"})
-			c.Abort()
-			return
-		}
-
-		token := parts[1]
-		if !strings.HasPrefix(token, "real-jwt-") {
-			c.JSON(401, gin.H{"error": "Invalid token"})
-			c.Abort()
-			return
-		}
-
-		userIDHex := strings.TrimPrefix(token, "real-jwt-")
-		objID, err := primitive.ObjectIDFromHex(userIDHex)
-		if err != nil {
-			c.JSON(401, gin.H{"error": "Invalid User ID in token"})
-			c.Abort()
+		token, ok := bearerToken(c)
+		if !ok {
 			return
 		}
 
-		// Verify User Exists in DB
-		collection := database.Mongo.Collection("users")
-		var user models.User
-		err = collection.FindOne(context.TODO(), bson.M{"_id": objID}).Decode(&user)
+		// Signature, expiry, session revocation and user lookup (cached) in one place
+		claims, user, err := services.NewTokenService().AuthenticateAccessToken(c.Request.Context(), token)
 		if err != nil {
-			c.JSON(401, gin.H{"error": "User not found"})
+			status, message := authErrorResponse(err)
+			c.JSON(status, gin.H{"error": message})
 			c.Abort()
 			return
 		}
 
 		// Store userID string in context for Handlers
-		c.Set("userID", userIDHex)
-		c.Set("user", user)
+		c.Set("userID", claims.Subject)
+		c.Set("sessionID", claims.SessionID)
+		c.Set("user", *user)
 		c.Next()
 	}
 }
 
+// bearerToken extracts the token from "Authorization: Bearer <token>" and aborts if missing
+func bearerToken(c *gin.Context) (string, bool) {
+	authHeader := c.GetHeader("Authorization")
+	if authHeader == "" {
+		c.JSON(401, gin.H{"error": "Authorization header required"})
+		c.Abort()
+		return "", false
+	}
+
+	parts := strings.Split(authHeader, " ")
+	if len(parts) != 2 || parts[0] != "Bearer" {
+		c.JSON(401, gin.H{"error": "Invalid authorization format"})
+		c.Abort()
+		return "", false
+	}
+	return parts[1], true
+}
+
+func authErrorResponse(err error) (int, string) {
+	switch err {
+	case services.ErrSessionRevoked:
+		return 401, "Session has been revoked"
+	case services.ErrUserNotFound:
+		return 401, "User not found"
+	case services.ErrAccountSuspended:
+		return 403, "Account suspended"
+	case services.ErrInvalidAccessToken:
+		return 401, "Invalid or expired token"
+	}
+	return 500, "Failed to authenticate"
+}
+
+// AdminAuth lets in any staff user (a role with admin:access, even if only for one cinema).
+// Use RequirePermission or RequireCinemaPermission on each route for the specific action.
 func AdminAuth() gin.HandlerFunc {
+	requireAuth := RequireAuth()
+	requireAdminAccess := RequireCinemaPermission(models.PermAdminAccess)
 	return func(c *gin.Context) {
-		authHeader := c.GetHeader("Authorization")
-		if authHeader == "" {
-			c.JSON(401, gin.H{"error": "Authorization header required"})
-			c.Abort()
+		requireAuth(c)
+		if c.IsAborted() {
 			return
 		}
+		requireAdminAccess(c)
+	}
+}
 
-		// Expected format: "Bearer real-jwt-{userID}"
-		parts := strings.Split(authHeader, " ")
-		if len(parts) != 2 || parts[0] != "Bearer" {
-			c.JSON(401, gin.H{"error": "Invalid authorization format"})
-			c.Abort()
-			return
-		}
+// RequirePermission must run after RequireAuth. The user needs every listed permission across
+// all cinemas; a role assigned for one cinema does not count.
+func RequirePermission(perms ...models.Permission) gin.HandlerFunc {
+	return requirePermissions(false, perms)
+}
 
-		token := parts[1]
-		if !strings.HasPrefix(token, "real-jwt-") {
-			c.JSON(401, gin.H{"error": "Invalid token"})
+// RequireCinemaPermission lets in users holding every listed permission for at least one cinema.
+// The handler must then check the cinema it acts on with AllowCinema, or narrow listings with CinemaScope.
+func RequireCinemaPermission(perms ...models.Permission) gin.HandlerFunc {
+	return requirePermissions(true, perms)
+}
+
+func requirePermissions(anyCinema bool, perms []models.Permission) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		val, exists := c.Get("user")
+		if !exists {
+			c.JSON(401, gin.H{"error": "Unauthorized"})
 			c.Abort()
 			return
 		}
+		user := val.(models.User)
+		// Requests made with an API key are limited to the key's scopes
+		keyScopes, hasKey := c.Get("apiKeyScopes")
 
-		userIDHex := strings.TrimPrefix(token, "real-jwt-")
-		objID, err := primitive.ObjectIDFromHex(userIDHex)
-		if err != nil {
-			c.JSON(401, gin.H{"error": "Invalid User ID in token"})
-			c.Abort()
-			return
+		for _, perm := range perms {
+			allowed := user.HasPermission(perm, "")
+			if anyCinema {
+				allowed = user.HasPermissionAnywhere(perm)
+			}
+			if allowed && hasKey {
+				allowed = models.APIKey{Scopes: keyScopes.([]models.Permission)}.HasScope(perm)
+			}
+			if !allowed {
+				denyPermission(c, user, perm, "")
+				return
+			}
 		}
+		c.Next()
+	}
+}
 
-		// Check User Role in DB
-		collection := database.Mongo.Collection("users")
-		var user models.User
-		err = collection.FindOne(context.TODO(), bson.M{"_id": objID}).Decode(&user)
-		if err != nil {
-			c.JSON(401, gin.H{"error": "User not found"})
-			c.Abort()
-			return
+// AllowCinema checks that the user holds every permission for the cinema (zero ID = not tied to
+// a cinema, which needs the permission globally). Writes a 403 and returns false otherwise.
+func AllowCinema(c *gin.Context, cinemaID primitive.ObjectID, perms ...models.Permission) bool {
+	val, _ := c.Get("user")
+	user, _ := val.(models.User)
+	scope := ""
+	if !cinemaID.IsZero() {
+		scope = cinemaID.Hex()
+	}
+	for _, perm := range perms {
+		if !user.HasPermission(perm, scope) {
+			denyPermission(c, user, perm, scope)
+			return false
 		}
+	}
+	return true
+}
 
-		if user.Role != models.RoleAdmin {
-			fmt.Printf("Access Denied: User %s (%s) tried to access Admin API\n", user.Name, user.Role)
-			c.JSON(403, gin.H{"error": "Admin access required"})
-			c.Abort()
-			return
+// CinemaScope returns the cinemas a listing must be narrowed to; global is true when the user
+// holds the permission everywhere.
+func CinemaScope(c *gin.Context, perm models.Permission) (cinemaIDs []primitive.ObjectID, global bool) {
+	val, _ := c.Get("user")
+	user, _ := val.(models.User)
+	ids, global := user.PermissionCinemaIDs(perm)
+	if global {
+		return nil, true
+	}
+	for _, id := range ids {
+		if objID, err := primitive.ObjectIDFromHex(id); err == nil {
+			cinemaIDs = append(cinemaIDs, objID)
 		}
+	}
+	return cinemaIDs, false
+}
 
-		// Pass User to context if needed
-		c.Set("user", user)
-		c.Next()
+func denyPermission(c *gin.Context, user models.User, perm models.Permission, cinemaID string) {
+	fmt.Printf("Access Denied: User %s (%s) lacks %s\n", user.Name, user.Role, perm)
+	details := map[string]interface{}{
+		"permission": perm,
+		"method":     c.Request.Method,
+		"path":       c.Request.URL.Path,
+	}
+	message := fmt.Sprintf("Permission %s required", perm)
+	if cinemaID != "" {
+		details["cinema_id"] = cinemaID
+		message = fmt.Sprintf("Permission %s required for this cinema", perm)
 	}
+	services.LogWarn("ACCESS_DENIED", user.ID.Hex(), details)
+	c.JSON(403, gin.H{"error": message})
+	c.Abort()
 }
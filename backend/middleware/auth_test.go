@@ -0,0 +1,87 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"movie-ticket-backend/models"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+var (
+	cinemaA = primitive.NewObjectID()
+	cinemaB = primitive.NewObjectID()
+	// A customer account that manages cinema A only
+	scopedManager = models.User{
+		ID:              primitive.NewObjectID(),
+		Role:            models.RoleUser,
+		RoleAssignments: []models.RoleAssignment{{Role: models.RoleCinemaManager, CinemaID: cinemaA.Hex()}},
+	}
+)
+
+func init() {
+	gin.SetMode(gin.TestMode)
+}
+
+// serve runs the handlers for one request made as user and returns the status code
+func serve(user models.User, handlers ...gin.HandlerFunc) int {
+	r := gin.New()
+	chain := append([]gin.HandlerFunc{func(c *gin.Context) { c.Set("user", user) }}, handlers...)
+	chain = append(chain, func(c *gin.Context) { c.Status(http.StatusOK) })
+	r.POST("/", chain...)
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/", nil))
+	return w.Code
+}
+
+// allowCinema mimics a handler acting on a screening of the cinema
+func allowCinema(cinemaID primitive.ObjectID, perms ...models.Permission) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !AllowCinema(c, cinemaID, perms...) {
+			return
+		}
+		c.Next()
+	}
+}
+
+func TestScopedManagerCinemaAccess(t *testing.T) {
+	cancel := []models.Permission{models.PermScreeningsWrite, models.PermBookingsRefund}
+	tests := []struct {
+		name     string
+		handlers []gin.HandlerFunc
+		want     int
+	}{
+		{"cinema route lets the manager in", []gin.HandlerFunc{RequireCinemaPermission(cancel...)}, 200},
+		{"own cinema", []gin.HandlerFunc{RequireCinemaPermission(cancel...), allowCinema(cinemaA, cancel...)}, 200},
+		{"other cinema", []gin.HandlerFunc{RequireCinemaPermission(cancel...), allowCinema(cinemaB, cancel...)}, 403},
+		{"screening without a cinema", []gin.HandlerFunc{allowCinema(primitive.NilObjectID, cancel...)}, 403},
+		{"global route", []gin.HandlerFunc{RequirePermission(models.PermScreeningsWrite)}, 403},
+		{"permission the role lacks", []gin.HandlerFunc{RequireCinemaPermission(models.PermMoviesWrite)}, 403},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := serve(scopedManager, tt.handlers...); got != tt.want {
+				t.Errorf("status = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestGlobalAdminCinemaAccess(t *testing.T) {
+	admin := models.User{ID: primitive.NewObjectID(), Role: models.RoleAdmin}
+	if got := serve(admin, RequirePermission(models.PermScreeningsWrite), allowCinema(cinemaB, models.PermScreeningsWrite)); got != 200 {
+		t.Errorf("status = %d, want 200", got)
+	}
+}
+
+func TestCinemaScope(t *testing.T) {
+	c, _ := gin.CreateTestContext(httptest.NewRecorder())
+	c.Set("user", scopedManager)
+	ids, global := CinemaScope(c, models.PermBookingsRead)
+	if global || len(ids) != 1 || ids[0] != cinemaA {
+		t.Errorf("CinemaScope = %v, %v; want [%s], false", ids, global, cinemaA.Hex())
+	}
+}
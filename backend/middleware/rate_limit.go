@@ -0,0 +1,135 @@
+package middleware
+
+import (
+	"fmt"
+	"log"
+	"math"
+	"movie-ticket-backend/config"
+	"movie-ticket-backend/services"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Route groups with their own buckets
+const (
+	RateGroupSeats      = "seats"
+	RateGroupPayment    = "payment"
+	RateGroupScreenings = "screenings"
+	RateGroupAuth       = "auth"
+)
+
+func rateLimitSpecs(group string) (userSpec, ipSpec string) {
+	cfg := config.AppConfig
+	switch group {
+	case RateGroupSeats:
+		return cfg.RateLimitSeatsUser, cfg.RateLimitSeatsIP
+	case RateGroupPayment:
+		return cfg.RateLimitPaymentUser, cfg.RateLimitPaymentIP
+	case RateGroupScreenings:
+		// Public route: there is no user to key on, only the client IP
+		return "", cfg.RateLimitScreeningsIP
+	case RateGroupAuth:
+		// Unauthenticated too; the per-account bucket is applied by the handlers
+		// through RateLimitAccount once they have read the submitted email
+		return "", cfg.RateLimitAuthIP
+	}
+	return "", ""
+}
+
+// RateLimit applies the group's per-IP limit and, when the request is authenticated
+// (place it after RequireAuth), the per-user limit. Exceeding either returns 429.
+// The client IP only honours X-Forwarded-For from TRUSTED_PROXIES.
+func RateLimit(group string) gin.HandlerFunc {
+	if !config.AppConfig.RateLimitEnabled {
+		return func(c *gin.Context) { c.Next() }
+	}
+
+	userSpec, ipSpec := rateLimitSpecs(group)
+	userLimit, err := services.ParseRateLimit(userSpec)
+	if err != nil {
+		log.Fatalf("Rate limit config for %s: %v", group, err)
+	}
+	ipLimit, err := services.ParseRateLimit(ipSpec)
+	if err != nil {
+		log.Fatalf("Rate limit config for %s: %v", group, err)
+	}
+	if group == RateGroupAuth {
+		// Fail at startup rather than on the first login
+		if _, err := services.ParseRateLimit(config.AppConfig.RateLimitAuthEmail); err != nil {
+			log.Fatalf("Rate limit config for %s: %v", group, err)
+		}
+	}
+
+	return func(c *gin.Context) {
+		if !enforceRateLimit(c, group, "ip", c.ClientIP(), ipLimit) {
+			return
+		}
+		if !enforceRateLimit(c, group, "user", c.GetString("userID"), userLimit) {
+			return
+		}
+		c.Next()
+	}
+}
+
+// RateLimitAccount applies the auth group's per-account limit, keyed on the email
+// the client submitted together with the client IP. Guessing one account's password
+// from an IP is throttled far below the IP-wide limit, while a stranger who knows the
+// email cannot use up the bucket and lock the owner out of logging in from elsewhere.
+// It writes the 429 itself and returns false when the request must stop.
+func RateLimitAccount(c *gin.Context, email string) bool {
+	if !config.AppConfig.RateLimitEnabled {
+		return true
+	}
+	limit, err := services.ParseRateLimit(config.AppConfig.RateLimitAuthEmail)
+	if err != nil {
+		log.Printf("Rate limit config for %s: %v", RateGroupAuth, err)
+		return true
+	}
+	email = strings.ToLower(strings.TrimSpace(email))
+	if email == "" {
+		return true
+	}
+	return enforceRateLimit(c, RateGroupAuth, "email", email+":"+c.ClientIP(), limit)
+}
+
+// enforceRateLimit counts the request against one bucket. A nil limit or empty id
+// means the bucket does not apply.
+func enforceRateLimit(c *gin.Context, group, scope, id string, limit *services.RateLimit) bool {
+	if limit == nil || id == "" {
+		return true
+	}
+
+	limiter := services.NewRateLimiter()
+	key := fmt.Sprintf("%s:%s:%s", group, scope, id)
+	allowed, retryAfter, err := limiter.Allow(c.Request.Context(), key, *limit)
+	if err != nil {
+		// Fail open: an unavailable Redis should not take booking down with it
+		log.Printf("Rate limiter error (%s): %v", key, err)
+		return true
+	}
+	if allowed {
+		return true
+	}
+
+	if limiter.ShouldAuditRateLimit(c.Request.Context(), key, time.Minute) {
+		services.LogWarn("RATE_LIMITED", c.GetString("userID"), map[string]interface{}{
+			"group":  group,
+			"scope":  scope,
+			"ip":     c.ClientIP(),
+			"method": c.Request.Method,
+			"path":   c.Request.URL.Path,
+		})
+	}
+
+	seconds := int(math.Ceil(retryAfter.Seconds()))
+	if seconds < 1 {
+		seconds = 1
+	}
+	c.Header("Retry-After", strconv.Itoa(seconds))
+	c.JSON(429, gin.H{"error": "Too many requests, please slow down", "retry_after": seconds})
+	c.Abort()
+	return false
+}
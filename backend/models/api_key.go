@@ -0,0 +1,40 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// APIKey lets a partner system call the API as its partner account.
+// Only the SHA-256 hash of the key is stored; the plaintext is shown once at creation.
+type APIKey struct {
+	ID         primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	Name       string             `bson:"name" json:"name"`
+	Prefix     string             `bson:"prefix" json:"prefix"` // First characters of the key, to recognise it in the UI
+	KeyHash    string             `bson:"key_hash" json:"-"`
+	UserID     string             `bson:"user_id" json:"user_id"` // Partner account the key acts as
+	Scopes     []Permission       `bson:"scopes" json:"scopes"`   // Subset of the partner account's permissions
+	ExpiresAt  *time.Time         `bson:"expires_at,omitempty" json:"expires_at,omitempty"`
+	LastUsedAt *time.Time         `bson:"last_used_at,omitempty" json:"last_used_at,omitempty"`
+	LastUsedIP string             `bson:"last_used_ip,omitempty" json:"last_used_ip,omitempty"`
+	CreatedBy  string             `bson:"created_by" json:"created_by"`
+	CreatedAt  time.Time          `bson:"created_at" json:"created_at"`
+	RevokedAt  *time.Time         `bson:"revoked_at,omitempty" json:"revoked_at,omitempty"`
+}
+
+func (k APIKey) IsActive(now time.Time) bool {
+	if k.RevokedAt != nil {
+		return false
+	}
+	return k.ExpiresAt == nil || now.Before(*k.ExpiresAt)
+}
+
+func (k APIKey) HasScope(perm Permission) bool {
+	for _, s := range k.Scopes {
+		if s == perm {
+			return true
+		}
+	}
+	return false
+}
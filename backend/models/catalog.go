@@ -0,0 +1,96 @@
+package models
+
+import (
+	"fmt"
+	"time"
+)
+
+// Catalog is the fixture format read and written by cmd/catalogctl. Records refer to each other
+// by name (cinema, hall) and title (movie) rather than IDs, so a catalog can move between databases.
+type Catalog struct {
+	Cinemas    []CatalogCinema    `json:"cinemas,omitempty"`
+	Movies     []CatalogMovie     `json:"movies,omitempty"`
+	Screenings []CatalogScreening `json:"screenings,omitempty"`
+}
+
+type CatalogCinema struct {
+	Name     string        `json:"name"`
+	Address  string        `json:"address,omitempty"`
+	Timezone string        `json:"timezone"`
+	Halls    []CatalogHall `json:"halls,omitempty"`
+}
+
+type CatalogHall struct {
+	Name    string  `json:"name"`
+	SeatMap SeatMap `json:"seat_map"`
+}
+
+// CatalogMovie is matched to existing movies by title. Import only writes the fields that are set,
+// so a file listing just title and duration_min leaves the rest of an existing movie alone.
+// Uploaded media is not part of the catalog; poster_url is exported as-is.
+type CatalogMovie struct {
+	Title            string    `json:"title"`
+	Description      string    `json:"description,omitempty"`
+	Genres           []string  `json:"genres,omitempty"`
+	AgeRating        AgeRating `json:"age_rating,omitempty"`
+	ReleaseDate      string    `json:"release_date,omitempty"`
+	Language         string    `json:"language,omitempty"`
+	SubtitleLanguage string    `json:"subtitle_language,omitempty"`
+	Directors        []string  `json:"directors,omitempty"`
+	Cast             []string  `json:"cast,omitempty"`
+	DurationMin      int       `json:"duration_min"`
+	PosterURL        string    `json:"poster_url,omitempty"`
+	TrailerURL       string    `json:"trailer_url,omitempty"`
+}
+
+// CatalogScreening is matched to an existing screening by movie, hall and start time.
+// Seat state is never part of a catalog.
+type CatalogScreening struct {
+	Movie     string               `json:"movie"` // Title
+	Cinema    string               `json:"cinema"`
+	Hall      string               `json:"hall"`
+	StartTime time.Time            `json:"start_time"`
+	Price     float64              `json:"price"`
+	Prices    map[SeatType]float64 `json:"prices,omitempty"`
+	Format    ScreeningFormat      `json:"format,omitempty"`
+	Audio     AudioVersion         `json:"audio,omitempty"`
+}
+
+func (m CatalogMovie) Validate() error {
+	return MovieDetails{
+		Title:            m.Title,
+		DurationMin:      m.DurationMin,
+		AgeRating:        m.AgeRating,
+		ReleaseDate:      m.ReleaseDate,
+		Language:         m.Language,
+		SubtitleLanguage: m.SubtitleLanguage,
+		TrailerURL:       m.TrailerURL,
+	}.Validate()
+}
+
+func (s CatalogScreening) Validate() error {
+	if s.Movie == "" || s.Cinema == "" || s.Hall == "" {
+		return fmt.Errorf("movie, cinema and hall are required")
+	}
+	if s.StartTime.IsZero() {
+		return fmt.Errorf("start_time is required")
+	}
+	if s.Price <= 0 {
+		return fmt.Errorf("price must be positive")
+	}
+	if s.Format != "" && !IsValidFormat(s.Format) {
+		return fmt.Errorf("unknown format %s", s.Format)
+	}
+	if s.Audio != "" && !IsValidAudio(s.Audio) {
+		return fmt.Errorf("unknown audio %s", s.Audio)
+	}
+	for seatType, price := range s.Prices {
+		if !IsValidSeatType(seatType) {
+			return fmt.Errorf("unknown seat type %s", seatType)
+		}
+		if price <= 0 {
+			return fmt.Errorf("price for %s must be positive", seatType)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,176 @@
+package models
+
+import (
+	"fmt"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type Cinema struct {
+	ID        primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	Name      string             `bson:"name" json:"name"`
+	Address   string             `bson:"address,omitempty" json:"address,omitempty"`
+	Timezone  string             `bson:"timezone" json:"timezone"` // IANA name, e.g. Asia/Bangkok
+	CreatedAt time.Time          `bson:"created_at" json:"created_at"`
+	UpdatedAt time.Time          `bson:"updated_at" json:"updated_at"`
+}
+
+// Location is the cinema's timezone (server local time if unset or invalid)
+func (c Cinema) Location() *time.Location {
+	if loc, err := time.LoadLocation(c.Timezone); err == nil && c.Timezone != "" {
+		return loc
+	}
+	return time.Local
+}
+
+// Hall is an auditorium in a cinema. Its seat map is the template new screenings are created from.
+type Hall struct {
+	ID        primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	CinemaID  primitive.ObjectID `bson:"cinema_id" json:"cinema_id"`
+	Name      string             `bson:"name" json:"name"`
+	SeatMap   SeatMap            `bson:"seat_map" json:"seat_map"`
+	CreatedAt time.Time          `bson:"created_at" json:"created_at"`
+	UpdatedAt time.Time          `bson:"updated_at" json:"updated_at"`
+}
+
+type SeatType string
+
+const (
+	SeatStandard   SeatType = "STANDARD"
+	SeatPremium    SeatType = "PREMIUM"
+	SeatVIP        SeatType = "VIP"
+	SeatCouple     SeatType = "COUPLE" // Sofa for two, sold as one seat
+	SeatWheelchair SeatType = "WHEELCHAIR"
+)
+
+var validSeatTypes = map[SeatType]bool{SeatStandard: true, SeatPremium: true, SeatVIP: true, SeatCouple: true, SeatWheelchair: true}
+
+func IsValidSeatType(t SeatType) bool {
+	return validSeatTypes[t]
+}
+
+type CellKind string
+
+const (
+	CellSeat  CellKind = "SEAT"
+	CellAisle CellKind = "AISLE" // Walkway, drawn as an empty column
+	CellGap   CellKind = "GAP"   // No seat at this position (pillar, stairs, uneven rows)
+)
+
+// SeatMap describes an auditorium row by row, front (closest to the screen) first.
+// Each cell is one grid position, so aisles and gaps line up across rows.
+type SeatMap struct {
+	Rows []SeatMapRow `bson:"rows" json:"rows"`
+}
+
+type SeatMapRow struct {
+	Label string        `bson:"label" json:"label"`
+	Cells []SeatMapCell `bson:"cells" json:"cells"`
+}
+
+type SeatMapCell struct {
+	Kind     CellKind `bson:"kind" json:"kind"`
+	Number   int      `bson:"number,omitempty" json:"number,omitempty"`     // SEAT only
+	Type     SeatType `bson:"type,omitempty" json:"type,omitempty"`         // SEAT only, empty = STANDARD
+	Disabled bool     `bson:"disabled,omitempty" json:"disabled,omitempty"` // Shown on the map but never sold
+}
+
+// seatID is how screenings and bookings name a seat: row label then number, e.g. "A11"
+func seatID(label string, number int) string {
+	return fmt.Sprintf("%s%d", label, number)
+}
+
+// Validate checks the template is well formed: unique row labels and seat numbers, known kinds and types.
+// Seat IDs must be unique across rows too, since rows "A" and "A1" would both name a seat "A11".
+func (m SeatMap) Validate() error {
+	if len(m.Rows) == 0 {
+		return fmt.Errorf("seat map needs at least one row")
+	}
+	labels := make(map[string]bool)
+	ids := make(map[string]string) // Seat ID -> row that produced it
+	seats := 0
+	for i, row := range m.Rows {
+		if row.Label == "" {
+			return fmt.Errorf("rows[%d]: label is required", i)
+		}
+		if labels[row.Label] {
+			return fmt.Errorf("rows[%d]: duplicate label %s", i, row.Label)
+		}
+		labels[row.Label] = true
+
+		numbers := make(map[int]bool)
+		for j, cell := range row.Cells {
+			switch cell.Kind {
+			case CellSeat:
+				if cell.Number <= 0 {
+					return fmt.Errorf("row %s cells[%d]: seat number must be positive", row.Label, j)
+				}
+				if numbers[cell.Number] {
+					return fmt.Errorf("row %s cells[%d]: duplicate seat number %d", row.Label, j, cell.Number)
+				}
+				numbers[cell.Number] = true
+				id := seatID(row.Label, cell.Number)
+				if other, taken := ids[id]; taken {
+					return fmt.Errorf("row %s cells[%d]: seat ID %s is also produced by row %s", row.Label, j, id, other)
+				}
+				ids[id] = row.Label
+				if cell.Type != "" && !validSeatTypes[cell.Type] {
+					return fmt.Errorf("row %s cells[%d]: unknown seat type %s", row.Label, j, cell.Type)
+				}
+				if !cell.Disabled {
+					seats++
+				}
+			case CellAisle, CellGap:
+			default:
+				return fmt.Errorf("row %s cells[%d]: unknown kind %s", row.Label, j, cell.Kind)
+			}
+		}
+	}
+	if seats == 0 {
+		return fmt.Errorf("seat map has no sellable seats")
+	}
+	return nil
+}
+
+// Seats builds the sellable seats of a screening from the template (disabled positions are skipped)
+func (m SeatMap) Seats() []Seat {
+	var seats []Seat
+	for _, row := range m.Rows {
+		for _, cell := range row.Cells {
+			if cell.Kind != CellSeat || cell.Disabled {
+				continue
+			}
+			seatType := cell.Type
+			if seatType == "" {
+				seatType = SeatStandard
+			}
+			seats = append(seats, Seat{
+				ID:     seatID(row.Label, cell.Number),
+				Row:    row.Label,
+				Number: cell.Number,
+				Type:   seatType,
+				Status: SeatAvailable,
+			})
+		}
+	}
+	return seats
+}
+
+// GridSeatMap is a plain rectangular layout: rows labelled A, B, C... with seats numbered from 1
+func GridSeatMap(rows, seatsPerRow int) SeatMap {
+	var m SeatMap
+	for r := 0; r < rows; r++ {
+		row := SeatMapRow{Label: string(rune('A' + r))}
+		for n := 1; n <= seatsPerRow; n++ {
+			row.Cells = append(row.Cells, SeatMapCell{Kind: CellSeat, Number: n})
+		}
+		m.Rows = append(m.Rows, row)
+	}
+	return m
+}
+
+// DefaultSeatMap is the layout used by screenings that were created without a hall
+func DefaultSeatMap() SeatMap {
+	return GridSeatMap(5, 8)
+}
@@ -0,0 +1,34 @@
+package models
+
+import "testing"
+
+func TestSeatMapValidateSeatIDs(t *testing.T) {
+	seats := func(numbers ...int) []SeatMapCell {
+		var cells []SeatMapCell
+		for _, n := range numbers {
+			cells = append(cells, SeatMapCell{Kind: CellSeat, Number: n})
+		}
+		return cells
+	}
+
+	tests := []struct {
+		name    string
+		rows    []SeatMapRow
+		wantErr bool
+	}{
+		{"plain grid", GridSeatMap(3, 12).Rows, false},
+		{"multi-letter labels", []SeatMapRow{{Label: "A", Cells: seats(1, 2)}, {Label: "AA", Cells: seats(1, 2)}}, false},
+		{"row A seat 11 and row A1 seat 1", []SeatMapRow{{Label: "A", Cells: seats(11)}, {Label: "A1", Cells: seats(1)}}, true},
+		{"row A1 seat 1 and row A seat 11", []SeatMapRow{{Label: "A1", Cells: seats(1)}, {Label: "A", Cells: seats(10, 11)}}, true},
+		{"digit labels that do not collide", []SeatMapRow{{Label: "A", Cells: seats(1, 2)}, {Label: "A1", Cells: seats(5)}}, false},
+		{"disabled seats count too", []SeatMapRow{{Label: "A", Cells: []SeatMapCell{{Kind: CellSeat, Number: 11, Disabled: true}, {Kind: CellSeat, Number: 1}}}, {Label: "A1", Cells: seats(1)}}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := SeatMap{Rows: tt.rows}.Validate()
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Validate() = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,52 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type MediaKind string
+
+const (
+	MediaPoster   MediaKind = "POSTER"
+	MediaBackdrop MediaKind = "BACKDROP"
+)
+
+func IsValidMediaKind(k MediaKind) bool {
+	return k == MediaPoster || k == MediaBackdrop
+}
+
+// Media is an uploaded image. The original is kept as uploaded; variants are resized JPEG copies.
+type Media struct {
+	ID          primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	Kind        MediaKind          `bson:"kind" json:"kind"`
+	Filename    string             `bson:"filename,omitempty" json:"filename,omitempty"` // As sent by the uploader
+	ContentType string             `bson:"content_type" json:"content_type"`
+	Size        int64              `bson:"size" json:"size"`
+	Width       int                `bson:"width" json:"width"`
+	Height      int                `bson:"height" json:"height"`
+	Variants    []MediaVariant     `bson:"variants" json:"variants"`
+	UploadedBy  string             `bson:"uploaded_by" json:"uploaded_by"`
+	CreatedAt   time.Time          `bson:"created_at" json:"created_at"`
+}
+
+type MediaVariant struct {
+	Name        string `bson:"name" json:"name"` // original, thumb, medium, large
+	Key         string `bson:"key" json:"-"`     // Storage key
+	ContentType string `bson:"content_type" json:"content_type"`
+	Width       int    `bson:"width" json:"width"`
+	Height      int    `bson:"height" json:"height"`
+	Size        int64  `bson:"size" json:"size"`
+	URL         string `bson:"-" json:"url"`
+}
+
+// Variant finds a variant by name
+func (m Media) Variant(name string) (MediaVariant, bool) {
+	for _, v := range m.Variants {
+		if v.Name == name {
+			return v, true
+		}
+	}
+	return MediaVariant{}, false
+}
@@ -9,35 +9,191 @@ import (
 type Role string
 
 const (
-	RoleUser  Role = "USER"
-	RoleAdmin Role = "ADMIN"
+	RoleUser          Role = "USER"
+	RoleAdmin         Role = "ADMIN"
+	RoleBoxOffice     Role = "BOX_OFFICE"
+	RoleCinemaManager Role = "CINEMA_MANAGER"
+	RoleUsher         Role = "USHER"
+	RoleFinance       Role = "FINANCE"
+	RolePartner       Role = "PARTNER" // Machine account used by API keys (kiosks, corporate portals)
+)
+
+type UserStatus string
+
+const (
+	UserActive    UserStatus = "ACTIVE"
+	UserSuspended UserStatus = "SUSPENDED"
+	UserDeleted   UserStatus = "DELETED" // Anonymized on request, kept so bookings still reference a user
 )
 
 type User struct {
-	ID         primitive.ObjectID `bson:"_id,omitempty" json:"id"`
-	Email      string             `bson:"email" json:"email"`
-	Name       string             `bson:"name" json:"name"`
-	PictureURL string             `bson:"picture_url" json:"picture_url"`
-	Role       Role               `bson:"role" json:"role"`
-	CreatedAt  time.Time          `bson:"created_at" json:"created_at"`
-	UpdatedAt  time.Time          `bson:"updated_at" json:"updated_at"`
+	ID              primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	Email           string             `bson:"email" json:"email"`
+	EmailVerified   bool               `bson:"email_verified" json:"email_verified"`
+	Name            string             `bson:"name" json:"name"`
+	PictureURL      string             `bson:"picture_url" json:"picture_url"`
+	DateOfBirth     string             `bson:"date_of_birth,omitempty" json:"date_of_birth,omitempty"`       // YYYY-MM-DD, set by the user on their profile
+	Role            Role               `bson:"role" json:"role"`                                             // Primary role (applies to all cinemas)
+	RoleAssignments []RoleAssignment   `bson:"role_assignments,omitempty" json:"role_assignments,omitempty"` // Extra roles, optionally per cinema
+	PasswordHash    string             `bson:"password_hash,omitempty" json:"-"`                             // Local accounts only (bcrypt)
+	Identities      []LinkedIdentity   `bson:"identities,omitempty" json:"identities,omitempty"`
+	Status          UserStatus         `bson:"status,omitempty" json:"status,omitempty"` // Empty on old documents = ACTIVE
+	SuspendedReason string             `bson:"suspended_reason,omitempty" json:"suspended_reason,omitempty"`
+	SuspendedAt     *time.Time         `bson:"suspended_at,omitempty" json:"suspended_at,omitempty"`
+	DeletedAt       *time.Time         `bson:"deleted_at,omitempty" json:"deleted_at,omitempty"`
+	CreatedAt       time.Time          `bson:"created_at" json:"created_at"`
+	UpdatedAt       time.Time          `bson:"updated_at" json:"updated_at"`
+}
+
+func (u User) IsSuspended() bool {
+	return u.Status == UserSuspended
+}
+
+func (u User) IsDeleted() bool {
+	return u.Status == UserDeleted
+}
+
+// AgeOn returns the user's age in whole years on the given day; false if no date of birth is set
+func (u User) AgeOn(t time.Time) (int, bool) {
+	dob, err := time.Parse("2006-01-02", u.DateOfBirth)
+	if err != nil {
+		return 0, false
+	}
+	age := t.Year() - dob.Year()
+	if t.Month() < dob.Month() || (t.Month() == dob.Month() && t.Day() < dob.Day()) {
+		age--
+	}
+	return age, true
+}
+
+// LinkedIdentity records which identity provider account maps to the user (e.g. google / 1234)
+type LinkedIdentity struct {
+	Provider string `bson:"provider" json:"provider"`
+	Subject  string `bson:"subject" json:"subject"`
 }
 
 type Movie struct {
-	ID          primitive.ObjectID `bson:"_id,omitempty" json:"id"`
-	Title       string             `bson:"title" json:"title"`
-	Description string             `bson:"description" json:"description"`
-	Genre       string             `bson:"genre" json:"genre"`
-	DurationMin int                `bson:"duration_min" json:"duration_min"`
-	PosterURL   string             `bson:"poster_url" json:"poster_url"`
-	Screenings  []Screening        `bson:"screenings" json:"screenings"`
+	ID               primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	Title            string             `bson:"title" json:"title"`
+	Description      string             `bson:"description" json:"description"`
+	Genres           []string           `bson:"genres" json:"genres"`
+	AgeRating        AgeRating          `bson:"age_rating,omitempty" json:"age_rating,omitempty"`
+	ReleaseDate      string             `bson:"release_date,omitempty" json:"release_date,omitempty"`           // YYYY-MM-DD
+	Language         string             `bson:"language,omitempty" json:"language,omitempty"`                   // Spoken language, ISO 639-1 (en, th)
+	SubtitleLanguage string             `bson:"subtitle_language,omitempty" json:"subtitle_language,omitempty"` // Subtitles on ORIGINAL audio screenings
+	Directors        []string           `bson:"directors,omitempty" json:"directors,omitempty"`
+	Cast             []string           `bson:"cast,omitempty" json:"cast,omitempty"`
+	DurationMin      int                `bson:"duration_min" json:"duration_min"`
+	PosterMediaID    primitive.ObjectID `bson:"poster_media_id,omitempty" json:"poster_media_id,omitempty"`
+	PosterURL        string             `bson:"poster_url" json:"poster_url"` // Medium variant of the poster; older movies may still have an external URL
+	PosterThumbURL   string             `bson:"poster_thumb_url,omitempty" json:"poster_thumb_url,omitempty"`
+	BackdropMediaID  primitive.ObjectID `bson:"backdrop_media_id,omitempty" json:"backdrop_media_id,omitempty"`
+	BackdropURL      string             `bson:"backdrop_url,omitempty" json:"backdrop_url,omitempty"`
+	TrailerURL       string             `bson:"trailer_url,omitempty" json:"trailer_url,omitempty"`
+	Screenings       []Screening        `bson:"-" json:"screenings,omitempty"` // Loaded from the screenings collection, never stored on the movie
+	CreatedAt        time.Time          `bson:"created_at,omitempty" json:"created_at,omitempty"`
+	UpdatedAt        time.Time          `bson:"updated_at,omitempty" json:"updated_at,omitempty"`
+	DeletedAt        *time.Time         `bson:"deleted_at,omitempty" json:"deleted_at,omitempty"` // Soft delete, hidden from the catalogue
 }
 
+// Screening is stored in its own collection. Seat state lives on the screening document so
+// bookings for different screenings (even of the same movie) never touch the same document.
 type Screening struct {
-	ID        string    `bson:"id" json:"id"` // Unique ID generator needed or use UUID
-	StartTime time.Time `bson:"start_time" json:"start_time"`
-	Price     float64   `bson:"price" json:"price"`
-	Seats     []Seat    `bson:"seats" json:"seats,omitempty"`
+	ID           primitive.ObjectID   `bson:"_id,omitempty" json:"id"`
+	MovieID      primitive.ObjectID   `bson:"movie_id" json:"movie_id"`
+	CinemaID     primitive.ObjectID   `bson:"cinema_id,omitempty" json:"cinema_id,omitempty"`
+	HallID       primitive.ObjectID   `bson:"hall_id,omitempty" json:"hall_id,omitempty"`
+	StartTime    time.Time            `bson:"start_time" json:"start_time"`
+	Format       ScreeningFormat      `bson:"format,omitempty" json:"format,omitempty"` // Empty = 2D
+	Audio        AudioVersion         `bson:"audio,omitempty" json:"audio,omitempty"`   // Empty = ORIGINAL
+	Price        float64              `bson:"price" json:"price"`                       // STANDARD price, also used for types without their own price
+	Prices       map[SeatType]float64 `bson:"prices,omitempty" json:"prices,omitempty"` // Per seat type overrides
+	Seats        []Seat               `bson:"seats,omitempty" json:"seats,omitempty"`
+	Layout       *SeatMap             `bson:"layout,omitempty" json:"layout,omitempty"`           // Copy of the hall's seat map when the screening was created
+	LegacyID     string               `bson:"legacy_id,omitempty" json:"-"`                       // Old embedded ID ("s1".."s20"), kept for migrated data
+	TemplateID   primitive.ObjectID   `bson:"template_id,omitempty" json:"template_id,omitempty"` // Schedule template that generated it
+	SeatsTotal   int                  `bson:"seats_total,omitempty" json:"-"`                     // Counters kept in step with seats so listings never scan them
+	SeatsBooked  int                  `bson:"seats_booked,omitempty" json:"-"`                    // Incremented when a seat is booked
+	Availability *SeatAvailability    `bson:"-" json:"availability,omitempty"`                    // Filled in for listings
+	CreatedAt    time.Time            `bson:"created_at,omitempty" json:"created_at,omitempty"`
+	UpdatedAt    time.Time            `bson:"updated_at,omitempty" json:"updated_at,omitempty"`
+	DeletedAt    *time.Time           `bson:"deleted_at,omitempty" json:"deleted_at,omitempty"`     // Set when the movie or screening is deleted
+	CancelledAt  *time.Time           `bson:"cancelled_at,omitempty" json:"cancelled_at,omitempty"` // Cancelled by the cinema; also soft deleted
+	CancelReason string               `bson:"cancel_reason,omitempty" json:"cancel_reason,omitempty"`
+}
+
+// SamePrices compares two per seat type price maps
+func SamePrices(a, b map[SeatType]float64) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for seatType, price := range a {
+		if other, ok := b[seatType]; !ok || other != price {
+			return false
+		}
+	}
+	return true
+}
+
+// PriceFor returns the price of a seat type at this screening
+func (s Screening) PriceFor(seatType SeatType) float64 {
+	if price, ok := s.Prices[seatType]; ok {
+		return price
+	}
+	return s.Price
+}
+
+// SoldSeats counts seats already booked for this screening
+func (s Screening) SoldSeats() int {
+	sold := 0
+	for _, seat := range s.Seats {
+		if seat.Status == SeatBooked {
+			sold++
+		}
+	}
+	return sold
+}
+
+type AvailabilityStatus string
+
+const (
+	AvailabilityOpen        AvailabilityStatus = "AVAILABLE"
+	AvailabilitySellingFast AvailabilityStatus = "SELLING_FAST"
+	AvailabilitySoldOut     AvailabilityStatus = "SOLD_OUT"
+	AvailabilityUnknown     AvailabilityStatus = "UNKNOWN" // No seat counters yet (screening not migrated)
+)
+
+// sellingFastShare is the share of free seats at or below which a screening is selling fast
+const sellingFastShare = 0.2
+
+// SeatAvailability summarises a screening's seats for listings
+type SeatAvailability struct {
+	Total     int                `json:"total"`
+	Available int                `json:"available"` // Neither booked nor locked by someone paying
+	Locked    int                `json:"locked"`
+	Booked    int                `json:"booked"`
+	Status    AvailabilityStatus `json:"status"`
+}
+
+// NewSeatAvailability derives the status from the counters. A zero total means the seats were
+// never counted, not that the hall is full, so it is reported as UNKNOWN.
+func NewSeatAvailability(total, booked, locked int) SeatAvailability {
+	a := SeatAvailability{Total: total, Booked: booked, Locked: locked, Available: total - booked - locked}
+	if a.Available < 0 {
+		a.Available = 0
+	}
+	switch {
+	case total <= 0:
+		a.Available = 0
+		a.Status = AvailabilityUnknown
+	case booked >= total:
+		a.Status = AvailabilitySoldOut
+	case float64(a.Available) <= float64(total)*sellingFastShare:
+		a.Status = AvailabilitySellingFast
+	default:
+		a.Status = AvailabilityOpen
+	}
+	return a
 }
 
 type SeatStatus string
@@ -51,18 +207,36 @@ type Seat struct {
 	ID       string     `bson:"id" json:"id"`
 	Row      string     `bson:"row" json:"row"`
 	Number   int        `bson:"number" json:"number"`
+	Type     SeatType   `bson:"type,omitempty" json:"type,omitempty"` // Empty on old screenings = STANDARD
 	Status   SeatStatus `bson:"status" json:"status"`
 	LockedBy string     `bson:"locked_by,omitempty" json:"locked_by,omitempty"`
 }
 
+// DefaultSeats is the standard hall layout: rows A-E, seats 1-8
+func DefaultSeats() []Seat {
+	return DefaultSeatMap().Seats()
+}
+
 type Booking struct {
-	ID              primitive.ObjectID `bson:"_id,omitempty" json:"id"`
-	UserID          string             `bson:"user_id" json:"user_id"`
-	ScreeningID     string             `bson:"screening_id" json:"screening_id"`
-	ScreenStartTime string             `bson:"screen_start_time" json:"screen_start_time"`
-	SeatID          string             `bson:"seat_id" json:"seat_id"`
-	Status          string             `bson:"status" json:"status"`
-	PaymentID       string             `bson:"payment_id" json:"payment_id"` // [NEW] Payment Reference
-	Amount          float64            `bson:"amount" json:"amount"`
-	CreatedAt       time.Time          `bson:"created_at" json:"created_at"`
+	ID              primitive.ObjectID   `bson:"_id,omitempty" json:"id"`
+	UserID          string               `bson:"user_id" json:"user_id"`
+	ScreeningID     string               `bson:"screening_id" json:"screening_id"`
+	ScreenStartTime string               `bson:"screen_start_time" json:"screen_start_time"`
+	SeatID          string               `bson:"seat_id" json:"seat_id"`
+	SeatType        SeatType             `bson:"seat_type,omitempty" json:"seat_type,omitempty"`
+	BasePrice       float64              `bson:"base_price,omitempty" json:"base_price,omitempty"`       // Seat type price before pricing rules
+	AppliedRules    []AppliedPricingRule `bson:"applied_rules,omitempty" json:"applied_rules,omitempty"` // Rules that changed Amount
+	Status          string               `bson:"status" json:"status"`
+	PaymentID       string               `bson:"payment_id" json:"payment_id"`                                   // [NEW] Payment Reference
+	APIKeyID        string               `bson:"api_key_id,omitempty" json:"api_key_id,omitempty"`               // Set when booked by a partner integration
+	RequiresIDCheck bool                 `bson:"requires_id_check,omitempty" json:"requires_id_check,omitempty"` // Age-restricted movie: ushers check ID at entry
+	Amount          float64              `bson:"amount" json:"amount"`
+	RefundID        string               `bson:"refund_id,omitempty" json:"refund_id,omitempty"` // Payment gateway reference of the refund
+	RefundedAt      *time.Time           `bson:"refunded_at,omitempty" json:"refunded_at,omitempty"`
+	CreatedAt       time.Time            `bson:"created_at" json:"created_at"`
 }
+
+const (
+	BookingStatusSuccess           = "SUCCESS"
+	BookingStatusCancelledByCinema = "CANCELLED_BY_CINEMA" // Screening cancelled and the ticket refunded
+)
@@ -0,0 +1,62 @@
+package models
+
+import "testing"
+
+func TestNewSeatAvailability(t *testing.T) {
+	tests := []struct {
+		name          string
+		total         int
+		booked        int
+		locked        int
+		wantAvailable int
+		wantStatus    AvailabilityStatus
+	}{
+		{"empty hall", 100, 0, 0, 100, AvailabilityOpen},
+		{"just above the selling fast share", 100, 70, 9, 21, AvailabilityOpen},
+		{"at the selling fast share", 100, 70, 10, 20, AvailabilitySellingFast},
+		{"locks count towards selling fast", 100, 0, 90, 10, AvailabilitySellingFast},
+		{"all locked is not sold out", 100, 0, 100, 0, AvailabilitySellingFast},
+		{"sold out", 100, 100, 0, 0, AvailabilitySoldOut},
+		{"counters out of step", 100, 95, 10, 0, AvailabilitySellingFast},
+		{"no counters yet", 0, 0, 0, 0, AvailabilityUnknown},
+		{"no counters with locks", 0, 0, 3, 0, AvailabilityUnknown},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a := NewSeatAvailability(tt.total, tt.booked, tt.locked)
+			if a.Available != tt.wantAvailable || a.Status != tt.wantStatus {
+				t.Errorf("NewSeatAvailability(%d, %d, %d) = %d %s, want %d %s",
+					tt.total, tt.booked, tt.locked, a.Available, a.Status, tt.wantAvailable, tt.wantStatus)
+			}
+		})
+	}
+}
+
+func TestMovieDetailsValidate(t *testing.T) {
+	valid := MovieDetails{Title: "Dune", DurationMin: 155, Language: "en", TrailerURL: "https://example.com/t"}
+	tests := []struct {
+		name    string
+		edit    func(d *MovieDetails)
+		wantErr bool
+	}{
+		{"valid", func(d *MovieDetails) {}, false},
+		{"optional fields blank", func(d *MovieDetails) { d.Language, d.TrailerURL = "", "" }, false},
+		{"no title", func(d *MovieDetails) { d.Title = "" }, true},
+		{"no duration", func(d *MovieDetails) { d.DurationMin = 0 }, true},
+		{"language name instead of code", func(d *MovieDetails) { d.Language = "english" }, true},
+		{"upper-case language", func(d *MovieDetails) { d.SubtitleLanguage = "TH" }, true},
+		{"trailer without scheme", func(d *MovieDetails) { d.TrailerURL = "example.com/t" }, true},
+		{"javascript trailer", func(d *MovieDetails) { d.TrailerURL = "javascript:alert(1)" }, true},
+		{"unknown age rating", func(d *MovieDetails) { d.AgeRating = "PG-15" }, true},
+		{"bad release date", func(d *MovieDetails) { d.ReleaseDate = "2024-13-01" }, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d := valid
+			tt.edit(&d)
+			if err := d.Validate(); (err != nil) != tt.wantErr {
+				t.Errorf("Validate() = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
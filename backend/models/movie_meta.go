@@ -0,0 +1,99 @@
+package models
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+)
+
+type AgeRating string
+
+const (
+	// MPA ratings
+	RatingG    AgeRating = "G"
+	RatingPG   AgeRating = "PG"
+	RatingPG13 AgeRating = "PG-13"
+	RatingR    AgeRating = "R"
+	RatingNC17 AgeRating = "NC-17"
+	Rating18   AgeRating = "18+"
+
+	// Thai film ratings
+	RatingTHPromote AgeRating = "TH-P"  // ส - promoted for education
+	RatingTHGeneral AgeRating = "TH-G"  // ท - general audiences
+	RatingTH13      AgeRating = "TH-13" // น 13+
+	RatingTH15      AgeRating = "TH-15" // น 15+
+	RatingTH18      AgeRating = "TH-18" // น 18+
+	RatingTH20      AgeRating = "TH-20" // ฉ 20- - nobody under 20 admitted
+)
+
+var validAgeRatings = map[AgeRating]bool{
+	RatingG: true, RatingPG: true, RatingPG13: true, RatingR: true, RatingNC17: true, Rating18: true,
+	RatingTHPromote: true, RatingTHGeneral: true, RatingTH13: true, RatingTH15: true, RatingTH18: true, RatingTH20: true,
+}
+
+func IsValidAgeRating(r AgeRating) bool {
+	return validAgeRatings[r]
+}
+
+// MinAge is the age below which tickets are not sold. Advisory ratings (PG-13, R, TH-13 to TH-18) return 0.
+func (r AgeRating) MinAge() int {
+	switch r {
+	case RatingNC17, Rating18:
+		return 18
+	case RatingTH20:
+		return 20
+	}
+	return 0
+}
+
+type AudioVersion string
+
+const (
+	AudioOriginal AudioVersion = "ORIGINAL" // Movie's own language, with subtitles
+	AudioDubbed   AudioVersion = "DUBBED"   // Dubbed into Thai
+)
+
+func IsValidAudio(a AudioVersion) bool {
+	return a == AudioOriginal || a == AudioDubbed
+}
+
+var languageCode = regexp.MustCompile(`^[a-z]{2}$`)
+
+// MovieDetails are the movie fields checked the same way by every writer: the admin API and
+// catalog imports. Languages are expected lower-cased already.
+type MovieDetails struct {
+	Title            string
+	DurationMin      int
+	AgeRating        AgeRating
+	ReleaseDate      string
+	Language         string
+	SubtitleLanguage string
+	TrailerURL       string
+}
+
+func (d MovieDetails) Validate() error {
+	if d.Title == "" {
+		return fmt.Errorf("title is required")
+	}
+	if d.DurationMin <= 0 {
+		return fmt.Errorf("duration_min must be positive")
+	}
+	if d.AgeRating != "" && !IsValidAgeRating(d.AgeRating) {
+		return fmt.Errorf("unknown age_rating %s", d.AgeRating)
+	}
+	if d.ReleaseDate != "" {
+		if _, err := time.Parse("2006-01-02", d.ReleaseDate); err != nil {
+			return fmt.Errorf("invalid release_date, expected YYYY-MM-DD")
+		}
+	}
+	for _, lang := range []string{d.Language, d.SubtitleLanguage} {
+		if lang != "" && !languageCode.MatchString(lang) {
+			return fmt.Errorf("invalid language %s, expected an ISO 639-1 code such as en or th", lang)
+		}
+	}
+	if d.TrailerURL != "" && !strings.HasPrefix(d.TrailerURL, "https://") && !strings.HasPrefix(d.TrailerURL, "http://") {
+		return fmt.Errorf("trailer_url must be an http(s) URL")
+	}
+	return nil
+}
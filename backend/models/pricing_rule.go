@@ -0,0 +1,122 @@
+package models
+
+import (
+	"fmt"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type ScreeningFormat string
+
+const (
+	Format2D   ScreeningFormat = "2D"
+	Format3D   ScreeningFormat = "3D"
+	FormatIMAX ScreeningFormat = "IMAX"
+	Format4DX  ScreeningFormat = "4DX"
+)
+
+var validFormats = map[ScreeningFormat]bool{Format2D: true, Format3D: true, FormatIMAX: true, Format4DX: true}
+
+func IsValidFormat(f ScreeningFormat) bool {
+	return validFormats[f]
+}
+
+type AdjustmentType string
+
+const (
+	AdjustPercent AdjustmentType = "PERCENT" // Value is a percentage of the current price, e.g. -20 or 15
+	AdjustAmount  AdjustmentType = "AMOUNT"  // Value is added to the current price, e.g. -40 or 50
+	AdjustFixed   AdjustmentType = "FIXED"   // Value replaces the current price
+)
+
+// PricingRule adjusts seat prices when all of its conditions match. Rules are applied in
+// ascending Priority (then ID), each one to the price left by the previous rules.
+type PricingRule struct {
+	ID          primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	Name        string             `bson:"name" json:"name"`
+	Priority    int                `bson:"priority" json:"priority"`
+	Active      bool               `bson:"active" json:"active"`
+	Conditions  PricingConditions  `bson:"conditions" json:"conditions"`
+	Adjustment  PriceAdjustment    `bson:"adjustment" json:"adjustment"`
+	StopOnMatch bool               `bson:"stop_on_match,omitempty" json:"stop_on_match,omitempty"` // Skip the remaining rules when this one applies
+	CreatedAt   time.Time          `bson:"created_at" json:"created_at"`
+	UpdatedAt   time.Time          `bson:"updated_at" json:"updated_at"`
+}
+
+// PricingConditions are ANDed; an empty condition matches everything.
+// Days and times are in the cinema's timezone.
+type PricingConditions struct {
+	Weekdays     []time.Weekday    `bson:"weekdays,omitempty" json:"weekdays,omitempty"`           // 0 = Sunday
+	Dates        []string          `bson:"dates,omitempty" json:"dates,omitempty"`                 // YYYY-MM-DD, e.g. public holidays
+	StartFrom    string            `bson:"start_from,omitempty" json:"start_from,omitempty"`       // HH:MM, inclusive
+	StartBefore  string            `bson:"start_before,omitempty" json:"start_before,omitempty"`   // HH:MM, exclusive
+	Formats      []ScreeningFormat `bson:"formats,omitempty" json:"formats,omitempty"`             // Screening format
+	SeatTypes    []SeatType        `bson:"seat_types,omitempty" json:"seat_types,omitempty"`       // Only these seats
+	MinOccupancy float64           `bson:"min_occupancy,omitempty" json:"min_occupancy,omitempty"` // 0-1, share of seats already sold
+}
+
+type PriceAdjustment struct {
+	Type  AdjustmentType `bson:"type" json:"type"`
+	Value float64        `bson:"value" json:"value"`
+}
+
+// AppliedPricingRule records what a rule did to a seat price (stored on bookings for auditing)
+type AppliedPricingRule struct {
+	RuleID string  `bson:"rule_id" json:"rule_id"`
+	Name   string  `bson:"name" json:"name"`
+	Delta  float64 `bson:"delta" json:"delta"` // Price change caused by the rule
+}
+
+func (r PricingRule) Validate() error {
+	if r.Name == "" {
+		return fmt.Errorf("name is required")
+	}
+	switch r.Adjustment.Type {
+	case AdjustPercent:
+		if r.Adjustment.Value <= -100 {
+			return fmt.Errorf("percent adjustment must be above -100")
+		}
+	case AdjustAmount:
+	case AdjustFixed:
+		if r.Adjustment.Value <= 0 {
+			return fmt.Errorf("fixed price must be positive")
+		}
+	default:
+		return fmt.Errorf("adjustment type must be PERCENT, AMOUNT or FIXED")
+	}
+
+	c := r.Conditions
+	for _, d := range c.Weekdays {
+		if d < time.Sunday || d > time.Saturday {
+			return fmt.Errorf("weekdays must be 0 (Sunday) to 6 (Saturday)")
+		}
+	}
+	for _, d := range c.Dates {
+		if _, err := time.Parse("2006-01-02", d); err != nil {
+			return fmt.Errorf("invalid date %s, expected YYYY-MM-DD", d)
+		}
+	}
+	for _, t := range []string{c.StartFrom, c.StartBefore} {
+		if t == "" {
+			continue
+		}
+		if _, err := time.Parse("15:04", t); err != nil {
+			return fmt.Errorf("invalid time %s, expected HH:MM", t)
+		}
+	}
+	for _, f := range c.Formats {
+		if !IsValidFormat(f) {
+			return fmt.Errorf("unknown format %s", f)
+		}
+	}
+	for _, t := range c.SeatTypes {
+		if !IsValidSeatType(t) {
+			return fmt.Errorf("unknown seat type %s", t)
+		}
+	}
+	if c.MinOccupancy < 0 || c.MinOccupancy > 1 {
+		return fmt.Errorf("min_occupancy must be between 0 and 1")
+	}
+	return nil
+}
@@ -0,0 +1,122 @@
+package models
+
+// Permission is a single action a role may perform, written as "<resource>:<action>"
+type Permission string
+
+const (
+	PermAdminAccess     Permission = "admin:access" // Can open the back office at all
+	PermSeatsLock       Permission = "seats:lock"
+	PermBookingsCreate  Permission = "bookings:create"
+	PermBookingsRead    Permission = "bookings:read"
+	PermBookingsRefund  Permission = "bookings:refund"
+	PermMoviesWrite     Permission = "movies:write"
+	PermScreeningsWrite Permission = "screenings:write"
+	PermTicketsCheckin  Permission = "tickets:checkin"
+	PermReportsFinance  Permission = "reports:finance"
+	PermUsersRead       Permission = "users:read"
+	PermUsersWrite      Permission = "users:write"
+	PermAuditRead       Permission = "audit:read"
+	PermAPIKeysManage   Permission = "api_keys:manage"
+	PermCinemasWrite    Permission = "cinemas:write" // Cinemas, halls and seat maps
+	PermPricingWrite    Permission = "pricing:write" // Dynamic pricing rules
+	PermAgeAttested     Permission = "age:attested"  // Partner verifies buyers' age itself; as an API key scope it replaces the date-of-birth check
+)
+
+// AllPermissions is granted to ADMIN
+var AllPermissions = []Permission{
+	PermAdminAccess, PermSeatsLock, PermBookingsCreate, PermBookingsRead, PermBookingsRefund,
+	PermMoviesWrite, PermScreeningsWrite, PermTicketsCheckin, PermReportsFinance,
+	PermUsersRead, PermUsersWrite, PermAuditRead, PermAPIKeysManage, PermCinemasWrite, PermPricingWrite,
+}
+
+// RolePermissions maps every role to its permission set
+var RolePermissions = map[Role][]Permission{
+	RoleUser:          {PermSeatsLock, PermBookingsCreate},
+	RoleAdmin:         AllPermissions,
+	RoleBoxOffice:     {PermAdminAccess, PermSeatsLock, PermBookingsCreate, PermBookingsRead, PermTicketsCheckin},
+	RoleCinemaManager: {PermAdminAccess, PermBookingsRead, PermBookingsRefund, PermScreeningsWrite, PermTicketsCheckin, PermReportsFinance, PermAuditRead},
+	RoleUsher:         {PermAdminAccess, PermTicketsCheckin},
+	RoleFinance:       {PermAdminAccess, PermBookingsRead, PermBookingsRefund, PermReportsFinance},
+	RolePartner:       {PermSeatsLock, PermBookingsCreate, PermAgeAttested},
+}
+
+func IsValidRole(role Role) bool {
+	_, ok := RolePermissions[role]
+	return ok
+}
+
+// RoleAssignment grants a role to a user, optionally limited to one cinema (empty CinemaID = all cinemas)
+type RoleAssignment struct {
+	Role     Role   `bson:"role" json:"role"`
+	CinemaID string `bson:"cinema_id,omitempty" json:"cinema_id,omitempty"`
+}
+
+// assignments returns the primary role (global) plus the extra assignments
+func (u User) assignments() []RoleAssignment {
+	all := make([]RoleAssignment, 0, len(u.RoleAssignments)+1)
+	if u.Role != "" {
+		all = append(all, RoleAssignment{Role: u.Role})
+	}
+	return append(all, u.RoleAssignments...)
+}
+
+// HasPermission checks the permission for a cinema. Pass "" to require it across all cinemas.
+func (u User) HasPermission(perm Permission, cinemaID string) bool {
+	for _, a := range u.assignments() {
+		if a.CinemaID != "" && a.CinemaID != cinemaID {
+			continue
+		}
+		if roleHasPermission(a.Role, perm) {
+			return true
+		}
+	}
+	return false
+}
+
+// HasPermissionAnywhere is true if the user holds the permission globally or for at least one cinema
+func (u User) HasPermissionAnywhere(perm Permission) bool {
+	for _, a := range u.assignments() {
+		if roleHasPermission(a.Role, perm) {
+			return true
+		}
+	}
+	return false
+}
+
+// PermissionCinemaIDs returns the cinemas the permission is limited to; global is true when it is not limited
+func (u User) PermissionCinemaIDs(perm Permission) (cinemaIDs []string, global bool) {
+	for _, a := range u.assignments() {
+		if !roleHasPermission(a.Role, perm) {
+			continue
+		}
+		if a.CinemaID == "" {
+			return nil, true
+		}
+		cinemaIDs = append(cinemaIDs, a.CinemaID)
+	}
+	return cinemaIDs, false
+}
+
+// Permissions lists the distinct permissions the user holds in any scope
+func (u User) Permissions() []Permission {
+	seen := make(map[Permission]bool)
+	var perms []Permission
+	for _, a := range u.assignments() {
+		for _, p := range RolePermissions[a.Role] {
+			if !seen[p] {
+				seen[p] = true
+				perms = append(perms, p)
+			}
+		}
+	}
+	return perms
+}
+
+func roleHasPermission(role Role, perm Permission) bool {
+	for _, p := range RolePermissions[role] {
+		if p == perm {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,54 @@
+package models
+
+import "testing"
+
+func TestHasPermissionScoping(t *testing.T) {
+	const cinemaA, cinemaB = "64b000000000000000000001", "64b000000000000000000002"
+	manager := User{Role: RoleUser, RoleAssignments: []RoleAssignment{{Role: RoleCinemaManager, CinemaID: cinemaA}}}
+	admin := User{Role: RoleAdmin}
+
+	tests := []struct {
+		name     string
+		user     User
+		perm     Permission
+		cinemaID string
+		want     bool
+	}{
+		{"scoped role in its cinema", manager, PermScreeningsWrite, cinemaA, true},
+		{"scoped role in another cinema", manager, PermScreeningsWrite, cinemaB, false},
+		{"scoped role does not count globally", manager, PermScreeningsWrite, "", false},
+		{"primary role applies everywhere", manager, PermSeatsLock, cinemaB, true},
+		{"permission the role lacks", manager, PermMoviesWrite, cinemaA, false},
+		{"global admin in any cinema", admin, PermBookingsRefund, cinemaB, true},
+		{"global admin globally", admin, PermBookingsRefund, "", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.user.HasPermission(tt.perm, tt.cinemaID); got != tt.want {
+				t.Errorf("HasPermission(%s, %q) = %v, want %v", tt.perm, tt.cinemaID, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPermissionCinemaIDs(t *testing.T) {
+	manager := User{Role: RoleUser, RoleAssignments: []RoleAssignment{
+		{Role: RoleCinemaManager, CinemaID: "a"},
+		{Role: RoleFinance, CinemaID: "b"},
+	}}
+
+	ids, global := manager.PermissionCinemaIDs(PermBookingsRead)
+	if global || len(ids) != 2 || ids[0] != "a" || ids[1] != "b" {
+		t.Errorf("bookings:read = %v, global %v; want [a b], false", ids, global)
+	}
+	ids, global = manager.PermissionCinemaIDs(PermScreeningsWrite)
+	if global || len(ids) != 1 || ids[0] != "a" {
+		t.Errorf("screenings:write = %v, global %v; want [a], false", ids, global)
+	}
+	if _, global := (User{Role: RoleAdmin}).PermissionCinemaIDs(PermScreeningsWrite); !global {
+		t.Error("admin should hold screenings:write globally")
+	}
+	if !manager.HasPermissionAnywhere(PermScreeningsWrite) || manager.HasPermissionAnywhere(PermMoviesWrite) {
+		t.Error("HasPermissionAnywhere does not match the assignments")
+	}
+}
@@ -0,0 +1,129 @@
+package models
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// ScheduleTemplate generates screenings of a movie in a hall: every day from StartDate to EndDate
+// at each of Times, minus the excluded weekdays and dates. Dates and times are in the cinema's timezone.
+type ScheduleTemplate struct {
+	ID             primitive.ObjectID   `bson:"_id,omitempty" json:"id"`
+	Name           string               `bson:"name,omitempty" json:"name,omitempty"`
+	MovieID        primitive.ObjectID   `bson:"movie_id" json:"movie_id"`
+	HallID         primitive.ObjectID   `bson:"hall_id" json:"hall_id"`
+	Times          []string             `bson:"times" json:"times"`                                         // HH:MM
+	StartDate      string               `bson:"start_date" json:"start_date"`                               // YYYY-MM-DD, inclusive
+	EndDate        string               `bson:"end_date" json:"end_date"`                                   // YYYY-MM-DD, inclusive
+	ExceptWeekdays []time.Weekday       `bson:"except_weekdays,omitempty" json:"except_weekdays,omitempty"` // 0 = Sunday
+	ExceptDates    []string             `bson:"except_dates,omitempty" json:"except_dates,omitempty"`       // YYYY-MM-DD
+	Format         ScreeningFormat      `bson:"format,omitempty" json:"format,omitempty"`
+	Audio          AudioVersion         `bson:"audio,omitempty" json:"audio,omitempty"`
+	Price          float64              `bson:"price" json:"price"`
+	Prices         map[SeatType]float64 `bson:"prices,omitempty" json:"prices,omitempty"`
+	CreatedAt      time.Time            `bson:"created_at" json:"created_at"`
+	UpdatedAt      time.Time            `bson:"updated_at" json:"updated_at"`
+}
+
+// maxTemplateDays keeps a single template from generating an unbounded number of screenings
+const maxTemplateDays = 120
+
+func (t ScheduleTemplate) Validate() error {
+	if t.MovieID.IsZero() {
+		return fmt.Errorf("movie_id is required")
+	}
+	if t.HallID.IsZero() {
+		return fmt.Errorf("hall_id is required")
+	}
+	if len(t.Times) == 0 {
+		return fmt.Errorf("times needs at least one HH:MM")
+	}
+	seen := make(map[string]bool)
+	for _, clock := range t.Times {
+		if _, err := time.Parse("15:04", clock); err != nil {
+			return fmt.Errorf("invalid time %s, expected HH:MM", clock)
+		}
+		if seen[clock] {
+			return fmt.Errorf("duplicate time %s", clock)
+		}
+		seen[clock] = true
+	}
+	start, err := time.Parse("2006-01-02", t.StartDate)
+	if err != nil {
+		return fmt.Errorf("invalid start_date, expected YYYY-MM-DD")
+	}
+	end, err := time.Parse("2006-01-02", t.EndDate)
+	if err != nil {
+		return fmt.Errorf("invalid end_date, expected YYYY-MM-DD")
+	}
+	if end.Before(start) {
+		return fmt.Errorf("end_date is before start_date")
+	}
+	if end.Sub(start) > maxTemplateDays*24*time.Hour {
+		return fmt.Errorf("a template can span at most %d days", maxTemplateDays)
+	}
+	for _, d := range t.ExceptWeekdays {
+		if d < time.Sunday || d > time.Saturday {
+			return fmt.Errorf("except_weekdays must be 0 (Sunday) to 6 (Saturday)")
+		}
+	}
+	for _, d := range t.ExceptDates {
+		if _, err := time.Parse("2006-01-02", d); err != nil {
+			return fmt.Errorf("invalid date %s, expected YYYY-MM-DD", d)
+		}
+	}
+	if t.Format != "" && !IsValidFormat(t.Format) {
+		return fmt.Errorf("unknown format %s", t.Format)
+	}
+	if t.Audio != "" && !IsValidAudio(t.Audio) {
+		return fmt.Errorf("unknown audio %s", t.Audio)
+	}
+	if t.Price <= 0 {
+		return fmt.Errorf("price must be positive")
+	}
+	for seatType, price := range t.Prices {
+		if !IsValidSeatType(seatType) {
+			return fmt.Errorf("unknown seat type %s", seatType)
+		}
+		if price <= 0 {
+			return fmt.Errorf("price for %s must be positive", seatType)
+		}
+	}
+	return nil
+}
+
+// Occurrences lists the start times the template produces, in order
+func (t ScheduleTemplate) Occurrences(loc *time.Location) []time.Time {
+	start, err1 := time.ParseInLocation("2006-01-02", t.StartDate, loc)
+	end, err2 := time.ParseInLocation("2006-01-02", t.EndDate, loc)
+	if err1 != nil || err2 != nil {
+		return nil
+	}
+	skipDay := make(map[time.Weekday]bool)
+	for _, d := range t.ExceptWeekdays {
+		skipDay[d] = true
+	}
+	skipDate := make(map[string]bool)
+	for _, d := range t.ExceptDates {
+		skipDate[d] = true
+	}
+
+	var starts []time.Time
+	for day := start; !day.After(end); day = day.AddDate(0, 0, 1) {
+		if skipDay[day.Weekday()] || skipDate[day.Format("2006-01-02")] {
+			continue
+		}
+		for _, clock := range t.Times {
+			hm, err := time.Parse("15:04", clock)
+			if err != nil {
+				continue
+			}
+			starts = append(starts, time.Date(day.Year(), day.Month(), day.Day(), hm.Hour(), hm.Minute(), 0, 0, loc))
+		}
+	}
+	sort.Slice(starts, func(i, j int) bool { return starts[i].Before(starts[j]) })
+	return starts
+}
@@ -0,0 +1,61 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"movie-ticket-backend/database"
+	"movie-ticket-backend/models"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+var (
+	ErrAgeRestricted       = errors.New("you are below the minimum age for this screening")
+	ErrDateOfBirthRequired = errors.New("add your date of birth to your profile to book age-restricted screenings")
+)
+
+// movieAgeRating loads only the rating of the screening's movie
+func movieAgeRating(ctx context.Context, screening *models.Screening) (models.AgeRating, error) {
+	var movie models.Movie
+	opts := options.FindOne().SetProjection(bson.M{"age_rating": 1})
+	if err := database.Mongo.Collection("movies").FindOne(ctx, bson.M{"_id": screening.MovieID}, opts).Decode(&movie); err != nil {
+		return "", err
+	}
+	return movie.AgeRating, nil
+}
+
+// CheckAgeRestriction returns ErrDateOfBirthRequired or ErrAgeRestricted when the user may not buy
+// tickets for the screening. Age is counted on the day of the screening in the cinema's timezone.
+func CheckAgeRestriction(ctx context.Context, user *models.User, screening *models.Screening) (models.AgeRating, error) {
+	rating, err := movieAgeRating(ctx, screening)
+	if err != nil {
+		return "", err
+	}
+	minAge := rating.MinAge()
+	if minAge == 0 {
+		return rating, nil
+	}
+
+	loc := time.Local
+	if !screening.CinemaID.IsZero() {
+		if cinema, err := NewCinemaService().GetCinema(ctx, screening.CinemaID.Hex()); err == nil {
+			loc = cinema.Location()
+		}
+	}
+	age, ok := user.AgeOn(screening.StartTime.In(loc))
+	if !ok {
+		return rating, ErrDateOfBirthRequired
+	}
+	if age < minAge {
+		return rating, ErrAgeRestricted
+	}
+	return rating, nil
+}
+
+// RequiresIDCheck reports whether tickets for the screening must be checked against ID at entry
+func RequiresIDCheck(ctx context.Context, screening *models.Screening) bool {
+	rating, err := movieAgeRating(ctx, screening)
+	return err == nil && rating.MinAge() > 0
+}
@@ -0,0 +1,158 @@
+package services
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"log"
+	"movie-ticket-backend/database"
+	"movie-ticket-backend/models"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const (
+	apiKeyPrefix = "mtk_"
+	// lastUsedInterval throttles last_used_at writes so busy kiosks do not write on every call
+	lastUsedInterval = time.Minute
+)
+
+var (
+	ErrInvalidAPIKey  = errors.New("api key is invalid, revoked or expired")
+	ErrAPIKeyNotFound = errors.New("api key not found")
+	ErrNotPartner     = errors.New("api keys can only be issued for PARTNER accounts")
+	ErrInvalidScope   = errors.New("scope is not granted to the partner account")
+)
+
+type APIKeyService struct{}
+
+func NewAPIKeyService() *APIKeyService {
+	return &APIKeyService{}
+}
+
+func hashAPIKey(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:])
+}
+
+// CreateKey issues a key for a partner account and returns the plaintext (only available now)
+func (s *APIKeyService) CreateKey(ctx context.Context, name string, partner *models.User, scopes []models.Permission, expiresAt *time.Time, createdBy string) (*models.APIKey, string, error) {
+	if partner.Role != models.RolePartner {
+		return nil, "", ErrNotPartner
+	}
+	for _, scope := range scopes {
+		if !partner.HasPermission(scope, "") {
+			return nil, "", ErrInvalidScope
+		}
+	}
+
+	secret, err := randomToken(32)
+	if err != nil {
+		return nil, "", err
+	}
+	plaintext := apiKeyPrefix + secret
+
+	key := models.APIKey{
+		ID:        primitive.NewObjectID(),
+		Name:      name,
+		Prefix:    plaintext[:len(apiKeyPrefix)+6],
+		KeyHash:   hashAPIKey(plaintext),
+		UserID:    partner.ID.Hex(),
+		Scopes:    scopes,
+		ExpiresAt: expiresAt,
+		CreatedBy: createdBy,
+		CreatedAt: time.Now(),
+	}
+	if _, err := database.Mongo.Collection("api_keys").InsertOne(ctx, key); err != nil {
+		return nil, "", err
+	}
+	return &key, plaintext, nil
+}
+
+// Authenticate resolves a plaintext key to the key record and its (active) partner account
+func (s *APIKeyService) Authenticate(ctx context.Context, plaintext, clientIP string) (*models.APIKey, *models.User, error) {
+	var key models.APIKey
+	err := database.Mongo.Collection("api_keys").FindOne(ctx, bson.M{"key_hash": hashAPIKey(plaintext)}).Decode(&key)
+	if err == mongo.ErrNoDocuments {
+		return nil, nil, ErrInvalidAPIKey
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+
+	now := time.Now()
+	if !key.IsActive(now) {
+		return nil, nil, ErrInvalidAPIKey
+	}
+
+	user, err := GetCachedUser(ctx, key.UserID)
+	if err == ErrUserNotFound {
+		return nil, nil, ErrInvalidAPIKey
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+	if user.IsSuspended() {
+		return nil, nil, ErrAccountSuspended
+	}
+
+	if key.LastUsedAt == nil || now.Sub(*key.LastUsedAt) > lastUsedInterval {
+		go s.touchLastUsed(key.ID, clientIP, now)
+	}
+	return &key, user, nil
+}
+
+func (s *APIKeyService) touchLastUsed(keyID primitive.ObjectID, clientIP string, now time.Time) {
+	// The filter makes concurrent requests write at most once per interval
+	filter := bson.M{"_id": keyID, "$or": bson.A{
+		bson.M{"last_used_at": bson.M{"$exists": false}},
+		bson.M{"last_used_at": bson.M{"$lt": now.Add(-lastUsedInterval)}},
+	}}
+	update := bson.M{"$set": bson.M{"last_used_at": now, "last_used_ip": clientIP}}
+	if _, err := database.Mongo.Collection("api_keys").UpdateOne(context.Background(), filter, update); err != nil {
+		log.Printf("Failed to update api key last_used_at: %v", err)
+	}
+}
+
+// ListKeys returns keys newest first, optionally for one partner account
+func (s *APIKeyService) ListKeys(ctx context.Context, userID string) ([]models.APIKey, error) {
+	filter := bson.M{}
+	if userID != "" {
+		filter["user_id"] = userID
+	}
+	opts := options.Find().SetSort(bson.D{{Key: "created_at", Value: -1}})
+	cursor, err := database.Mongo.Collection("api_keys").Find(ctx, filter, opts)
+	if err != nil {
+		return nil, err
+	}
+	keys := []models.APIKey{}
+	if err := cursor.All(ctx, &keys); err != nil {
+		return nil, err
+	}
+	return keys, nil
+}
+
+// RevokeKey disables a key immediately; the record is kept for the audit trail
+func (s *APIKeyService) RevokeKey(ctx context.Context, keyIDHex string) (*models.APIKey, error) {
+	objID, err := primitive.ObjectIDFromHex(keyIDHex)
+	if err != nil {
+		return nil, ErrAPIKeyNotFound
+	}
+
+	var key models.APIKey
+	opts := options.FindOneAndUpdate().SetReturnDocument(options.After)
+	update := bson.M{"$set": bson.M{"revoked_at": time.Now()}}
+	err = database.Mongo.Collection("api_keys").FindOneAndUpdate(ctx, bson.M{"_id": objID, "revoked_at": bson.M{"$exists": false}}, update, opts).Decode(&key)
+	if err == mongo.ErrNoDocuments {
+		return nil, ErrAPIKeyNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &key, nil
+}
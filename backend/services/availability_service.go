@@ -0,0 +1,62 @@
+package services
+
+import (
+	"context"
+	"log"
+	"movie-ticket-backend/models"
+	"time"
+)
+
+// SeatCountsMessage is broadcast whenever a screening's availability changes, so listings stay current
+type SeatCountsMessage struct {
+	Type         string                  `json:"type"` // SEAT_COUNTS
+	ScreeningID  string                  `json:"screening_id"`
+	MovieID      string                  `json:"movie_id"`
+	StartTime    string                  `json:"start_time"`
+	Availability models.SeatAvailability `json:"availability"`
+}
+
+// AttachAvailability fills in Availability from the screenings' seat counters and the live lock counts.
+// Screenings must be loaded with seats_total and seats_booked; their seats are only counted when a
+// screening has no counters yet and its seats were loaded.
+func AttachAvailability(ctx context.Context, screenings []models.Screening) error {
+	ids := make([]string, len(screenings))
+	for i, sc := range screenings {
+		ids[i] = sc.ID.Hex()
+	}
+	locked, err := NewLockService().LockedCounts(ids)
+	if err != nil {
+		return err
+	}
+	for i := range screenings {
+		sc := &screenings[i]
+		total, booked := sc.SeatsTotal, sc.SeatsBooked
+		if total == 0 && len(sc.Seats) > 0 {
+			total, booked = len(sc.Seats), sc.SoldSeats()
+		}
+		availability := models.NewSeatAvailability(total, booked, locked[sc.ID.Hex()])
+		sc.Availability = &availability
+	}
+	return nil
+}
+
+// PublishSeatCounts pushes the current availability of a screening over the WebSocket hub
+func PublishSeatCounts(screeningID string) {
+	screening, err := NewScreeningService().GetByID(context.Background(), screeningID)
+	if err != nil {
+		log.Printf("Seat counts: cannot load screening %s: %v", screeningID, err)
+		return
+	}
+	screenings := []models.Screening{*screening}
+	if err := AttachAvailability(context.Background(), screenings); err != nil {
+		log.Printf("Seat counts: cannot count locks of %s: %v", screeningID, err)
+		return
+	}
+	WSHub.Broadcast <- SeatCountsMessage{
+		Type:         "SEAT_COUNTS",
+		ScreeningID:  screeningID,
+		MovieID:      screening.MovieID.Hex(),
+		StartTime:    screening.StartTime.Format(time.RFC3339Nano),
+		Availability: *screenings[0].Availability,
+	}
+}
@@ -7,9 +7,7 @@ import (
 	"movie-ticket-backend/models"
 	"time"
 
-	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
-	"go.mongodb.org/mongo-driver/mongo/options"
 )
 
 type BookingService struct{}
@@ -24,86 +22,66 @@ type BookingResult struct {
 	SuccessfulBookings []models.Booking
 }
 
-// ProcessBooking handles the core logic of booking seats
-func (s *BookingService) ProcessBooking(userID string, movieID string, screeningID string, startTime string, seatIDs []string, paymentID string) (*BookingResult, error) {
+// ProcessBooking handles the core logic of booking seats. apiKeyID is set when a partner integration books.
+func (s *BookingService) ProcessBooking(userID string, movieID string, screeningID string, startTime string, seatIDs []string, paymentID string, apiKeyID string) (*BookingResult, error) {
 	lockService := NewLockService()
-	collection := database.Mongo.Collection("movies")
+	holder := LockHolder(userID, apiKeyID)
 	bookingCollection := database.Mongo.Collection("bookings")
 
 	bookedCount := 0
 	var successfulBookings []models.Booking
 
-	// 1. Fetch the screening price from the database
-	movieObjID, _ := primitive.ObjectIDFromHex(movieID)
-	var movie models.Movie
-	collection.FindOne(context.TODO(), bson.M{"_id": movieObjID}).Decode(&movie)
-
-	var screeningPrice float64 = 0
-	for _, s := range movie.Screenings {
-		if s.ID == screeningID {
-			screeningPrice = s.Price
-			break
-		}
+	// 1. Charge the price frozen when payment started; quote afresh only if there is none
+	// (e.g. partner integrations that book without starting a payment)
+	screening, err := NewScreeningService().GetByID(context.TODO(), screeningID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load screening: %w", err)
 	}
-	// Fallback if price is 0 (should not happen with seeded data, but safety check)
-	if screeningPrice == 0 {
-		screeningPrice = 200
+	var quote *PriceBreakdown
+	if paymentLock, _ := lockService.GetPaymentLock(holder); paymentLock != nil && paymentLock.Quote != nil &&
+		paymentLock.Quote.ScreeningID == screeningID && paymentLock.Quote.Covers(seatIDs) {
+		quote = paymentLock.Quote
+	} else if quote, err = NewPricingService().Quote(context.TODO(), screening, seatIDs); err != nil {
+		return nil, err
 	}
 
+	requiresIDCheck := RequiresIDCheck(context.TODO(), screening)
+
 	for _, seatID := range seatIDs {
 		// 1. Check Lock
-		locked, holder := lockService.IsSeatLocked(movieID, startTime, seatID)
-		if !locked || holder != userID {
-			fmt.Printf("Seat %s lock invalid for user %s\n", seatID, userID)
+		locked, lockedBy := lockService.IsSeatLocked(screeningID, seatID)
+		if !locked || lockedBy != holder {
+			fmt.Printf("Seat %s lock invalid for %s\n", seatID, holder)
 			continue
 		}
 
 		// 2. Update Mongo (Set Status BOOKED)
-		filter := bson.M{
-			"screenings": bson.M{
-				"$elemMatch": bson.M{
-					"id":           screeningID,
-					"seats.id":     seatID,
-					"seats.status": "AVAILABLE", // Concurrency check
-				},
-			},
-		}
-
-		update := bson.M{
-			"$set": bson.M{
-				"screenings.$[scr].seats.$[seat].status": "BOOKED",
-			},
-		}
-
-		arrayFilters := options.UpdateOptions{
-			ArrayFilters: &options.ArrayFilters{
-				Filters: []interface{}{
-					bson.M{"scr.id": screeningID},
-					bson.M{"seat.id": seatID},
-				},
-			},
-		}
-
-		res, err := collection.UpdateOne(context.TODO(), filter, update, &arrayFilters)
+		booked, err := NewScreeningService().MarkSeatBooked(context.TODO(), screening.ID, seatID)
 		if err != nil {
 			fmt.Printf("Mongo Update Error for %s: %v\n", seatID, err)
 			continue
 		}
-		if res.ModifiedCount == 0 {
+		if !booked {
 			fmt.Printf("Seat %s update failed (modified 0)\n", seatID)
 			continue
 		}
 
 		// 3. Create Booking Record
+		line, _ := quote.Line(seatID)
 		booking := models.Booking{
 			ID:              primitive.NewObjectID(),
 			UserID:          userID,
 			ScreeningID:     screeningID,
 			ScreenStartTime: startTime,
 			SeatID:          seatID,
-			Status:          "SUCCESS",
+			SeatType:        line.SeatType,
+			BasePrice:       line.BasePrice,
+			AppliedRules:    line.AppliedRules,
+			Status:          models.BookingStatusSuccess,
 			PaymentID:       paymentID,
-			Amount:          screeningPrice,
+			APIKeyID:        apiKeyID,
+			RequiresIDCheck: requiresIDCheck,
+			Amount:          line.Price,
 			CreatedAt:       time.Now(),
 		}
 		bookingCollection.InsertOne(context.TODO(), booking)
@@ -112,7 +90,7 @@ func (s *BookingService) ProcessBooking(userID string, movieID string, screening
 		successfulBookings = append(successfulBookings, booking)
 
 		// 4. Unlock Redis
-		lockService.UnlockSeat(movieID, startTime, seatID)
+		lockService.UnlockSeat(screeningID, seatID)
 
 		// 5. Update WS
 		WSHub.Broadcast <- SeatUpdateMessage{
@@ -127,6 +105,7 @@ func (s *BookingService) ProcessBooking(userID string, movieID string, screening
 	if bookedCount == 0 {
 		return nil, fmt.Errorf("failed to book any seats")
 	}
+	PublishSeatCounts(screeningID)
 
 	result := &BookingResult{
 		BookedCount:        bookedCount,
@@ -138,6 +117,10 @@ func (s *BookingService) ProcessBooking(userID string, movieID string, screening
 	GetQueueService().PublishEvent("BOOKING_GROUP_SUCCESS", successfulBookings)
 
 	// Audit Log
+	totalAmount := 0.0
+	for _, b := range successfulBookings {
+		totalAmount += b.Amount
+	}
 	LogInfo("BOOKING_SUCCESS", userID, map[string]interface{}{
 		"movie_id":          movieID,
 		"screen_id":         screeningID,
@@ -145,10 +128,12 @@ func (s *BookingService) ProcessBooking(userID string, movieID string, screening
 		"seat_ids":          seatIDs,
 		"booked_count":      bookedCount,
 		"payment_id":        paymentID,
+		"api_key_id":        apiKeyID,
+		"total_amount":      totalAmount,
 	})
 
 	// Release Payment Lock
-	lockService.ReleasePaymentLock(userID)
+	lockService.ReleasePaymentLock(holder)
 
 	return result, nil
 }
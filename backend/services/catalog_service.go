@@ -0,0 +1,347 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"movie-ticket-backend/database"
+	"movie-ticket-backend/models"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// ImportOptions tunes CatalogService.Import
+type ImportOptions struct {
+	AllowOverlaps bool // Create screenings even when they overlap another one in the hall (demo data)
+}
+
+// ImportReport counts what an import did per record type ("cinemas", "halls", "movies", "screenings").
+// Updated means an existing record was matched and refreshed from the catalog.
+type ImportReport struct {
+	Created map[string]int `json:"created"`
+	Updated map[string]int `json:"updated"`
+	Skipped []string       `json:"skipped"` // Records left out, with the reason
+}
+
+func (r *ImportReport) skip(format string, args ...interface{}) {
+	r.Skipped = append(r.Skipped, fmt.Sprintf(format, args...))
+}
+
+type CatalogService struct{}
+
+func NewCatalogService() *CatalogService {
+	return &CatalogService{}
+}
+
+// catalogMovie is what screenings need to know about a movie while importing
+type catalogMovie struct {
+	ID          primitive.ObjectID
+	DurationMin int
+}
+
+// Import upserts cinemas, halls, movies and screenings by their natural keys, so running the same
+// catalog twice changes nothing. Seat state is only written when a screening is created: existing
+// screenings keep their seats and bookings, and a hall's new seat map only applies to new screenings.
+func (s *CatalogService) Import(ctx context.Context, catalog *models.Catalog, opts ImportOptions) (*ImportReport, error) {
+	report := &ImportReport{Created: map[string]int{}, Updated: map[string]int{}, Skipped: []string{}}
+	halls := make(map[string]*models.Hall)  // "<cinema>/<hall>"
+	movies := make(map[string]catalogMovie) // Title
+
+	for _, c := range catalog.Cinemas {
+		name := strings.TrimSpace(c.Name)
+		if name == "" {
+			report.skip("cinema without a name")
+			continue
+		}
+		if _, err := time.LoadLocation(c.Timezone); err != nil || c.Timezone == "" {
+			report.skip("cinema %s: %v", name, ErrInvalidTimezone)
+			continue
+		}
+		set := bson.M{"timezone": c.Timezone}
+		if c.Address != "" {
+			set["address"] = c.Address
+		}
+		cinemaID, created, err := upsertCatalogRecord(ctx, database.Mongo.Collection("cinemas"), bson.M{"name": name}, set, nil)
+		if err != nil {
+			return report, fmt.Errorf("cinema %s: %w", name, err)
+		}
+		report.count("cinemas", created)
+
+		for _, h := range c.Halls {
+			hallName := strings.TrimSpace(h.Name)
+			if err := h.SeatMap.Validate(); err != nil || hallName == "" {
+				report.skip("hall %s/%s: invalid name or seat map", name, hallName)
+				continue
+			}
+			filter := bson.M{"cinema_id": cinemaID, "name": hallName}
+			hallID, created, err := upsertCatalogRecord(ctx, database.Mongo.Collection("halls"), filter, bson.M{"seat_map": h.SeatMap}, nil)
+			if err != nil {
+				return report, fmt.Errorf("hall %s/%s: %w", name, hallName, err)
+			}
+			report.count("halls", created)
+			halls[name+"/"+hallName] = &models.Hall{ID: hallID, CinemaID: cinemaID, Name: hallName, SeatMap: h.SeatMap}
+		}
+	}
+
+	for _, m := range catalog.Movies {
+		m.Title = strings.TrimSpace(m.Title)
+		m.Language = strings.ToLower(strings.TrimSpace(m.Language))
+		m.SubtitleLanguage = strings.ToLower(strings.TrimSpace(m.SubtitleLanguage))
+		if err := m.Validate(); err != nil {
+			report.skip("movie %q: %v", m.Title, err)
+			continue
+		}
+		// Fields the file leaves out keep their stored value; new movies get empty lists for them
+		set := bson.M{"duration_min": m.DurationMin}
+		onInsert := bson.M{}
+		for field, value := range map[string]string{
+			"description":       m.Description,
+			"age_rating":        string(m.AgeRating),
+			"release_date":      m.ReleaseDate,
+			"language":          m.Language,
+			"subtitle_language": m.SubtitleLanguage,
+			"trailer_url":       m.TrailerURL,
+			"poster_url":        m.PosterURL,
+		} {
+			if value != "" {
+				set[field] = value
+			}
+		}
+		for field, list := range map[string][]string{"genres": m.Genres, "directors": m.Directors, "cast": m.Cast} {
+			if len(list) > 0 {
+				set[field] = list
+			} else {
+				onInsert[field] = []string{}
+			}
+		}
+		filter := bson.M{"title": m.Title, "deleted_at": bson.M{"$exists": false}}
+		movieID, created, err := upsertCatalogRecord(ctx, database.Mongo.Collection("movies"), filter, set, onInsert)
+		if err != nil {
+			return report, fmt.Errorf("movie %s: %w", m.Title, err)
+		}
+		report.count("movies", created)
+		movies[m.Title] = catalogMovie{ID: movieID, DurationMin: m.DurationMin}
+	}
+
+	for _, sc := range catalog.Screenings {
+		label := fmt.Sprintf("screening %s at %s/%s %s", sc.Movie, sc.Cinema, sc.Hall, sc.StartTime.Format(time.RFC3339))
+		if err := sc.Validate(); err != nil {
+			report.skip("%s: %v", label, err)
+			continue
+		}
+		movie, err := s.lookupMovie(ctx, movies, sc.Movie)
+		if err != nil {
+			report.skip("%s: %v", label, err)
+			continue
+		}
+		hall, err := s.lookupHall(ctx, halls, sc.Cinema, sc.Hall)
+		if err != nil {
+			report.skip("%s: %v", label, err)
+			continue
+		}
+
+		var existing models.Screening
+		filter := bson.M{"movie_id": movie.ID, "hall_id": hall.ID, "start_time": sc.StartTime, "deleted_at": bson.M{"$exists": false}}
+		err = screeningsCollection().FindOne(ctx, filter, options.FindOne().SetProjection(bson.M{"seats": 0, "layout": 0})).Decode(&existing)
+		if err == nil {
+			if existing.Price == sc.Price && models.SamePrices(existing.Prices, sc.Prices) && existing.Format == sc.Format && existing.Audio == sc.Audio {
+				continue
+			}
+			set := bson.M{"price": sc.Price, "prices": sc.Prices, "format": sc.Format, "audio": sc.Audio, "updated_at": time.Now()}
+			if _, err := screeningsCollection().UpdateOne(ctx, bson.M{"_id": existing.ID}, bson.M{"$set": set}); err != nil {
+				return report, fmt.Errorf("%s: %w", label, err)
+			}
+			report.count("screenings", false)
+			continue
+		}
+		if err != mongo.ErrNoDocuments {
+			return report, fmt.Errorf("%s: %w", label, err)
+		}
+
+		conflicts, err := s.insertScreening(ctx, movie, hall, sc, opts.AllowOverlaps)
+		if err != nil {
+			return report, fmt.Errorf("%s: %w", label, err)
+		}
+		if conflicts > 0 {
+			report.skip("%s: overlaps %d other screening(s) in the hall", label, conflicts)
+			continue
+		}
+		report.count("screenings", true)
+	}
+	return report, nil
+}
+
+// insertScreening creates the screening unless it overlaps others in the hall (and overlaps are
+// not allowed), in which case it returns how many. The hall stays locked from check to insert.
+func (s *CatalogService) insertScreening(ctx context.Context, movie catalogMovie, hall *models.Hall, sc models.CatalogScreening, allowOverlaps bool) (int, error) {
+	unlock, err := NewLockService().LockHalls(ctx, hall.ID)
+	if err != nil {
+		return 0, err
+	}
+	defer unlock()
+
+	screeningService := NewScreeningService()
+	if !allowOverlaps {
+		conflicts, err := screeningService.FindHallConflicts(ctx, hall.ID, sc.StartTime, movie.DurationMin)
+		if err != nil {
+			return 0, err
+		}
+		if len(conflicts) > 0 {
+			return len(conflicts), nil
+		}
+	}
+	screening := NewScreening(movie.ID, hall, sc.StartTime, sc.Price, sc.Prices)
+	screening.Format = sc.Format
+	screening.Audio = sc.Audio
+	return 0, screeningService.Insert(ctx, screening)
+}
+
+func (r *ImportReport) count(kind string, created bool) {
+	if created {
+		r.Created[kind]++
+	} else {
+		r.Updated[kind]++
+	}
+}
+
+// upsertCatalogRecord sets fields on the record matching filter, creating it if needed, and returns its ID.
+// onInsert fields are only written when the record is created.
+func upsertCatalogRecord(ctx context.Context, coll *mongo.Collection, filter, set, onInsert bson.M) (primitive.ObjectID, bool, error) {
+	now := time.Now()
+	set["updated_at"] = now
+	setOnInsert := bson.M{"created_at": now}
+	for k, v := range onInsert {
+		setOnInsert[k] = v
+	}
+	update := bson.M{"$set": set, "$setOnInsert": setOnInsert}
+	res, err := coll.UpdateOne(ctx, filter, update, options.Update().SetUpsert(true))
+	if err != nil {
+		return primitive.NilObjectID, false, err
+	}
+	if id, ok := res.UpsertedID.(primitive.ObjectID); ok {
+		return id, true, nil
+	}
+	var doc struct {
+		ID primitive.ObjectID `bson:"_id"`
+	}
+	err = coll.FindOne(ctx, filter, options.FindOne().SetProjection(bson.M{"_id": 1})).Decode(&doc)
+	return doc.ID, false, err
+}
+
+// lookupMovie finds a movie imported earlier in this run or already in the database
+func (s *CatalogService) lookupMovie(ctx context.Context, cache map[string]catalogMovie, title string) (catalogMovie, error) {
+	if movie, ok := cache[title]; ok {
+		return movie, nil
+	}
+	var movie models.Movie
+	filter := bson.M{"title": title, "deleted_at": bson.M{"$exists": false}}
+	if err := database.Mongo.Collection("movies").FindOne(ctx, filter).Decode(&movie); err != nil {
+		return catalogMovie{}, fmt.Errorf("unknown movie %q", title)
+	}
+	cache[title] = catalogMovie{ID: movie.ID, DurationMin: movie.DurationMin}
+	return cache[title], nil
+}
+
+// lookupHall finds a hall imported earlier in this run or already in the database
+func (s *CatalogService) lookupHall(ctx context.Context, cache map[string]*models.Hall, cinemaName, hallName string) (*models.Hall, error) {
+	key := cinemaName + "/" + hallName
+	if hall, ok := cache[key]; ok {
+		return hall, nil
+	}
+	var cinema models.Cinema
+	if err := database.Mongo.Collection("cinemas").FindOne(ctx, bson.M{"name": cinemaName}).Decode(&cinema); err != nil {
+		return nil, fmt.Errorf("unknown cinema %q", cinemaName)
+	}
+	var hall models.Hall
+	if err := database.Mongo.Collection("halls").FindOne(ctx, bson.M{"cinema_id": cinema.ID, "name": hallName}).Decode(&hall); err != nil {
+		return nil, fmt.Errorf("unknown hall %q in %s", hallName, cinemaName)
+	}
+	cache[key] = &hall
+	return &hall, nil
+}
+
+// Export writes the catalog: all cinemas with their halls, movies that are not deleted, and their
+// screenings starting at or after from. Screenings without a hall cannot be expressed and are left out.
+func (s *CatalogService) Export(ctx context.Context, from time.Time) (*models.Catalog, error) {
+	catalog := &models.Catalog{Cinemas: []models.CatalogCinema{}, Movies: []models.CatalogMovie{}, Screenings: []models.CatalogScreening{}}
+	cinemaService := NewCinemaService()
+
+	cinemas, err := cinemaService.ListCinemas(ctx)
+	if err != nil {
+		return nil, err
+	}
+	hallNames := make(map[primitive.ObjectID][2]string) // Hall ID -> cinema name, hall name
+	for _, c := range cinemas {
+		halls, err := cinemaService.ListHalls(ctx, c.ID.Hex())
+		if err != nil {
+			return nil, err
+		}
+		entry := models.CatalogCinema{Name: c.Name, Address: c.Address, Timezone: c.Timezone}
+		for _, h := range halls {
+			entry.Halls = append(entry.Halls, models.CatalogHall{Name: h.Name, SeatMap: h.SeatMap})
+			hallNames[h.ID] = [2]string{c.Name, h.Name}
+		}
+		catalog.Cinemas = append(catalog.Cinemas, entry)
+	}
+
+	opts := options.Find().SetSort(bson.D{{Key: "title", Value: 1}})
+	cursor, err := database.Mongo.Collection("movies").Find(ctx, bson.M{"deleted_at": bson.M{"$exists": false}}, opts)
+	if err != nil {
+		return nil, err
+	}
+	var movies []models.Movie
+	if err := cursor.All(ctx, &movies); err != nil {
+		return nil, err
+	}
+	titles := make(map[primitive.ObjectID]string, len(movies))
+	for _, m := range movies {
+		titles[m.ID] = m.Title
+		catalog.Movies = append(catalog.Movies, models.CatalogMovie{
+			Title:            m.Title,
+			Description:      m.Description,
+			Genres:           m.Genres,
+			AgeRating:        m.AgeRating,
+			ReleaseDate:      m.ReleaseDate,
+			Language:         m.Language,
+			SubtitleLanguage: m.SubtitleLanguage,
+			Directors:        m.Directors,
+			Cast:             m.Cast,
+			DurationMin:      m.DurationMin,
+			PosterURL:        m.PosterURL,
+			TrailerURL:       m.TrailerURL,
+		})
+	}
+
+	filter := bson.M{"start_time": bson.M{"$gte": from}, "hall_id": bson.M{"$exists": true}, "deleted_at": bson.M{"$exists": false}}
+	opts = options.Find().SetSort(bson.D{{Key: "start_time", Value: 1}}).SetProjection(bson.M{"seats": 0, "layout": 0})
+	cursor, err = screeningsCollection().Find(ctx, filter, opts)
+	if err != nil {
+		return nil, err
+	}
+	var screenings []models.Screening
+	if err := cursor.All(ctx, &screenings); err != nil {
+		return nil, err
+	}
+	for _, sc := range screenings {
+		title, ok := titles[sc.MovieID]
+		names, hasHall := hallNames[sc.HallID]
+		if !ok || !hasHall {
+			continue
+		}
+		catalog.Screenings = append(catalog.Screenings, models.CatalogScreening{
+			Movie:     title,
+			Cinema:    names[0],
+			Hall:      names[1],
+			StartTime: sc.StartTime,
+			Price:     sc.Price,
+			Prices:    sc.Prices,
+			Format:    sc.Format,
+			Audio:     sc.Audio,
+		})
+	}
+	return catalog, nil
+}
@@ -0,0 +1,146 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"movie-ticket-backend/database"
+	"movie-ticket-backend/models"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+var (
+	ErrCinemaNotFound  = errors.New("cinema not found")
+	ErrHallNotFound    = errors.New("hall not found")
+	ErrInvalidTimezone = errors.New("timezone must be an IANA name such as Asia/Bangkok")
+)
+
+type CinemaService struct{}
+
+func NewCinemaService() *CinemaService {
+	return &CinemaService{}
+}
+
+func (s *CinemaService) CreateCinema(ctx context.Context, name, address, timezone string) (*models.Cinema, error) {
+	if _, err := time.LoadLocation(timezone); err != nil || timezone == "" {
+		return nil, ErrInvalidTimezone
+	}
+	now := time.Now()
+	cinema := models.Cinema{
+		ID:        primitive.NewObjectID(),
+		Name:      strings.TrimSpace(name),
+		Address:   address,
+		Timezone:  timezone,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+	if _, err := database.Mongo.Collection("cinemas").InsertOne(ctx, cinema); err != nil {
+		return nil, err
+	}
+	return &cinema, nil
+}
+
+func (s *CinemaService) ListCinemas(ctx context.Context) ([]models.Cinema, error) {
+	cursor, err := database.Mongo.Collection("cinemas").Find(ctx, bson.M{}, options.Find().SetSort(bson.D{{Key: "name", Value: 1}}))
+	if err != nil {
+		return nil, err
+	}
+	cinemas := []models.Cinema{}
+	if err := cursor.All(ctx, &cinemas); err != nil {
+		return nil, err
+	}
+	return cinemas, nil
+}
+
+func (s *CinemaService) GetCinema(ctx context.Context, cinemaIDHex string) (*models.Cinema, error) {
+	objID, err := primitive.ObjectIDFromHex(cinemaIDHex)
+	if err != nil {
+		return nil, ErrCinemaNotFound
+	}
+	var cinema models.Cinema
+	err = database.Mongo.Collection("cinemas").FindOne(ctx, bson.M{"_id": objID}).Decode(&cinema)
+	if err == mongo.ErrNoDocuments {
+		return nil, ErrCinemaNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &cinema, nil
+}
+
+// CreateHall adds a hall to a cinema. The seat map must already be validated.
+func (s *CinemaService) CreateHall(ctx context.Context, cinemaIDHex, name string, seatMap models.SeatMap) (*models.Hall, error) {
+	cinema, err := s.GetCinema(ctx, cinemaIDHex)
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	hall := models.Hall{
+		ID:        primitive.NewObjectID(),
+		CinemaID:  cinema.ID,
+		Name:      strings.TrimSpace(name),
+		SeatMap:   seatMap,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+	if _, err := database.Mongo.Collection("halls").InsertOne(ctx, hall); err != nil {
+		return nil, err
+	}
+	return &hall, nil
+}
+
+func (s *CinemaService) ListHalls(ctx context.Context, cinemaIDHex string) ([]models.Hall, error) {
+	cinema, err := s.GetCinema(ctx, cinemaIDHex)
+	if err != nil {
+		return nil, err
+	}
+	cursor, err := database.Mongo.Collection("halls").Find(ctx, bson.M{"cinema_id": cinema.ID}, options.Find().SetSort(bson.D{{Key: "name", Value: 1}}))
+	if err != nil {
+		return nil, err
+	}
+	halls := []models.Hall{}
+	if err := cursor.All(ctx, &halls); err != nil {
+		return nil, err
+	}
+	return halls, nil
+}
+
+func (s *CinemaService) GetHall(ctx context.Context, hallIDHex string) (*models.Hall, error) {
+	objID, err := primitive.ObjectIDFromHex(hallIDHex)
+	if err != nil {
+		return nil, ErrHallNotFound
+	}
+	var hall models.Hall
+	err = database.Mongo.Collection("halls").FindOne(ctx, bson.M{"_id": objID}).Decode(&hall)
+	if err == mongo.ErrNoDocuments {
+		return nil, ErrHallNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &hall, nil
+}
+
+// UpdateHall renames a hall or replaces its seat map. Existing screenings keep the layout they were created with.
+func (s *CinemaService) UpdateHall(ctx context.Context, hallIDHex, name string, seatMap models.SeatMap) (*models.Hall, error) {
+	objID, err := primitive.ObjectIDFromHex(hallIDHex)
+	if err != nil {
+		return nil, ErrHallNotFound
+	}
+	var hall models.Hall
+	update := bson.M{"$set": bson.M{"name": strings.TrimSpace(name), "seat_map": seatMap, "updated_at": time.Now()}}
+	opts := options.FindOneAndUpdate().SetReturnDocument(options.After)
+	err = database.Mongo.Collection("halls").FindOneAndUpdate(ctx, bson.M{"_id": objID}, update, opts).Decode(&hall)
+	if err == mongo.ErrNoDocuments {
+		return nil, ErrHallNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &hall, nil
+}
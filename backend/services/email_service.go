@@ -60,8 +60,96 @@ func (s *EmailService) SendGroupTicketEmail(user models.User, bookings []models.
 	body.WriteString("\n")
 	body.WriteString("--------------------------------------------------\n")
 	body.WriteString(" Please show this email at the theater entrance.\n")
+	if firstBooking.RequiresIDCheck {
+		body.WriteString(" This movie is age-restricted: bring photo ID, it will\n")
+		body.WriteString(" be checked at the entrance.\n")
+	}
 	body.WriteString("==================================================\n")
 
+	s.deliver(user, subject, body.String())
+}
+
+// SendScheduleChangeEmail tells a customer their screening moved to a new time
+func (s *EmailService) SendScheduleChangeEmail(user models.User, bookings []models.Booking, movieTitle string, oldStart, newStart string) {
+	var seatList []string
+	for _, b := range bookings {
+		seatList = append(seatList, b.SeatID)
+	}
+
+	subject := fmt.Sprintf("Show time changed: %s", movieTitle)
+
+	body := new(strings.Builder)
+	body.WriteString(fmt.Sprintf("To: %s\r\n", user.Email))
+	body.WriteString(fmt.Sprintf("Subject: %s\r\n", subject))
+	body.WriteString("\r\n") // End of headers
+
+	body.WriteString(fmt.Sprintf(" Hello %s,\n", user.Name))
+	body.WriteString("\n")
+	body.WriteString(" The cinema has rescheduled a screening you have tickets for:\n")
+	body.WriteString("\n")
+	body.WriteString(fmt.Sprintf(" Movie:          %s\n", movieTitle))
+	body.WriteString(fmt.Sprintf(" Old Show Time:  %s\n", oldStart))
+	body.WriteString(fmt.Sprintf(" New Show Time:  %s\n", newStart))
+	body.WriteString(fmt.Sprintf(" Seats:          %s\n", strings.Join(seatList, ", ")))
+	body.WriteString("\n")
+	body.WriteString(" Your seats are kept. Please contact us if the new time does not suit you.\n")
+
+	s.deliver(user, subject, body.String())
+}
+
+// SendCancellationEmail tells a customer their screening was cancelled and their tickets refunded
+func (s *EmailService) SendCancellationEmail(user models.User, bookings []models.Booking, movieTitle string, startTime string, reason string) {
+	var seatList []string
+	var total float64
+	for _, b := range bookings {
+		seatList = append(seatList, b.SeatID)
+		total += b.Amount
+	}
+
+	subject := fmt.Sprintf("Screening cancelled: %s", movieTitle)
+
+	body := new(strings.Builder)
+	body.WriteString(fmt.Sprintf("To: %s\r\n", user.Email))
+	body.WriteString(fmt.Sprintf("Subject: %s\r\n", subject))
+	body.WriteString("\r\n") // End of headers
+
+	body.WriteString(fmt.Sprintf(" Hello %s,\n", user.Name))
+	body.WriteString("\n")
+	body.WriteString(" We are sorry, the cinema had to cancel a screening you have tickets for:\n")
+	body.WriteString("\n")
+	body.WriteString(fmt.Sprintf(" Movie:      %s\n", movieTitle))
+	body.WriteString(fmt.Sprintf(" Show Time:  %s\n", startTime))
+	body.WriteString(fmt.Sprintf(" Seats:      %s\n", strings.Join(seatList, ", ")))
+	if reason != "" {
+		body.WriteString(fmt.Sprintf(" Reason:     %s\n", reason))
+	}
+	body.WriteString("\n")
+	body.WriteString(fmt.Sprintf(" A refund of %.2f %s has been issued to your original payment method.\n", total, Currency))
+
+	s.deliver(user, subject, body.String())
+}
+
+// SendVerificationEmail sends the confirmation link for a new local account
+func (s *EmailService) SendVerificationEmail(user models.User, link string) {
+	subject := "Confirm your MovieTicket account"
+
+	body := new(strings.Builder)
+	body.WriteString(fmt.Sprintf("To: %s\r\n", user.Email))
+	body.WriteString(fmt.Sprintf("Subject: %s\r\n", subject))
+	body.WriteString("\r\n") // End of headers
+
+	body.WriteString(fmt.Sprintf(" Hello %s,\n", user.Name))
+	body.WriteString("\n")
+	body.WriteString(" Please confirm your email address by opening the link below:\n")
+	body.WriteString(fmt.Sprintf(" %s\n", link))
+	body.WriteString("\n")
+	body.WriteString(" The link expires in 24 hours.\n")
+
+	s.deliver(user, subject, body.String())
+}
+
+// deliver sends the message over SMTP when configured, otherwise logs it (mock)
+func (s *EmailService) deliver(user models.User, subject string, message string) {
 	// Check if real email config is available
 	if config.AppConfig.GoogleClientID != "" || os.Getenv("EMAIL_SENDER") != "" {
 		// Ideally checking specific EMAIL_SENDER config from env directly as it wasn't in config struct yet
@@ -72,7 +160,7 @@ func (s *EmailService) SendGroupTicketEmail(user models.User, bookings []models.
 		if sender != "" && password != "" {
 			auth := smtp.PlainAuth("", sender, password, "smtp.gmail.com")
 			to := []string{user.Email}
-			msg := []byte(body.String())
+			msg := []byte(message)
 
 			err := smtp.SendMail("smtp.gmail.com:587", auth, sender, to, msg)
 			if err != nil {
@@ -85,5 +173,5 @@ func (s *EmailService) SendGroupTicketEmail(user models.User, bookings []models.
 	}
 
 	// Fallback to Console Log (Mock)
-	log.Printf("\n[EMAIL SENT] To: %s (%s)\nSubject: %s%s", user.Name, user.Email, subject, body.String())
+	log.Printf("\n[EMAIL SENT] To: %s (%s)\nSubject: %s%s", user.Name, user.Email, subject, message)
 }
@@ -0,0 +1,79 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"golang.org/x/oauth2"
+	"golang.org/x/oauth2/google"
+)
+
+// GoogleProvider logs users in with Google OAuth 2.0
+type GoogleProvider struct {
+	oauthConfig *oauth2.Config
+}
+
+func NewGoogleProvider(clientID, clientSecret, redirectURL string) *GoogleProvider {
+	return &GoogleProvider{
+		oauthConfig: &oauth2.Config{
+			RedirectURL:  redirectURL,
+			ClientID:     clientID,
+			ClientSecret: clientSecret,
+			Scopes:       []string{"https://www.googleapis.com/auth/userinfo.email", "https://www.googleapis.com/auth/userinfo.profile"},
+			Endpoint:     google.Endpoint,
+		},
+	}
+}
+
+func (p *GoogleProvider) Name() string {
+	return "google"
+}
+
+func (p *GoogleProvider) AuthCodeURL(state string) string {
+	return p.oauthConfig.AuthCodeURL(state)
+}
+
+func (p *GoogleProvider) Exchange(ctx context.Context, code string) (*ExternalIdentity, error) {
+	token, err := p.oauthConfig.Exchange(ctx, code)
+	if err != nil {
+		return nil, fmt.Errorf("code exchange failed: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, "https://www.googleapis.com/oauth2/v2/userinfo", nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Authorization", "Bearer "+token.AccessToken)
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("user data fetch failed: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("user data fetch failed: status %d", resp.StatusCode)
+	}
+
+	// Parse Google User Data
+	var googleUser struct {
+		ID            string `json:"id"`
+		Email         string `json:"email"`
+		VerifiedEmail bool   `json:"verified_email"`
+		Name          string `json:"name"`
+		Picture       string `json:"picture"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&googleUser); err != nil {
+		return nil, fmt.Errorf("JSON parse failed: %w", err)
+	}
+
+	return &ExternalIdentity{
+		Provider:      p.Name(),
+		Subject:       googleUser.ID,
+		Email:         googleUser.Email,
+		EmailVerified: googleUser.VerifiedEmail,
+		Name:          googleUser.Name,
+		PictureURL:    googleUser.Picture,
+	}, nil
+}
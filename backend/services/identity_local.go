@@ -0,0 +1,182 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"movie-ticket-backend/config"
+	"movie-ticket-backend/database"
+	"movie-ticket-backend/models"
+	"net/url"
+	"sync"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"golang.org/x/crypto/bcrypt"
+)
+
+const emailVerificationTTL = 24 * time.Hour
+
+var (
+	ErrInvalidCredentials       = errors.New("invalid email or password")
+	ErrEmailNotVerified         = errors.New("email address is not verified")
+	ErrEmailAlreadyRegistered   = errors.New("email is already registered")
+	ErrInvalidVerificationToken = errors.New("verification link is invalid or expired")
+	ErrPasswordPolicy           = errors.New("password must be between 8 and 72 characters")
+)
+
+var (
+	dummyPasswordHash     []byte
+	dummyPasswordHashOnce sync.Once
+)
+
+func getDummyPasswordHash() []byte {
+	dummyPasswordHashOnce.Do(func() {
+		dummyPasswordHash, _ = bcrypt.GenerateFromPassword([]byte("not-a-real-password"), bcrypt.DefaultCost)
+	})
+	return dummyPasswordHash
+}
+
+// LocalProvider handles email/password accounts stored in the users collection
+type LocalProvider struct {
+	RDB *redis.Client
+}
+
+func NewLocalProvider() *LocalProvider {
+	return &LocalProvider{
+		RDB: database.RDB,
+	}
+}
+
+func (p *LocalProvider) Name() string {
+	return "local"
+}
+
+// Register creates an unverified account and emails a verification link
+func (p *LocalProvider) Register(ctx context.Context, email, password, name string) (*models.User, error) {
+	email = normalizeEmail(email)
+	// bcrypt ignores everything after 72 bytes
+	if len(password) < 8 || len(password) > 72 {
+		return nil, ErrPasswordPolicy
+	}
+
+	collection := database.Mongo.Collection("users")
+	count, err := collection.CountDocuments(ctx, bson.M{"email": email})
+	if err != nil {
+		return nil, err
+	}
+	if count > 0 {
+		return nil, ErrEmailAlreadyRegistered
+	}
+
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		return nil, err
+	}
+
+	// Bootstrap admins are promoted on their first verified login (see UpsertUserFromIdentity)
+	role := models.RoleUser
+
+	userID := primitive.NewObjectID()
+	user := models.User{
+		ID:            userID,
+		Email:         email,
+		EmailVerified: false,
+		Name:          name,
+		Role:          role,
+		PasswordHash:  string(hash),
+		Identities:    []models.LinkedIdentity{{Provider: p.Name(), Subject: userID.Hex()}},
+		Status:        models.UserActive,
+		CreatedAt:     time.Now(),
+		UpdatedAt:     time.Now(),
+	}
+	if _, err := collection.InsertOne(ctx, user); err != nil {
+		if mongo.IsDuplicateKeyError(err) {
+			return nil, ErrEmailAlreadyRegistered // Registered concurrently
+		}
+		return nil, err
+	}
+
+	if err := p.SendVerification(ctx, user); err != nil {
+		return nil, err
+	}
+	return &user, nil
+}
+
+// SendVerification stores a single use token and emails the link to the user
+func (p *LocalProvider) SendVerification(ctx context.Context, user models.User) error {
+	token, err := randomToken(32)
+	if err != nil {
+		return err
+	}
+	key := fmt.Sprintf("email_verify:%s", token)
+	if err := p.RDB.Set(ctx, key, user.ID.Hex(), emailVerificationTTL).Err(); err != nil {
+		return err
+	}
+
+	link := fmt.Sprintf("%s/api/auth/local/verify?token=%s", config.AppConfig.PublicAPIURL, url.QueryEscape(token))
+	GetEmailService().SendVerificationEmail(user, link)
+	return nil
+}
+
+// VerifyEmail consumes a verification token and marks the email as verified
+func (p *LocalProvider) VerifyEmail(ctx context.Context, token string) (*models.User, error) {
+	key := fmt.Sprintf("email_verify:%s", token)
+	userIDHex, err := p.RDB.GetDel(ctx, key).Result()
+	if err == redis.Nil {
+		return nil, ErrInvalidVerificationToken
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	objID, err := primitive.ObjectIDFromHex(userIDHex)
+	if err != nil {
+		return nil, ErrInvalidVerificationToken
+	}
+
+	collection := database.Mongo.Collection("users")
+	_, err = collection.UpdateOne(ctx, bson.M{"_id": objID}, bson.M{"$set": bson.M{"email_verified": true, "updated_at": time.Now()}})
+	if err != nil {
+		return nil, err
+	}
+	InvalidateUser(userIDHex)
+
+	var user models.User
+	if err := collection.FindOne(ctx, bson.M{"_id": objID}).Decode(&user); err != nil {
+		return nil, err
+	}
+	return &user, nil
+}
+
+// Authenticate checks the password. Unknown emails still run bcrypt so timing does not reveal accounts.
+func (p *LocalProvider) Authenticate(ctx context.Context, email, password string) (*ExternalIdentity, error) {
+	var user models.User
+	err := database.Mongo.Collection("users").FindOne(ctx, bson.M{"email": normalizeEmail(email)}).Decode(&user)
+	if err != nil && err != mongo.ErrNoDocuments {
+		return nil, err
+	}
+
+	hash := []byte(user.PasswordHash)
+	if len(hash) == 0 {
+		hash = getDummyPasswordHash()
+	}
+	if bcrypt.CompareHashAndPassword(hash, []byte(password)) != nil || user.PasswordHash == "" {
+		return nil, ErrInvalidCredentials
+	}
+	if !user.EmailVerified {
+		return nil, ErrEmailNotVerified
+	}
+
+	return &ExternalIdentity{
+		Provider:      p.Name(),
+		Subject:       user.ID.Hex(),
+		Email:         user.Email,
+		EmailVerified: true,
+		Name:          user.Name,
+		PictureURL:    user.PictureURL,
+	}, nil
+}
@@ -0,0 +1,250 @@
+package services
+
+import (
+	"context"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/oauth2"
+)
+
+// OIDCProvider works with any OpenID Connect issuer that publishes a discovery document
+type OIDCProvider struct {
+	name        string
+	issuer      string
+	clientID    string
+	oauthConfig *oauth2.Config
+	jwksURI     string
+	userinfoURL string
+
+	keysMu    sync.RWMutex
+	keys      map[string]*rsa.PublicKey
+	keysFetch time.Time
+}
+
+type oidcDiscovery struct {
+	Issuer                string `json:"issuer"`
+	AuthorizationEndpoint string `json:"authorization_endpoint"`
+	TokenEndpoint         string `json:"token_endpoint"`
+	UserinfoEndpoint      string `json:"userinfo_endpoint"`
+	JWKSURI               string `json:"jwks_uri"`
+}
+
+type oidcClaims struct {
+	RegisteredClaims
+	Email         string          `json:"email"`
+	EmailVerified json.RawMessage `json:"email_verified"` // some issuers send "true" as a string
+	Name          string          `json:"name"`
+	Picture       string          `json:"picture"`
+}
+
+// NewOIDCProvider fetches <issuer>/.well-known/openid-configuration and builds the OAuth2 client from it
+func NewOIDCProvider(ctx context.Context, name, issuer, clientID, clientSecret, redirectURL string, scopes []string) (*OIDCProvider, error) {
+	issuer = strings.TrimRight(issuer, "/")
+
+	var discovery oidcDiscovery
+	if err := getJSON(ctx, issuer+"/.well-known/openid-configuration", &discovery); err != nil {
+		return nil, fmt.Errorf("discovery failed: %w", err)
+	}
+	if strings.TrimRight(discovery.Issuer, "/") != issuer {
+		return nil, fmt.Errorf("discovery issuer %q does not match %q", discovery.Issuer, issuer)
+	}
+	if discovery.JWKSURI == "" || discovery.AuthorizationEndpoint == "" || discovery.TokenEndpoint == "" {
+		return nil, fmt.Errorf("discovery document is missing endpoints")
+	}
+
+	return &OIDCProvider{
+		name:     name,
+		issuer:   discovery.Issuer,
+		clientID: clientID,
+		oauthConfig: &oauth2.Config{
+			ClientID:     clientID,
+			ClientSecret: clientSecret,
+			RedirectURL:  redirectURL,
+			Scopes:       scopes,
+			Endpoint: oauth2.Endpoint{
+				AuthURL:  discovery.AuthorizationEndpoint,
+				TokenURL: discovery.TokenEndpoint,
+			},
+		},
+		jwksURI:     discovery.JWKSURI,
+		userinfoURL: discovery.UserinfoEndpoint,
+	}, nil
+}
+
+func (p *OIDCProvider) Name() string {
+	return p.name
+}
+
+func (p *OIDCProvider) AuthCodeURL(state string) string {
+	return p.oauthConfig.AuthCodeURL(state)
+}
+
+func (p *OIDCProvider) Exchange(ctx context.Context, code string) (*ExternalIdentity, error) {
+	token, err := p.oauthConfig.Exchange(ctx, code)
+	if err != nil {
+		return nil, fmt.Errorf("code exchange failed: %w", err)
+	}
+
+	rawIDToken, ok := token.Extra("id_token").(string)
+	if !ok || rawIDToken == "" {
+		return nil, fmt.Errorf("token response has no id_token")
+	}
+
+	var claims oidcClaims
+	err = parseJWT(rawIDToken, func(header jwtHeader) (interface{}, error) {
+		if header.Alg != "RS256" {
+			return nil, fmt.Errorf("unsupported id_token algorithm %q", header.Alg)
+		}
+		return p.publicKey(ctx, header.Kid)
+	}, &claims)
+	if err != nil {
+		return nil, fmt.Errorf("invalid id_token: %w", err)
+	}
+	if err := claims.Valid(time.Now()); err != nil {
+		return nil, fmt.Errorf("invalid id_token: %w", err)
+	}
+	if claims.Issuer != p.issuer || !claims.Audience.Contains(p.clientID) {
+		return nil, fmt.Errorf("id_token was not issued for this client")
+	}
+
+	identity := &ExternalIdentity{
+		Provider:      p.name,
+		Subject:       claims.Subject,
+		Email:         claims.Email,
+		EmailVerified: parseBoolClaim(claims.EmailVerified),
+		Name:          claims.Name,
+		PictureURL:    claims.Picture,
+	}
+
+	// Some issuers keep the profile out of the id_token
+	if identity.Email == "" && p.userinfoURL != "" {
+		var info oidcClaims
+		if err := getJSONWithToken(ctx, p.userinfoURL, token.AccessToken, &info); err != nil {
+			return nil, fmt.Errorf("userinfo fetch failed: %w", err)
+		}
+		if info.Subject != claims.Subject {
+			return nil, fmt.Errorf("userinfo subject mismatch")
+		}
+		identity.Email = info.Email
+		identity.EmailVerified = parseBoolClaim(info.EmailVerified)
+		if identity.Name == "" {
+			identity.Name = info.Name
+		}
+		if identity.PictureURL == "" {
+			identity.PictureURL = info.Picture
+		}
+	}
+
+	return identity, nil
+}
+
+// publicKey returns the JWKS key for kid, refetching the key set when the kid is unknown (key rotation)
+func (p *OIDCProvider) publicKey(ctx context.Context, kid string) (*rsa.PublicKey, error) {
+	p.keysMu.RLock()
+	key, ok := p.keys[kid]
+	lastFetch := p.keysFetch
+	p.keysMu.RUnlock()
+	if ok {
+		return key, nil
+	}
+
+	// Don't let unknown kids hammer the issuer
+	if time.Since(lastFetch) < 30*time.Second {
+		return nil, fmt.Errorf("unknown signing key %q", kid)
+	}
+
+	keys, err := fetchJWKS(ctx, p.jwksURI)
+	p.keysMu.Lock()
+	p.keysFetch = time.Now()
+	if err == nil {
+		p.keys = keys
+	}
+	p.keysMu.Unlock()
+	if err != nil {
+		return nil, err
+	}
+
+	key, ok = keys[kid]
+	if !ok {
+		return nil, fmt.Errorf("unknown signing key %q", kid)
+	}
+	return key, nil
+}
+
+func fetchJWKS(ctx context.Context, uri string) (map[string]*rsa.PublicKey, error) {
+	var jwks struct {
+		Keys []struct {
+			Kty string `json:"kty"`
+			Kid string `json:"kid"`
+			Use string `json:"use"`
+			N   string `json:"n"`
+			E   string `json:"e"`
+		} `json:"keys"`
+	}
+	if err := getJSON(ctx, uri, &jwks); err != nil {
+		return nil, fmt.Errorf("JWKS fetch failed: %w", err)
+	}
+
+	keys := make(map[string]*rsa.PublicKey)
+	for _, k := range jwks.Keys {
+		if k.Kty != "RSA" || (k.Use != "" && k.Use != "sig") {
+			continue
+		}
+		n, err := base64.RawURLEncoding.DecodeString(k.N)
+		if err != nil {
+			continue
+		}
+		e, err := base64.RawURLEncoding.DecodeString(k.E)
+		if err != nil {
+			continue
+		}
+		keys[k.Kid] = &rsa.PublicKey{
+			N: new(big.Int).SetBytes(n),
+			E: int(new(big.Int).SetBytes(e).Int64()),
+		}
+	}
+	return keys, nil
+}
+
+func parseBoolClaim(raw json.RawMessage) bool {
+	var b bool
+	if json.Unmarshal(raw, &b) == nil {
+		return b
+	}
+	var s string
+	if json.Unmarshal(raw, &s) == nil {
+		return strings.EqualFold(s, "true")
+	}
+	return false
+}
+
+func getJSON(ctx context.Context, url string, out interface{}) error {
+	return getJSONWithToken(ctx, url, "", out)
+}
+
+func getJSONWithToken(ctx context.Context, url, bearer string, out interface{}) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return err
+	}
+	if bearer != "" {
+		req.Header.Set("Authorization", "Bearer "+bearer)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("GET %s: status %d", url, resp.StatusCode)
+	}
+	return json.NewDecoder(resp.Body).Decode(out)
+}
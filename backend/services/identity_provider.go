@@ -0,0 +1,101 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"movie-ticket-backend/config"
+	"sync"
+	"time"
+)
+
+var ErrIdentityProviderNotFound = errors.New("identity provider not found")
+
+// ExternalIdentity is the normalized profile every identity provider returns
+type ExternalIdentity struct {
+	Provider      string
+	Subject       string // Provider specific user ID (Google ID, OIDC "sub", local user ID)
+	Email         string
+	EmailVerified bool
+	Name          string
+	PictureURL    string
+}
+
+// IdentityProvider is a way of proving who a user is. Concrete providers also implement
+// RedirectIdentityProvider (browser based, e.g. Google / OIDC) or PasswordIdentityProvider (local accounts).
+type IdentityProvider interface {
+	Name() string
+}
+
+// RedirectIdentityProvider sends the browser to the provider and gets a code back on the callback
+type RedirectIdentityProvider interface {
+	IdentityProvider
+	AuthCodeURL(state string) string
+	Exchange(ctx context.Context, code string) (*ExternalIdentity, error)
+}
+
+// PasswordIdentityProvider checks credentials directly
+type PasswordIdentityProvider interface {
+	IdentityProvider
+	Authenticate(ctx context.Context, email, password string) (*ExternalIdentity, error)
+}
+
+var (
+	identityProviders   = make(map[string]IdentityProvider)
+	identityProvidersMu sync.RWMutex
+)
+
+// RegisterIdentityProvider makes a provider available under /api/auth/<name>/...
+func RegisterIdentityProvider(p IdentityProvider) {
+	identityProvidersMu.Lock()
+	defer identityProvidersMu.Unlock()
+	identityProviders[p.Name()] = p
+}
+
+func GetIdentityProvider(name string) (IdentityProvider, error) {
+	identityProvidersMu.RLock()
+	defer identityProvidersMu.RUnlock()
+	p, ok := identityProviders[name]
+	if !ok {
+		return nil, ErrIdentityProviderNotFound
+	}
+	return p, nil
+}
+
+// IdentityProviderNames lists enabled providers (for the login page)
+func IdentityProviderNames() []string {
+	identityProvidersMu.RLock()
+	defer identityProvidersMu.RUnlock()
+	names := make([]string, 0, len(identityProviders))
+	for name := range identityProviders {
+		names = append(names, name)
+	}
+	return names
+}
+
+// InitIdentityProviders registers every provider that is configured
+func InitIdentityProviders() {
+	cfg := config.AppConfig
+
+	if cfg.GoogleClientID != "" {
+		RegisterIdentityProvider(NewGoogleProvider(cfg.GoogleClientID, cfg.GoogleClientSecret, cfg.GoogleRedirectURL))
+	}
+
+	if cfg.OIDCIssuerURL != "" {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		provider, err := NewOIDCProvider(ctx, cfg.OIDCProviderName, cfg.OIDCIssuerURL, cfg.OIDCClientID, cfg.OIDCClientSecret, cfg.OIDCRedirectURL, cfg.OIDCScopes)
+		if err != nil {
+			log.Printf("Failed to set up OIDC provider %s: %v", cfg.OIDCIssuerURL, err)
+		} else {
+			RegisterIdentityProvider(provider)
+		}
+	}
+
+	if cfg.LocalAuthEnabled {
+		RegisterIdentityProvider(NewLocalProvider())
+	}
+
+	fmt.Printf("Identity providers enabled: %v\n", IdentityProviderNames())
+}
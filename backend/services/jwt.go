@@ -0,0 +1,178 @@
+package services
+
+import (
+	"crypto"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+var (
+	ErrTokenMalformed = errors.New("token is malformed")
+	ErrTokenSignature = errors.New("token signature is invalid")
+	ErrTokenExpired   = errors.New("token is expired")
+)
+
+// Audience accepts both the string and array forms of the "aud" claim
+type Audience []string
+
+func (a *Audience) UnmarshalJSON(data []byte) error {
+	var single string
+	if err := json.Unmarshal(data, &single); err == nil {
+		*a = Audience{single}
+		return nil
+	}
+	var many []string
+	if err := json.Unmarshal(data, &many); err != nil {
+		return err
+	}
+	*a = many
+	return nil
+}
+
+func (a Audience) Contains(value string) bool {
+	for _, v := range a {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+// RegisteredClaims are the standard JWT claims (RFC 7519)
+type RegisteredClaims struct {
+	Issuer    string   `json:"iss,omitempty"`
+	Subject   string   `json:"sub,omitempty"`
+	Audience  Audience `json:"aud,omitempty"`
+	ExpiresAt int64    `json:"exp,omitempty"`
+	NotBefore int64    `json:"nbf,omitempty"`
+	IssuedAt  int64    `json:"iat,omitempty"`
+	ID        string   `json:"jti,omitempty"`
+}
+
+// Valid checks the time based claims with a small clock skew allowance
+func (c RegisteredClaims) Valid(now time.Time) error {
+	const leeway = 30 * time.Second
+	if c.ExpiresAt == 0 || now.Add(-leeway).Unix() > c.ExpiresAt {
+		return ErrTokenExpired
+	}
+	if c.NotBefore != 0 && now.Add(leeway).Unix() < c.NotBefore {
+		return fmt.Errorf("token not valid yet")
+	}
+	return nil
+}
+
+type jwtHeader struct {
+	Alg string `json:"alg"`
+	Typ string `json:"typ,omitempty"`
+	Kid string `json:"kid,omitempty"`
+}
+
+// signJWT encodes claims and signs them with HS256 ([]byte key) or RS256 (*rsa.PrivateKey)
+func signJWT(alg, kid string, key interface{}, claims interface{}) (string, error) {
+	headerJSON, err := json.Marshal(jwtHeader{Alg: alg, Typ: "JWT", Kid: kid})
+	if err != nil {
+		return "", err
+	}
+	claimsJSON, err := json.Marshal(claims)
+	if err != nil {
+		return "", err
+	}
+
+	signingInput := base64.RawURLEncoding.EncodeToString(headerJSON) + "." + base64.RawURLEncoding.EncodeToString(claimsJSON)
+
+	var sig []byte
+	switch alg {
+	case "HS256":
+		secret, ok := key.([]byte)
+		if !ok {
+			return "", fmt.Errorf("HS256 requires a []byte secret")
+		}
+		mac := hmac.New(sha256.New, secret)
+		mac.Write([]byte(signingInput))
+		sig = mac.Sum(nil)
+	case "RS256":
+		privateKey, ok := key.(*rsa.PrivateKey)
+		if !ok {
+			return "", fmt.Errorf("RS256 requires an RSA private key")
+		}
+		digest := sha256.Sum256([]byte(signingInput))
+		sig, err = rsa.SignPKCS1v15(rand.Reader, privateKey, crypto.SHA256, digest[:])
+		if err != nil {
+			return "", err
+		}
+	default:
+		return "", fmt.Errorf("unsupported JWT algorithm %q", alg)
+	}
+
+	return signingInput + "." + base64.RawURLEncoding.EncodeToString(sig), nil
+}
+
+// parseJWT verifies the signature using the key returned by keyFunc and decodes the claims.
+// keyFunc receives the token header so callers can reject unexpected algorithms or pick a key by kid.
+func parseJWT(token string, keyFunc func(header jwtHeader) (interface{}, error), claims interface{}) error {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return ErrTokenMalformed
+	}
+
+	headerJSON, err := base64.RawURLEncoding.DecodeString(parts[0])
+	if err != nil {
+		return ErrTokenMalformed
+	}
+	var header jwtHeader
+	if err := json.Unmarshal(headerJSON, &header); err != nil {
+		return ErrTokenMalformed
+	}
+
+	sig, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return ErrTokenMalformed
+	}
+
+	key, err := keyFunc(header)
+	if err != nil {
+		return err
+	}
+
+	signingInput := parts[0] + "." + parts[1]
+	switch header.Alg {
+	case "HS256":
+		secret, ok := key.([]byte)
+		if !ok {
+			return ErrTokenSignature
+		}
+		mac := hmac.New(sha256.New, secret)
+		mac.Write([]byte(signingInput))
+		if !hmac.Equal(sig, mac.Sum(nil)) {
+			return ErrTokenSignature
+		}
+	case "RS256":
+		publicKey, ok := key.(*rsa.PublicKey)
+		if !ok {
+			return ErrTokenSignature
+		}
+		digest := sha256.Sum256([]byte(signingInput))
+		if err := rsa.VerifyPKCS1v15(publicKey, crypto.SHA256, digest[:], sig); err != nil {
+			return ErrTokenSignature
+		}
+	default:
+		return fmt.Errorf("unsupported JWT algorithm %q", header.Alg)
+	}
+
+	claimsJSON, err := base64.RawURLEncoding.DecodeString(parts[1])
+	if err != nil {
+		return ErrTokenMalformed
+	}
+	if err := json.Unmarshal(claimsJSON, claims); err != nil {
+		return ErrTokenMalformed
+	}
+	return nil
+}
@@ -3,14 +3,15 @@ package services
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"movie-ticket-backend/database"
-	"movie-ticket-backend/models"
+	"sort"
+	"strconv"
 	"strings"
 	"time"
 
 	"github.com/go-redis/redis/v8"
-	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 )
 
@@ -24,30 +25,94 @@ func NewLockService() *LockService {
 	}
 }
 
-// LockSeat uses MovieID + StartTime + SeatID for unique locking
-func (s *LockService) LockSeat(movieID, startTime, seatID, userID string, duration time.Duration) (bool, error) {
+// lockHolderKeySep joins the partner account and the API key in a lock holder
+const lockHolderKeySep = ":apikey:"
+
+// LockHolder is the owner recorded on seat and payment locks. Sessions hold locks as the
+// user; API-key callers hold them per key, because every kiosk and integration of a
+// partner signs in as the same account and must not share (or release) each other's locks.
+func LockHolder(userID, apiKeyID string) string {
+	if apiKeyID == "" {
+		return userID
+	}
+	return userID + lockHolderKeySep + apiKeyID
+}
+
+// LockHolderUserID returns the account behind a lock holder
+func LockHolderUserID(holder string) string {
+	userID, _, _ := strings.Cut(holder, lockHolderKeySep)
+	return userID
+}
+
+func seatLockKey(screeningID, seatID string) string {
+	return fmt.Sprintf("seat_lock:screening:%s:seat:%s", screeningID, seatID)
+}
+
+// seatLockIndexKey is a sorted set of a screening's locked seats scored by lock expiry (unix ms).
+// Counting members with a future score gives the locked count without scanning keys, and
+// stays right even if an expiry notification is missed.
+func seatLockIndexKey(screeningID string) string {
+	return fmt.Sprintf("seat_lock_index:screening:%s", screeningID)
+}
+
+func (s *LockService) indexLock(ctx context.Context, screeningID, seatID string, duration time.Duration) {
+	key := seatLockIndexKey(screeningID)
+	pipe := s.RDB.Pipeline()
+	pipe.ZAdd(ctx, key, &redis.Z{Score: float64(time.Now().Add(duration).UnixMilli()), Member: seatID})
+	pipe.ZRemRangeByScore(ctx, key, "-inf", strconv.FormatInt(time.Now().UnixMilli(), 10))
+	pipe.Expire(ctx, key, duration) // Locks all last the same time, so the newest one outlives the rest
+	pipe.Exec(ctx)
+}
+
+// LockSeat uses ScreeningID + SeatID for unique locking
+func (s *LockService) LockSeat(screeningID, seatID, holder string, duration time.Duration) (bool, error) {
 	ctx := context.Background()
-	key := fmt.Sprintf("seat_lock:movie:%s:time:%s:seat:%s", movieID, startTime, seatID)
+	key := seatLockKey(screeningID, seatID)
 
-	// Value is UserID to indicate who holds the lock
-	success, err := s.RDB.SetNX(ctx, key, userID, duration).Result()
+	// Value is the LockHolder to indicate who holds the lock
+	success, err := s.RDB.SetNX(ctx, key, holder, duration).Result()
 	if err != nil {
 		return false, err
 	}
+	if success {
+		s.indexLock(ctx, screeningID, seatID, duration)
+	}
 	return success, nil
 }
 
-// UnlockSeat uses MovieID + StartTime + SeatID
-func (s *LockService) UnlockSeat(movieID, startTime, seatID string) error {
+// UnlockSeat uses ScreeningID + SeatID
+func (s *LockService) UnlockSeat(screeningID, seatID string) error {
 	ctx := context.Background()
-	key := fmt.Sprintf("seat_lock:movie:%s:time:%s:seat:%s", movieID, startTime, seatID)
-	return s.RDB.Del(ctx, key).Err()
+	s.RDB.ZRem(ctx, seatLockIndexKey(screeningID), seatID)
+	return s.RDB.Del(ctx, seatLockKey(screeningID, seatID)).Err()
+}
+
+// LockedCounts returns how many seats are locked right now in each of the screenings
+func (s *LockService) LockedCounts(screeningIDs []string) (map[string]int, error) {
+	ctx := context.Background()
+	counts := make(map[string]int, len(screeningIDs))
+	if len(screeningIDs) == 0 {
+		return counts, nil
+	}
+	now := "(" + strconv.FormatInt(time.Now().UnixMilli(), 10)
+	pipe := s.RDB.Pipeline()
+	cmds := make([]*redis.IntCmd, len(screeningIDs))
+	for i, id := range screeningIDs {
+		cmds[i] = pipe.ZCount(ctx, seatLockIndexKey(id), now, "+inf")
+	}
+	if _, err := pipe.Exec(ctx); err != nil && err != redis.Nil {
+		return nil, err
+	}
+	for i, id := range screeningIDs {
+		counts[id] = int(cmds[i].Val())
+	}
+	return counts, nil
 }
 
-// ExtendSeatLock uses MovieID + StartTime + SeatID
-func (s *LockService) ExtendSeatLock(movieID, startTime, seatID, userID string, duration time.Duration) (bool, error) {
+// ExtendSeatLock uses ScreeningID + SeatID
+func (s *LockService) ExtendSeatLock(screeningID, seatID, holder string, duration time.Duration) (bool, error) {
 	ctx := context.Background()
-	key := fmt.Sprintf("seat_lock:movie:%s:time:%s:seat:%s", movieID, startTime, seatID)
+	key := seatLockKey(screeningID, seatID)
 
 	// Check ownership first
 	val, err := s.RDB.Get(ctx, key).Result()
@@ -57,20 +122,23 @@ func (s *LockService) ExtendSeatLock(movieID, startTime, seatID, userID string,
 	if err != nil {
 		return false, err
 	}
-	if val != userID {
+	if val != holder {
 		return false, nil // Locked by someone else
 	}
 
 	// Extend TTL
-	return s.RDB.Expire(ctx, key, duration).Result()
+	extended, err := s.RDB.Expire(ctx, key, duration).Result()
+	if extended {
+		s.indexLock(ctx, screeningID, seatID, duration)
+	}
+	return extended, err
 }
 
-// IsSeatLocked uses MovieID + StartTime + SeatID
-func (s *LockService) IsSeatLocked(movieID, startTime, seatID string) (bool, string) {
+// IsSeatLocked uses ScreeningID + SeatID
+func (s *LockService) IsSeatLocked(screeningID, seatID string) (bool, string) {
 	ctx := context.Background()
-	key := fmt.Sprintf("seat_lock:movie:%s:time:%s:seat:%s", movieID, startTime, seatID)
 
-	val, err := s.RDB.Get(ctx, key).Result()
+	val, err := s.RDB.Get(ctx, seatLockKey(screeningID, seatID)).Result()
 	if err == redis.Nil {
 		return false, ""
 	}
@@ -80,20 +148,91 @@ func (s *LockService) IsSeatLocked(movieID, startTime, seatID string) (bool, str
 	return true, val
 }
 
-// --- User Payment Lock ---
+// --- Hall Lock (per hall schedule) ---
+
+// ErrHallBusy is returned when another request keeps a hall's schedule locked past hallLockWait
+var ErrHallBusy = errors.New("hall schedule is being changed by another request")
+
+const (
+	hallLockTTL  = 15 * time.Second // Frees the hall if the holder dies between check and write
+	hallLockWait = 3 * time.Second
+)
+
+// releaseHallLock deletes the lock only if it still carries our token, so a holder that
+// outlived hallLockTTL cannot release the next holder's lock
+var releaseHallLock = redis.NewScript(`
+if redis.call("GET", KEYS[1]) == ARGV[1] then
+	return redis.call("DEL", KEYS[1])
+end
+return 0`)
+
+func hallLockKey(hallID primitive.ObjectID) string {
+	return fmt.Sprintf("hall_lock:%s", hallID.Hex())
+}
+
+// LockHalls serialises schedule changes per hall: the overlap check and the write that follows
+// it must run under the lock, or two requests can both pass the check and double-book the hall.
+// Halls are locked in ID order so requests touching several halls cannot deadlock.
+// The returned function releases every lock taken.
+func (s *LockService) LockHalls(ctx context.Context, hallIDs ...primitive.ObjectID) (func(), error) {
+	keys := make([]string, 0, len(hallIDs))
+	seen := make(map[primitive.ObjectID]bool)
+	for _, id := range hallIDs {
+		if id.IsZero() || seen[id] {
+			continue
+		}
+		seen[id] = true
+		keys = append(keys, hallLockKey(id))
+	}
+	sort.Strings(keys)
+
+	token, err := randomToken(16)
+	if err != nil {
+		return nil, err
+	}
+	var held []string
+	unlock := func() {
+		for _, key := range held {
+			releaseHallLock.Run(context.Background(), s.RDB, []string{key}, token)
+		}
+	}
+	deadline := time.Now().Add(hallLockWait)
+	for _, key := range keys {
+		for {
+			ok, err := s.RDB.SetNX(ctx, key, token, hallLockTTL).Result()
+			if err != nil {
+				unlock()
+				return nil, err
+			}
+			if ok {
+				held = append(held, key)
+				break
+			}
+			if time.Now().After(deadline) {
+				unlock()
+				return nil, ErrHallBusy
+			}
+			time.Sleep(50 * time.Millisecond)
+		}
+	}
+	return unlock, nil
+}
+
+// --- Payment Lock (per LockHolder) ---
 
 type PaymentLockDetails struct {
-	UserID      string   `json:"user_id"`
-	MovieID     string   `json:"movie_id"`
-	ScreeningID string   `json:"screening_id"`
-	StartTime   string   `json:"start_time"`
-	SeatIDs     []string `json:"seat_ids"`
+	UserID      string          `json:"user_id"`
+	MovieID     string          `json:"movie_id"`
+	ScreeningID string          `json:"screening_id"`
+	StartTime   string          `json:"start_time"`
+	SeatIDs     []string        `json:"seat_ids"`
+	Quote       *PriceBreakdown `json:"quote,omitempty"` // Price frozen when payment started; ProcessBooking charges this
 }
 
-func (s *LockService) SetPaymentLock(userID string, details PaymentLockDetails, duration time.Duration) error {
+func (s *LockService) SetPaymentLock(holder string, details PaymentLockDetails, duration time.Duration) error {
 	ctx := context.Background()
-	key := fmt.Sprintf("payment_lock:%s", userID)
-	dataKey := fmt.Sprintf("payment_data:%s", userID)
+	key := fmt.Sprintf("payment_lock:%s", holder)
+	dataKey := fmt.Sprintf("payment_data:%s", holder)
 
 	val, err := json.Marshal(details)
 	if err != nil {
@@ -108,25 +247,25 @@ func (s *LockService) SetPaymentLock(userID string, details PaymentLockDetails,
 	return s.RDB.Set(ctx, dataKey, val, duration+5*time.Minute).Err()
 }
 
-func (s *LockService) ReleasePaymentLock(userID string) error {
+func (s *LockService) ReleasePaymentLock(holder string) error {
 	ctx := context.Background()
-	key := fmt.Sprintf("payment_lock:%s", userID)
-	dataKey := fmt.Sprintf("payment_data:%s", userID)
+	key := fmt.Sprintf("payment_lock:%s", holder)
+	dataKey := fmt.Sprintf("payment_data:%s", holder)
 
 	s.RDB.Del(ctx, dataKey)
 	return s.RDB.Del(ctx, key).Err()
 }
 
-func (s *LockService) HasPaymentLock(userID string) bool {
+func (s *LockService) HasPaymentLock(holder string) bool {
 	ctx := context.Background()
-	key := fmt.Sprintf("payment_lock:%s", userID)
+	key := fmt.Sprintf("payment_lock:%s", holder)
 	count, _ := s.RDB.Exists(ctx, key).Result()
 	return count > 0
 }
 
-func (s *LockService) GetPaymentLock(userID string) (*PaymentLockDetails, error) {
+func (s *LockService) GetPaymentLock(holder string) (*PaymentLockDetails, error) {
 	ctx := context.Background()
-	key := fmt.Sprintf("payment_lock:%s", userID)
+	key := fmt.Sprintf("payment_lock:%s", holder)
 	val, err := s.RDB.Get(ctx, key).Result()
 	if err == redis.Nil {
 		return nil, nil
@@ -142,12 +281,12 @@ func (s *LockService) GetPaymentLock(userID string) (*PaymentLockDetails, error)
 	return &details, nil
 }
 
-// GetLockedSeats matches pattern for specific movie & time
-func (s *LockService) GetLockedSeats(movieID, startTime string) (map[string]string, error) {
+// GetLockedSeats matches pattern for a specific screening
+func (s *LockService) GetLockedSeats(screeningID string) (map[string]string, error) {
 	ctx := context.Background()
-	pattern := fmt.Sprintf("seat_lock:movie:%s:time:%s:seat:*", movieID, startTime)
+	prefix := seatLockKey(screeningID, "")
 
-	keys, err := s.RDB.Keys(ctx, pattern).Result()
+	keys, err := s.RDB.Keys(ctx, prefix+"*").Result()
 	if err != nil {
 		return nil, err
 	}
@@ -163,14 +302,9 @@ func (s *LockService) GetLockedSeats(movieID, startTime string) (map[string]stri
 	}
 
 	for i, key := range keys {
-		// key: seat_lock:movie:<ID>:time:<Time>:seat:<SeatID>
-		// Robust parsing tailored to this specific format
-		prefix := fmt.Sprintf("seat_lock:movie:%s:time:%s:seat:", movieID, startTime)
-		if strings.HasPrefix(key, prefix) {
-			seatID := strings.TrimPrefix(key, prefix)
-			if val, ok := values[i].(string); ok {
-				lockedSeats[seatID] = val
-			}
+		seatID := strings.TrimPrefix(key, prefix)
+		if val, ok := values[i].(string); ok {
+			lockedSeats[seatID] = val
 		}
 	}
 
@@ -188,59 +322,44 @@ func (s *LockService) ListenForExpireRedis() {
 	for msg := range ch {
 		key := msg.Payload
 
-		// Key: seat_lock:movie:%s:time:%s:seat:%s
-		if strings.HasPrefix(key, "seat_lock:movie:") {
-			parts := strings.Split(key, ":")
-			// seat_lock:movie:<ID>:time:<Time>:seat:<SeatID> -> 7 parts
-			// 0: seat_lock, 1: movie, 2: <ID>, 3: time, 4: <Time>, 5: seat, 6: <SeatID>
-			if len(parts) >= 7 {
-				movieID := parts[2]
-				// Time might contain colons (e.g. 2024-12-31T20:00:00Z)
-				// So we take everything between 'time' and 'seat'
-				// Use substring logic for safer parsing with variable separators
-				// Pattern: seat_lock:movie:<MID>:time:<TIME>:seat:<SID>
-				// Find first "time:" and last ":seat:"
-
-				// Re-parsing strategy:
-				// 1. Remove prefix "seat_lock:movie:"
-				// 2. Find next ":time:"
-				// 3. Find last ":seat:"
-
-				remainder := strings.TrimPrefix(key, "seat_lock:movie:")
-				timeSplit := strings.Index(remainder, ":time:")
-				seatSplit := strings.LastIndex(remainder, ":seat:")
-
-				if timeSplit != -1 && seatSplit != -1 && seatSplit > timeSplit {
-					movieID = remainder[:timeSplit]
-					startTime := remainder[timeSplit+6 : seatSplit]
-					seatID := remainder[seatSplit+6:]
-
-					// Resolve ScreeningID for WS Broadcast
-					screeningID, err := s.getScreeningID(movieID, startTime)
-					if err != nil {
-						fmt.Printf("Failed to resolve screening ID for expired key %s: %v\n", key, err)
-						continue
-					}
-
-					fmt.Printf("Key Expired! Movie: %s, Seat: %s. Broadcasting unlock...\n", movieID, seatID)
-
-					LogInfo("SEAT_RELEASED", "SYSTEM", map[string]interface{}{
-						"movie_id":  movieID,
-						"seat_id":   seatID,
-						"reason":    "expired",
-						"screen_id": screeningID, // Log Internal ID too
-					})
+		// Key: seat_lock:screening:<ScreeningID>:seat:<SeatID>
+		if strings.HasPrefix(key, "seat_lock:screening:") {
+			remainder := strings.TrimPrefix(key, "seat_lock:screening:")
+			seatSplit := strings.Index(remainder, ":seat:")
+			if seatSplit == -1 {
+				continue
+			}
+			screeningID := remainder[:seatSplit]
+			seatID := remainder[seatSplit+6:]
+
+			// Load the screening so the broadcast carries movie_id/start_time for the frontend
+			screening, err := NewScreeningService().GetByID(ctx, screeningID)
+			if err != nil {
+				fmt.Printf("Failed to resolve screening for expired key %s: %v\n", key, err)
+				continue
+			}
 
-					WSHub.Broadcast <- SeatUpdateMessage{
-						ScreeningID: screeningID,
-						SeatID:      seatID,
-						Status:      "AVAILABLE",
-					}
-				}
+			fmt.Printf("Key Expired! Screening: %s, Seat: %s. Broadcasting unlock...\n", screeningID, seatID)
+
+			LogInfo("SEAT_RELEASED", "SYSTEM", map[string]interface{}{
+				"movie_id":  screening.MovieID.Hex(),
+				"seat_id":   seatID,
+				"reason":    "expired",
+				"screen_id": screeningID,
+			})
+
+			WSHub.Broadcast <- SeatUpdateMessage{
+				ScreeningID: screeningID,
+				MovieID:     screening.MovieID.Hex(),
+				StartTime:   screening.StartTime.Format(time.RFC3339Nano),
+				SeatID:      seatID,
+				Status:      "AVAILABLE",
 			}
+			PublishSeatCounts(screeningID)
 		} else if strings.HasPrefix(key, "payment_lock:") {
-			userID := strings.TrimPrefix(key, "payment_lock:")
-			dataKey := fmt.Sprintf("payment_data:%s", userID)
+			holder := strings.TrimPrefix(key, "payment_lock:")
+			userID := LockHolderUserID(holder)
+			dataKey := fmt.Sprintf("payment_data:%s", holder)
 			val, err := s.RDB.Get(ctx, dataKey).Result()
 			if err == nil {
 				var details PaymentLockDetails
@@ -258,33 +377,3 @@ func (s *LockService) ListenForExpireRedis() {
 		}
 	}
 }
-
-// Internal Helper to find Screening ID from MovieID + StartTime
-func (s *LockService) getScreeningID(movieIDHex, startTimeStr string) (string, error) {
-	movieObjID, err := primitive.ObjectIDFromHex(movieIDHex)
-	if err != nil {
-		return "", fmt.Errorf("invalid Movie ID")
-	}
-
-	// Try parsing standard time formats
-	reqTime, err := time.Parse(time.RFC3339, startTimeStr)
-	if err != nil {
-		// Try fallback if stored differently in key vs logic, but usually we stick to RFC3339
-		return "", fmt.Errorf("invalid Start Time")
-	}
-
-	collection := database.Mongo.Collection("movies")
-	var movie models.Movie
-	err = collection.FindOne(context.TODO(), bson.M{"_id": movieObjID}).Decode(&movie)
-	if err != nil {
-		return "", fmt.Errorf("movie not found")
-	}
-
-	for _, sc := range movie.Screenings {
-		// Compare time equality or string match
-		if sc.StartTime.Equal(reqTime) || sc.StartTime.Format(time.RFC3339) == startTimeStr {
-			return sc.ID, nil
-		}
-	}
-	return "", fmt.Errorf("screening not found")
-}
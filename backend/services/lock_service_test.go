@@ -0,0 +1,27 @@
+package services
+
+import "testing"
+
+func TestLockHolder(t *testing.T) {
+	tests := []struct {
+		name     string
+		userID   string
+		apiKeyID string
+		want     string
+	}{
+		{"session", "u1", "", "u1"},
+		{"api key", "u1", "k1", "u1:apikey:k1"},
+		{"other key of the same partner", "u1", "k2", "u1:apikey:k2"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			holder := LockHolder(tt.userID, tt.apiKeyID)
+			if holder != tt.want {
+				t.Fatalf("LockHolder(%q, %q) = %q, want %q", tt.userID, tt.apiKeyID, holder, tt.want)
+			}
+			if got := LockHolderUserID(holder); got != tt.userID {
+				t.Fatalf("LockHolderUserID(%q) = %q, want %q", holder, got, tt.userID)
+			}
+		})
+	}
+}
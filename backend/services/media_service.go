@@ -0,0 +1,215 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"image"
+	"image/jpeg"
+	_ "image/png" // Register decoders for image.Decode
+	"io"
+	"movie-ticket-backend/config"
+	"movie-ticket-backend/database"
+	"movie-ticket-backend/models"
+	"net/http"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"golang.org/x/image/draw"
+	_ "golang.org/x/image/webp"
+)
+
+var (
+	ErrMediaNotFound     = errors.New("media not found")
+	ErrMediaTooLarge     = errors.New("file is too large")
+	ErrUnsupportedImage  = errors.New("only JPEG, PNG and WebP images are accepted")
+	ErrImageDimensions   = errors.New("image must be between 100 and 8000 pixels on each side")
+	ErrMediaKindMismatch = errors.New("media is not of the expected kind")
+)
+
+const (
+	minImageSide       = 100
+	maxImageSide       = 8000 // Also keeps decoding memory bounded
+	variantJPEGQuality = 85
+)
+
+// mediaSizes are the resized copies made for each kind; height follows the aspect ratio
+var mediaSizes = map[models.MediaKind][]struct {
+	Name  string
+	Width int
+}{
+	models.MediaPoster:   {{"thumb", 185}, {"medium", 500}},
+	models.MediaBackdrop: {{"thumb", 300}, {"large", 1280}},
+}
+
+// allowedImageTypes maps sniffed content types to the extension of the stored original
+var allowedImageTypes = map[string]string{"image/jpeg": "jpg", "image/png": "png", "image/webp": "webp"}
+
+type MediaService struct{}
+
+func NewMediaService() *MediaService {
+	return &MediaService{}
+}
+
+func mediaCollection() *mongo.Collection {
+	return database.Mongo.Collection("media")
+}
+
+// MediaURL is the public address of a media variant, served by GET /media/:id/:variant
+func MediaURL(mediaID primitive.ObjectID, variant string) string {
+	return fmt.Sprintf("%s/media/%s/%s", config.AppConfig.PublicAPIURL, mediaID.Hex(), variant)
+}
+
+// Upload validates an image, stores the original plus its resized variants and records it.
+// The type is sniffed from the content; the client's Content-Type and file name are not trusted.
+func (s *MediaService) Upload(ctx context.Context, kind models.MediaKind, filename string, r io.Reader, uploadedBy string) (*models.Media, error) {
+	maxBytes := config.AppConfig.MediaMaxUploadBytes
+	data, err := io.ReadAll(io.LimitReader(r, maxBytes+1))
+	if err != nil {
+		return nil, err
+	}
+	if int64(len(data)) > maxBytes {
+		return nil, ErrMediaTooLarge
+	}
+	contentType := http.DetectContentType(data)
+	ext, ok := allowedImageTypes[contentType]
+	if !ok {
+		return nil, ErrUnsupportedImage
+	}
+	cfg, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return nil, ErrUnsupportedImage
+	}
+	if cfg.Width < minImageSide || cfg.Height < minImageSide || cfg.Width > maxImageSide || cfg.Height > maxImageSide {
+		return nil, ErrImageDimensions
+	}
+	img, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, ErrUnsupportedImage
+	}
+
+	media := &models.Media{
+		ID:          primitive.NewObjectID(),
+		Kind:        kind,
+		Filename:    filename,
+		ContentType: contentType,
+		Size:        int64(len(data)),
+		Width:       cfg.Width,
+		Height:      cfg.Height,
+		UploadedBy:  uploadedBy,
+		CreatedAt:   time.Now(),
+	}
+	prefix := "media/" + media.ID.Hex() + "/"
+
+	original := models.MediaVariant{Name: "original", Key: prefix + "original." + ext, ContentType: contentType, Width: cfg.Width, Height: cfg.Height, Size: int64(len(data))}
+	if err := MediaStorage.Put(ctx, original.Key, bytes.NewReader(data), contentType); err != nil {
+		return nil, err
+	}
+	media.Variants = append(media.Variants, original)
+
+	for _, size := range mediaSizes[kind] {
+		variant, err := storeResized(ctx, img, prefix+size.Name+".jpg", size.Width)
+		if err != nil {
+			s.deleteFiles(ctx, media)
+			return nil, err
+		}
+		variant.Name = size.Name
+		media.Variants = append(media.Variants, *variant)
+	}
+
+	if _, err := mediaCollection().InsertOne(ctx, media); err != nil {
+		s.deleteFiles(ctx, media)
+		return nil, err
+	}
+	withMediaURLs(media)
+	return media, nil
+}
+
+// storeResized scales the image down to width (never up) and stores it as JPEG.
+// Transparent areas are flattened onto white since JPEG has no alpha channel.
+func storeResized(ctx context.Context, img image.Image, key string, width int) (*models.MediaVariant, error) {
+	bounds := img.Bounds()
+	if width > bounds.Dx() {
+		width = bounds.Dx()
+	}
+	height := (bounds.Dy()*width + bounds.Dx()/2) / bounds.Dx()
+
+	dst := image.NewRGBA(image.Rect(0, 0, width, height))
+	draw.Draw(dst, dst.Bounds(), image.White, image.Point{}, draw.Src)
+	draw.CatmullRom.Scale(dst, dst.Bounds(), img, bounds, draw.Over, nil)
+
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, dst, &jpeg.Options{Quality: variantJPEGQuality}); err != nil {
+		return nil, err
+	}
+	size := int64(buf.Len())
+	if err := MediaStorage.Put(ctx, key, &buf, "image/jpeg"); err != nil {
+		return nil, err
+	}
+	return &models.MediaVariant{Key: key, ContentType: "image/jpeg", Width: width, Height: height, Size: size}, nil
+}
+
+func (s *MediaService) deleteFiles(ctx context.Context, media *models.Media) {
+	for _, v := range media.Variants {
+		MediaStorage.Delete(ctx, v.Key)
+	}
+}
+
+func withMediaURLs(media *models.Media) {
+	for i := range media.Variants {
+		media.Variants[i].URL = MediaURL(media.ID, media.Variants[i].Name)
+	}
+}
+
+// Get loads a media record by its hex ID
+func (s *MediaService) Get(ctx context.Context, mediaIDHex string) (*models.Media, error) {
+	objID, err := primitive.ObjectIDFromHex(mediaIDHex)
+	if err != nil {
+		return nil, ErrMediaNotFound
+	}
+	var media models.Media
+	err = mediaCollection().FindOne(ctx, bson.M{"_id": objID}).Decode(&media)
+	if err == mongo.ErrNoDocuments {
+		return nil, ErrMediaNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	withMediaURLs(&media)
+	return &media, nil
+}
+
+// GetOfKind loads a media record and checks it is of the given kind (e.g. a poster for PosterMediaID)
+func (s *MediaService) GetOfKind(ctx context.Context, mediaIDHex string, kind models.MediaKind) (*models.Media, error) {
+	media, err := s.Get(ctx, mediaIDHex)
+	if err != nil {
+		return nil, err
+	}
+	if media.Kind != kind {
+		return nil, ErrMediaKindMismatch
+	}
+	return media, nil
+}
+
+// Open returns a reader for one variant of a media item
+func (s *MediaService) Open(ctx context.Context, mediaIDHex, variantName string) (io.ReadCloser, *models.MediaVariant, error) {
+	media, err := s.Get(ctx, mediaIDHex)
+	if err != nil {
+		return nil, nil, err
+	}
+	variant, ok := media.Variant(variantName)
+	if !ok {
+		return nil, nil, ErrMediaNotFound
+	}
+	rc, err := MediaStorage.Get(ctx, variant.Key)
+	if err == ErrObjectNotFound {
+		return nil, nil, ErrMediaNotFound
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+	return rc, &variant, nil
+}
@@ -119,6 +119,10 @@ func handleMQMessage(msg *sarama.ConsumerMessage) {
 		log.Println("MQ [WARN] Received legacy BOOKING_SUCCESS event, ignoring in favor of GROUP.")
 	case "AUDIT_LOG":
 		saveAuditToMongo(event.Payload)
+	case "SCREENING_RESCHEDULED":
+		triggerRescheduleNotification(event.Payload)
+	case "SCREENING_CANCELLED":
+		triggerCancellationNotification(event.Payload)
 	default:
 		log.Printf("MQ [IGNORED]: Unknown event type: %s", event.Type)
 	}
@@ -159,7 +163,10 @@ func triggerGroupNotification(payload interface{}) {
 
 	// 3. ดึงข้อมูลหนัง (เพื่อเอาชื่อหนัง)
 	var movie models.Movie
-	err = database.Mongo.Collection("movies").FindOne(context.TODO(), bson.M{"screenings.id": firstBooking.ScreeningID}).Decode(&movie)
+	screening, err := NewScreeningService().GetByID(context.TODO(), firstBooking.ScreeningID)
+	if err == nil {
+		err = database.Mongo.Collection("movies").FindOne(context.TODO(), bson.M{"_id": screening.MovieID}).Decode(&movie)
+	}
 	if err != nil {
 		log.Printf("MQ [EMAIL WARN]: Movie not found for Screening ID %s", firstBooking.ScreeningID)
 		movie.Title = "Unknown Movie"
@@ -169,6 +176,111 @@ func triggerGroupNotification(payload interface{}) {
 	GetEmailService().SendGroupTicketEmail(user, bookings, movie.Title)
 }
 
+// triggerRescheduleNotification ส่งเมลแจ้งลูกค้าทุกคนที่มีตั๋วของรอบฉายที่ถูกเลื่อนเวลา (1 เมลต่อ 1 User)
+func triggerRescheduleNotification(payload interface{}) {
+	if database.Mongo == nil {
+		return
+	}
+
+	data, err := json.Marshal(payload)
+	if err != nil {
+		log.Printf("MQ [EMAIL ERROR]: Failed to marshal reschedule payload: %v", err)
+		return
+	}
+	var event ScreeningRescheduledEvent
+	if err := json.Unmarshal(data, &event); err != nil {
+		log.Printf("MQ [EMAIL ERROR]: Failed to unmarshal to ScreeningRescheduledEvent: %v", err)
+		return
+	}
+
+	ctx := context.TODO()
+	cursor, err := database.Mongo.Collection("bookings").Find(ctx, bson.M{"screening_id": event.ScreeningID, "status": models.BookingStatusSuccess})
+	if err != nil {
+		log.Printf("MQ [EMAIL ERROR]: Failed to load bookings for screening %s: %v", event.ScreeningID, err)
+		return
+	}
+	var bookings []models.Booking
+	if err := cursor.All(ctx, &bookings); err != nil {
+		log.Printf("MQ [EMAIL ERROR]: Failed to decode bookings: %v", err)
+		return
+	}
+
+	movieTitle := "Unknown Movie"
+	movieObjID, _ := primitive.ObjectIDFromHex(event.MovieID)
+	var movie models.Movie
+	if err := database.Mongo.Collection("movies").FindOne(ctx, bson.M{"_id": movieObjID}).Decode(&movie); err == nil {
+		movieTitle = movie.Title
+	}
+
+	byUser := make(map[string][]models.Booking)
+	for _, b := range bookings {
+		byUser[b.UserID] = append(byUser[b.UserID], b)
+	}
+	for userID, userBookings := range byUser {
+		user, err := NewUserService().GetUserByID(ctx, userID)
+		if err != nil || user.IsDeleted() {
+			continue
+		}
+		GetEmailService().SendScheduleChangeEmail(*user, userBookings, movieTitle,
+			event.OldStartTime.Format(time.RFC1123), event.NewStartTime.Format(time.RFC1123))
+	}
+}
+
+// triggerCancellationNotification ส่งเมลแจ้งลูกค้าที่ได้รับเงินคืนจากรอบฉายที่ถูกยกเลิก (1 เมลต่อ 1 User)
+func triggerCancellationNotification(payload interface{}) {
+	if database.Mongo == nil {
+		return
+	}
+
+	data, err := json.Marshal(payload)
+	if err != nil {
+		log.Printf("MQ [EMAIL ERROR]: Failed to marshal cancellation payload: %v", err)
+		return
+	}
+	var event ScreeningCancelledEvent
+	if err := json.Unmarshal(data, &event); err != nil {
+		log.Printf("MQ [EMAIL ERROR]: Failed to unmarshal to ScreeningCancelledEvent: %v", err)
+		return
+	}
+
+	ids := make([]primitive.ObjectID, 0, len(event.BookingIDs))
+	for _, id := range event.BookingIDs {
+		if objID, err := primitive.ObjectIDFromHex(id); err == nil {
+			ids = append(ids, objID)
+		}
+	}
+	ctx := context.TODO()
+	cursor, err := database.Mongo.Collection("bookings").Find(ctx, bson.M{"_id": bson.M{"$in": ids}})
+	if err != nil {
+		log.Printf("MQ [EMAIL ERROR]: Failed to load bookings for screening %s: %v", event.ScreeningID, err)
+		return
+	}
+	var bookings []models.Booking
+	if err := cursor.All(ctx, &bookings); err != nil {
+		log.Printf("MQ [EMAIL ERROR]: Failed to decode bookings: %v", err)
+		return
+	}
+
+	movieTitle := "Unknown Movie"
+	movieObjID, _ := primitive.ObjectIDFromHex(event.MovieID)
+	var movie models.Movie
+	if err := database.Mongo.Collection("movies").FindOne(ctx, bson.M{"_id": movieObjID}).Decode(&movie); err == nil {
+		movieTitle = movie.Title
+	}
+
+	byUser := make(map[string][]models.Booking)
+	for _, b := range bookings {
+		byUser[b.UserID] = append(byUser[b.UserID], b)
+	}
+	for userID, userBookings := range byUser {
+		user, err := NewUserService().GetUserByID(ctx, userID)
+		if err != nil || user.IsDeleted() {
+			continue
+		}
+		GetEmailService().SendCancellationEmail(*user, userBookings, movieTitle, event.StartTime.Format(time.RFC1123), event.Reason)
+	}
+}
+
 // saveAuditToMongo บันทึกข้อมูลลง Audit Log ใน MongoDB
 func saveAuditToMongo(payload interface{}) {
 	if database.Mongo == nil {
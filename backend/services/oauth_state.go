@@ -0,0 +1,115 @@
+package services
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"log"
+	"movie-ticket-backend/config"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+const oauthStateTTL = 10 * time.Minute
+
+var ErrInvalidOAuthState = errors.New("oauth state is invalid or expired")
+
+// OAuthState is the signed payload passed through the identity provider as "state".
+// Nonce must match the oauth_nonce cookie set on the browser that started the login.
+type OAuthState struct {
+	RegisteredClaims
+	Provider   string `json:"provider"`
+	Nonce      string `json:"nonce"`
+	RedirectTo string `json:"redirect_to"`
+}
+
+var (
+	stateSecret     []byte
+	stateSecretOnce sync.Once
+)
+
+func getStateSecret() []byte {
+	stateSecretOnce.Do(func() {
+		if config.AppConfig.OAuthStateSecret != "" {
+			stateSecret = []byte(config.AppConfig.OAuthStateSecret)
+			return
+		}
+		// Dev fallback: logins started before a restart will fail
+		log.Println("WARNING: OAUTH_STATE_SECRET is not set, generating a random secret for this process")
+		stateSecret = make([]byte, 32)
+		rand.Read(stateSecret)
+	})
+	return stateSecret
+}
+
+// NewOAuthState returns the signed state and the nonce the caller must store in a cookie
+func NewOAuthState(provider, redirectTo string) (state string, nonce string, err error) {
+	nonce, err = randomToken(24)
+	if err != nil {
+		return "", "", err
+	}
+
+	now := time.Now()
+	claims := OAuthState{
+		RegisteredClaims: RegisteredClaims{
+			IssuedAt:  now.Unix(),
+			ExpiresAt: now.Add(oauthStateTTL).Unix(),
+		},
+		Provider:   provider,
+		Nonce:      nonce,
+		RedirectTo: redirectTo,
+	}
+
+	state, err = signJWT("HS256", "", getStateSecret(), claims)
+	if err != nil {
+		return "", "", err
+	}
+	return state, nonce, nil
+}
+
+// VerifyOAuthState checks signature, expiry and that the nonce matches the cookie value
+func VerifyOAuthState(state, cookieNonce string) (*OAuthState, error) {
+	var claims OAuthState
+	err := parseJWT(state, func(header jwtHeader) (interface{}, error) {
+		if header.Alg != "HS256" {
+			return nil, ErrInvalidOAuthState
+		}
+		return getStateSecret(), nil
+	}, &claims)
+	if err != nil {
+		return nil, ErrInvalidOAuthState
+	}
+	if err := claims.Valid(time.Now()); err != nil {
+		return nil, ErrInvalidOAuthState
+	}
+	if cookieNonce == "" || subtle.ConstantTimeCompare([]byte(cookieNonce), []byte(claims.Nonce)) != 1 {
+		return nil, ErrInvalidOAuthState
+	}
+	return &claims, nil
+}
+
+// IsAllowedRedirect reports whether the URL's origin (scheme://host) is in ALLOWED_REDIRECT_ORIGINS
+func IsAllowedRedirect(rawURL string) bool {
+	parsed, err := url.Parse(rawURL)
+	if err != nil || parsed.Scheme == "" || parsed.Host == "" {
+		return false
+	}
+	origin := strings.ToLower(parsed.Scheme + "://" + parsed.Host)
+	for _, allowed := range config.AppConfig.AllowedRedirectOrigins {
+		if strings.TrimRight(strings.ToLower(strings.TrimSpace(allowed)), "/") == origin {
+			return true
+		}
+	}
+	return false
+}
+
+func randomToken(size int) (string, error) {
+	buf := make([]byte, size)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(buf), nil
+}
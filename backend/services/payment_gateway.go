@@ -0,0 +1,60 @@
+package services
+
+import (
+	"context"
+	"log"
+	"movie-ticket-backend/config"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// RefundRequest returns money taken by a payment. IdempotencyKey makes retries safe:
+// a gateway must not refund the same key twice.
+type RefundRequest struct {
+	PaymentID      string
+	Amount         float64
+	Currency       string
+	Reason         string
+	IdempotencyKey string
+}
+
+type Refund struct {
+	ID        string
+	PaymentID string
+	Amount    float64
+	CreatedAt time.Time
+}
+
+// PaymentGateway is the payment provider. Payments are still confirmed by the client (mock),
+// so refunds are the only operation so far.
+type PaymentGateway interface {
+	Refund(ctx context.Context, req RefundRequest) (*Refund, error)
+}
+
+var Payments PaymentGateway
+
+// InitPaymentGateway selects the provider from PAYMENT_GATEWAY
+func InitPaymentGateway() {
+	switch config.AppConfig.PaymentGateway {
+	case "", "mock":
+		Payments = &MockPaymentGateway{}
+	default:
+		log.Printf("Unknown PAYMENT_GATEWAY %q, falling back to the mock gateway", config.AppConfig.PaymentGateway)
+		Payments = &MockPaymentGateway{}
+	}
+}
+
+// MockPaymentGateway accepts every refund and only logs it
+type MockPaymentGateway struct{}
+
+func (g *MockPaymentGateway) Refund(ctx context.Context, req RefundRequest) (*Refund, error) {
+	refund := &Refund{
+		ID:        "mock_refund_" + primitive.NewObjectID().Hex(),
+		PaymentID: req.PaymentID,
+		Amount:    req.Amount,
+		CreatedAt: time.Now(),
+	}
+	log.Printf("[PAYMENT MOCK] Refunded %.2f %s of payment %q (%s) as %s", req.Amount, req.Currency, req.PaymentID, req.Reason, refund.ID)
+	return refund, nil
+}
@@ -0,0 +1,374 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"movie-ticket-backend/database"
+	"movie-ticket-backend/models"
+	"sort"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const Currency = "THB"
+
+var (
+	ErrSeatNotPriced       = errors.New("seat has no price for this screening")
+	ErrPricingRuleNotFound = errors.New("pricing rule not found")
+)
+
+// PriceLine is the price of one seat
+type PriceLine struct {
+	SeatID       string                      `json:"seat_id"`
+	SeatType     models.SeatType             `json:"seat_type"`
+	BasePrice    float64                     `json:"base_price"`
+	Price        float64                     `json:"price"`
+	AppliedRules []models.AppliedPricingRule `json:"applied_rules,omitempty"`
+}
+
+// PriceSubtotal groups the lines of one seat type
+type PriceSubtotal struct {
+	SeatType models.SeatType `json:"seat_type"`
+	Count    int             `json:"count"`
+	Subtotal float64         `json:"subtotal"`
+}
+
+// PriceBreakdown is what the customer is charged for a set of seats
+type PriceBreakdown struct {
+	ScreeningID string          `json:"screening_id"`
+	Lines       []PriceLine     `json:"lines"`
+	Subtotals   []PriceSubtotal `json:"subtotals"`
+	Total       float64         `json:"total"`
+	Currency    string          `json:"currency"`
+	QuotedAt    time.Time       `json:"quoted_at"`
+}
+
+// Line returns the price line of a seat
+func (b *PriceBreakdown) Line(seatID string) (PriceLine, bool) {
+	for _, line := range b.Lines {
+		if line.SeatID == seatID {
+			return line, true
+		}
+	}
+	return PriceLine{}, false
+}
+
+// Covers reports whether the breakdown prices every one of the seats
+func (b *PriceBreakdown) Covers(seatIDs []string) bool {
+	for _, seatID := range seatIDs {
+		if _, ok := b.Line(seatID); !ok {
+			return false
+		}
+	}
+	return true
+}
+
+type PricingService struct{}
+
+func NewPricingService() *PricingService {
+	return &PricingService{}
+}
+
+func pricingRulesCollection() *mongo.Collection {
+	return database.Mongo.Collection("pricing_rules")
+}
+
+// pricingContext is what rules are matched against, computed once per quote
+type pricingContext struct {
+	localStart time.Time
+	format     models.ScreeningFormat
+	occupancy  float64
+}
+
+// Quote prices the seats: seat type price first, then every matching pricing rule in order
+func (s *PricingService) Quote(ctx context.Context, screening *models.Screening, seatIDs []string) (*PriceBreakdown, error) {
+	rules, err := s.ListRules(ctx, true)
+	if err != nil {
+		return nil, err
+	}
+	pctx := s.contextFor(ctx, screening)
+
+	seatTypes := make(map[string]models.SeatType, len(screening.Seats))
+	for _, seat := range screening.Seats {
+		seatType := seat.Type
+		if seatType == "" {
+			seatType = models.SeatStandard
+		}
+		seatTypes[seat.ID] = seatType
+	}
+
+	breakdown := &PriceBreakdown{
+		ScreeningID: screening.ID.Hex(),
+		Lines:       []PriceLine{},
+		Subtotals:   []PriceSubtotal{},
+		Currency:    Currency,
+		QuotedAt:    time.Now(),
+	}
+	subtotals := make(map[models.SeatType]*PriceSubtotal)
+	for _, seatID := range seatIDs {
+		seatType, ok := seatTypes[seatID]
+		if !ok {
+			return nil, fmt.Errorf("unknown seat %s", seatID)
+		}
+		base := screening.PriceFor(seatType)
+		if base <= 0 {
+			return nil, fmt.Errorf("%w: %s (%s)", ErrSeatNotPriced, seatID, seatType)
+		}
+
+		line := PriceLine{SeatID: seatID, SeatType: seatType, BasePrice: base}
+		line.Price, line.AppliedRules = applyRules(rules, pctx, seatType, base)
+
+		breakdown.Lines = append(breakdown.Lines, line)
+		breakdown.Total = roundPrice(breakdown.Total + line.Price)
+
+		sub, ok := subtotals[seatType]
+		if !ok {
+			sub = &PriceSubtotal{SeatType: seatType}
+			subtotals[seatType] = sub
+		}
+		sub.Count++
+		sub.Subtotal = roundPrice(sub.Subtotal + line.Price)
+	}
+
+	for _, sub := range subtotals {
+		breakdown.Subtotals = append(breakdown.Subtotals, *sub)
+	}
+	sort.Slice(breakdown.Subtotals, func(i, j int) bool {
+		return breakdown.Subtotals[i].SeatType < breakdown.Subtotals[j].SeatType
+	})
+	return breakdown, nil
+}
+
+// applyRules runs the base price of a seat type through every matching rule in order
+func applyRules(rules []models.PricingRule, pctx pricingContext, seatType models.SeatType, base float64) (float64, []models.AppliedPricingRule) {
+	price := base
+	var applied []models.AppliedPricingRule
+	for _, rule := range rules {
+		if !ruleMatches(rule, pctx, seatType) {
+			continue
+		}
+		adjusted := applyAdjustment(price, rule.Adjustment)
+		applied = append(applied, models.AppliedPricingRule{
+			RuleID: rule.ID.Hex(),
+			Name:   rule.Name,
+			Delta:  roundPrice(adjusted - price),
+		})
+		price = adjusted
+		if rule.StopOnMatch {
+			break
+		}
+	}
+	return price, applied
+}
+
+// PriceRange is the cheapest and dearest seat type of a screening after pricing rules, for listings.
+// rules are the active rules (ListRules) and loc the cinema's timezone, so callers can load them once.
+func (s *PricingService) PriceRange(screening *models.Screening, rules []models.PricingRule, loc *time.Location) (min, max float64) {
+	pctx := newPricingContext(screening, loc)
+	seen := make(map[models.SeatType]bool)
+	for _, seat := range screening.Seats {
+		seatType := seat.Type
+		if seatType == "" {
+			seatType = models.SeatStandard
+		}
+		if seen[seatType] {
+			continue
+		}
+		seen[seatType] = true
+		base := screening.PriceFor(seatType)
+		if base <= 0 {
+			continue
+		}
+		price, _ := applyRules(rules, pctx, seatType, base)
+		if min == 0 || price < min {
+			min = price
+		}
+		if price > max {
+			max = price
+		}
+	}
+	return min, max
+}
+
+func (s *PricingService) contextFor(ctx context.Context, screening *models.Screening) pricingContext {
+	loc := time.Local
+	if !screening.CinemaID.IsZero() {
+		if cinema, err := NewCinemaService().GetCinema(ctx, screening.CinemaID.Hex()); err == nil {
+			loc = cinema.Location()
+		}
+	}
+	return newPricingContext(screening, loc)
+}
+
+func newPricingContext(screening *models.Screening, loc *time.Location) pricingContext {
+	format := screening.Format
+	if format == "" {
+		format = models.Format2D
+	}
+	occupancy := 0.0
+	if len(screening.Seats) > 0 {
+		occupancy = float64(screening.SoldSeats()) / float64(len(screening.Seats))
+	}
+	return pricingContext{localStart: screening.StartTime.In(loc), format: format, occupancy: occupancy}
+}
+
+func ruleMatches(rule models.PricingRule, pctx pricingContext, seatType models.SeatType) bool {
+	c := rule.Conditions
+	if len(c.Weekdays) > 0 && !containsWeekday(c.Weekdays, pctx.localStart.Weekday()) {
+		return false
+	}
+	if len(c.Dates) > 0 && !containsString(c.Dates, pctx.localStart.Format("2006-01-02")) {
+		return false
+	}
+	// HH:MM strings compare correctly as text
+	clock := pctx.localStart.Format("15:04")
+	if c.StartFrom != "" && clock < c.StartFrom {
+		return false
+	}
+	if c.StartBefore != "" && clock >= c.StartBefore {
+		return false
+	}
+	if len(c.Formats) > 0 && !containsFormat(c.Formats, pctx.format) {
+		return false
+	}
+	if len(c.SeatTypes) > 0 && !containsSeatType(c.SeatTypes, seatType) {
+		return false
+	}
+	if c.MinOccupancy > 0 && pctx.occupancy < c.MinOccupancy {
+		return false
+	}
+	return true
+}
+
+func applyAdjustment(price float64, adj models.PriceAdjustment) float64 {
+	switch adj.Type {
+	case models.AdjustPercent:
+		price = price * (100 + adj.Value) / 100
+	case models.AdjustAmount:
+		price += adj.Value
+	case models.AdjustFixed:
+		price = adj.Value
+	}
+	return math.Max(0, roundPrice(price))
+}
+
+func roundPrice(price float64) float64 {
+	return math.Round(price*100) / 100
+}
+
+func containsWeekday(list []time.Weekday, v time.Weekday) bool {
+	for _, item := range list {
+		if item == v {
+			return true
+		}
+	}
+	return false
+}
+
+func containsString(list []string, v string) bool {
+	for _, item := range list {
+		if item == v {
+			return true
+		}
+	}
+	return false
+}
+
+func containsFormat(list []models.ScreeningFormat, v models.ScreeningFormat) bool {
+	for _, item := range list {
+		if item == v {
+			return true
+		}
+	}
+	return false
+}
+
+func containsSeatType(list []models.SeatType, v models.SeatType) bool {
+	for _, item := range list {
+		if item == v {
+			return true
+		}
+	}
+	return false
+}
+
+// --- Rule management ---
+
+// ListRules returns rules in evaluation order (priority, then ID)
+func (s *PricingService) ListRules(ctx context.Context, activeOnly bool) ([]models.PricingRule, error) {
+	filter := bson.M{}
+	if activeOnly {
+		filter["active"] = true
+	}
+	opts := options.Find().SetSort(bson.D{{Key: "priority", Value: 1}, {Key: "_id", Value: 1}})
+	cursor, err := pricingRulesCollection().Find(ctx, filter, opts)
+	if err != nil {
+		return nil, err
+	}
+	rules := []models.PricingRule{}
+	if err := cursor.All(ctx, &rules); err != nil {
+		return nil, err
+	}
+	return rules, nil
+}
+
+// CreateRule stores a rule that has already been validated
+func (s *PricingService) CreateRule(ctx context.Context, rule models.PricingRule) (*models.PricingRule, error) {
+	now := time.Now()
+	rule.ID = primitive.NewObjectID()
+	rule.CreatedAt = now
+	rule.UpdatedAt = now
+	if _, err := pricingRulesCollection().InsertOne(ctx, rule); err != nil {
+		return nil, err
+	}
+	return &rule, nil
+}
+
+// UpdateRule replaces a rule's definition, keeping its ID and creation time
+func (s *PricingService) UpdateRule(ctx context.Context, ruleIDHex string, rule models.PricingRule) (*models.PricingRule, error) {
+	objID, err := primitive.ObjectIDFromHex(ruleIDHex)
+	if err != nil {
+		return nil, ErrPricingRuleNotFound
+	}
+	update := bson.M{"$set": bson.M{
+		"name":          rule.Name,
+		"priority":      rule.Priority,
+		"active":        rule.Active,
+		"conditions":    rule.Conditions,
+		"adjustment":    rule.Adjustment,
+		"stop_on_match": rule.StopOnMatch,
+		"updated_at":    time.Now(),
+	}}
+	var updated models.PricingRule
+	opts := options.FindOneAndUpdate().SetReturnDocument(options.After)
+	err = pricingRulesCollection().FindOneAndUpdate(ctx, bson.M{"_id": objID}, update, opts).Decode(&updated)
+	if err == mongo.ErrNoDocuments {
+		return nil, ErrPricingRuleNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &updated, nil
+}
+
+// DeleteRule removes a rule. Bookings keep the rule name they were charged with.
+func (s *PricingService) DeleteRule(ctx context.Context, ruleIDHex string) error {
+	objID, err := primitive.ObjectIDFromHex(ruleIDHex)
+	if err != nil {
+		return ErrPricingRuleNotFound
+	}
+	res, err := pricingRulesCollection().DeleteOne(ctx, bson.M{"_id": objID})
+	if err != nil {
+		return err
+	}
+	if res.DeletedCount == 0 {
+		return ErrPricingRuleNotFound
+	}
+	return nil
+}
@@ -0,0 +1,74 @@
+package services
+
+import (
+	"testing"
+	"time"
+
+	"movie-ticket-backend/models"
+)
+
+func TestRuleMatches(t *testing.T) {
+	// Wednesday 2026-10-14, 18:30 local
+	wednesdayEvening := pricingContext{
+		localStart: time.Date(2026, 10, 14, 18, 30, 0, 0, time.UTC),
+		format:     models.FormatIMAX,
+		occupancy:  0.5,
+	}
+
+	tests := []struct {
+		name       string
+		conditions models.PricingConditions
+		seatType   models.SeatType
+		want       bool
+	}{
+		{"no conditions", models.PricingConditions{}, models.SeatStandard, true},
+		{"weekday matches", models.PricingConditions{Weekdays: []time.Weekday{time.Wednesday}}, models.SeatStandard, true},
+		{"weekday differs", models.PricingConditions{Weekdays: []time.Weekday{time.Saturday, time.Sunday}}, models.SeatStandard, false},
+		{"date matches", models.PricingConditions{Dates: []string{"2026-10-14"}}, models.SeatStandard, true},
+		{"date differs", models.PricingConditions{Dates: []string{"2026-12-31"}}, models.SeatStandard, false},
+		{"start_from is inclusive", models.PricingConditions{StartFrom: "18:30"}, models.SeatStandard, true},
+		{"before start_from", models.PricingConditions{StartFrom: "19:00"}, models.SeatStandard, false},
+		{"start_before is exclusive", models.PricingConditions{StartBefore: "18:30"}, models.SeatStandard, false},
+		{"before start_before", models.PricingConditions{StartBefore: "19:00"}, models.SeatStandard, true},
+		{"format matches", models.PricingConditions{Formats: []models.ScreeningFormat{models.FormatIMAX}}, models.SeatStandard, true},
+		{"format differs", models.PricingConditions{Formats: []models.ScreeningFormat{models.Format2D}}, models.SeatStandard, false},
+		{"seat type matches", models.PricingConditions{SeatTypes: []models.SeatType{models.SeatVIP}}, models.SeatVIP, true},
+		{"seat type differs", models.PricingConditions{SeatTypes: []models.SeatType{models.SeatVIP}}, models.SeatStandard, false},
+		{"occupancy reached", models.PricingConditions{MinOccupancy: 0.5}, models.SeatStandard, true},
+		{"occupancy not reached", models.PricingConditions{MinOccupancy: 0.8}, models.SeatStandard, false},
+		{"all conditions must hold", models.PricingConditions{Weekdays: []time.Weekday{time.Wednesday}, MinOccupancy: 0.8}, models.SeatStandard, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rule := models.PricingRule{Conditions: tt.conditions}
+			if got := ruleMatches(rule, wednesdayEvening, tt.seatType); got != tt.want {
+				t.Errorf("ruleMatches = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestApplyAdjustment(t *testing.T) {
+	tests := []struct {
+		name  string
+		price float64
+		adj   models.PriceAdjustment
+		want  float64
+	}{
+		{"percent discount", 200, models.PriceAdjustment{Type: models.AdjustPercent, Value: -20}, 160},
+		{"percent surcharge", 200, models.PriceAdjustment{Type: models.AdjustPercent, Value: 15}, 230},
+		{"percent rounds to cents", 199.99, models.PriceAdjustment{Type: models.AdjustPercent, Value: -33}, 133.99},
+		{"amount added", 200, models.PriceAdjustment{Type: models.AdjustAmount, Value: 50}, 250},
+		{"amount taken off", 200, models.PriceAdjustment{Type: models.AdjustAmount, Value: -40}, 160},
+		{"amount never below zero", 30, models.PriceAdjustment{Type: models.AdjustAmount, Value: -40}, 0},
+		{"fixed replaces the price", 200, models.PriceAdjustment{Type: models.AdjustFixed, Value: 99}, 99},
+		{"unknown type keeps the price", 200, models.PriceAdjustment{Type: "OTHER", Value: 10}, 200},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := applyAdjustment(tt.price, tt.adj); got != tt.want {
+				t.Errorf("applyAdjustment(%v, %+v) = %v, want %v", tt.price, tt.adj, got, tt.want)
+			}
+		})
+	}
+}
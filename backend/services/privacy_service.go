@@ -0,0 +1,163 @@
+package services
+
+import (
+	"archive/zip"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"movie-ticket-backend/database"
+	"movie-ticket-backend/models"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+var ErrAccountDeleted = errors.New("account has already been deleted")
+
+// auditPIIFields are removed from audit log details when the user is deleted
+var auditPIIFields = []string{"ip", "email", "name", "user_agent"}
+
+// DataExport is everything we store about a user
+type DataExport struct {
+	ExportedAt time.Time         `json:"exported_at"`
+	Profile    models.User       `json:"profile"`
+	Bookings   []models.Booking  `json:"bookings"`
+	AuditLogs  []models.AuditLog `json:"audit_logs"`
+}
+
+type PrivacyService struct{}
+
+func NewPrivacyService() *PrivacyService {
+	return &PrivacyService{}
+}
+
+// Export collects the user's profile, bookings and the audit entries they triggered
+func (s *PrivacyService) Export(ctx context.Context, userID string) (*DataExport, error) {
+	user, err := NewUserService().GetUserByID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	export := &DataExport{
+		ExportedAt: time.Now(),
+		Profile:    *user,
+		Bookings:   []models.Booking{},
+		AuditLogs:  []models.AuditLog{},
+	}
+
+	byDate := options.Find().SetSort(bson.D{{Key: "created_at", Value: 1}})
+	cursor, err := database.Mongo.Collection("bookings").Find(ctx, bson.M{"user_id": userID}, byDate)
+	if err != nil {
+		return nil, err
+	}
+	if err := cursor.All(ctx, &export.Bookings); err != nil {
+		return nil, err
+	}
+
+	byTimestamp := options.Find().SetSort(bson.D{{Key: "timestamp", Value: 1}})
+	cursor, err = database.Mongo.Collection("audit_logs").Find(ctx, bson.M{"user_id": userID}, byTimestamp)
+	if err != nil {
+		return nil, err
+	}
+	if err := cursor.All(ctx, &export.AuditLogs); err != nil {
+		return nil, err
+	}
+
+	return export, nil
+}
+
+// WriteZip writes the export as a zip with one JSON file per section
+func (e *DataExport) WriteZip(w io.Writer) error {
+	zw := zip.NewWriter(w)
+	files := []struct {
+		name string
+		data interface{}
+	}{
+		{"profile.json", e.Profile},
+		{"bookings.json", e.Bookings},
+		{"audit_logs.json", e.AuditLogs},
+	}
+	for _, f := range files {
+		fw, err := zw.CreateHeader(&zip.FileHeader{Name: f.name, Method: zip.Deflate, Modified: e.ExportedAt})
+		if err != nil {
+			return err
+		}
+		enc := json.NewEncoder(fw)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(f.data); err != nil {
+			return err
+		}
+	}
+	return zw.Close()
+}
+
+// DeleteAccount anonymizes the user record, pseudonymizes their audit logs and ends all sessions.
+// Bookings are kept untouched for financial records; they still point at the anonymized user.
+func (s *PrivacyService) DeleteAccount(ctx context.Context, userID string) (pseudonym string, err error) {
+	user, err := NewUserService().GetUserByID(ctx, userID)
+	if err != nil {
+		return "", err
+	}
+	if user.IsDeleted() {
+		return "", ErrAccountDeleted
+	}
+
+	// Random (not derived from the ID) so the audit trail cannot be linked back to the user
+	suffix, err := randomToken(12)
+	if err != nil {
+		return "", err
+	}
+	pseudonym = "deleted-" + suffix
+	now := time.Now()
+
+	update := bson.M{
+		"$set": bson.M{
+			"email":          fmt.Sprintf("%s@deleted.invalid", user.ID.Hex()),
+			"email_verified": false,
+			"name":           "Deleted User",
+			"picture_url":    "",
+			"status":         models.UserDeleted,
+			"deleted_at":     now,
+			"updated_at":     now,
+		},
+		// Unlinking identities means signing in again with the same Google account creates a new user
+		"$unset": bson.M{
+			"password_hash":    "",
+			"date_of_birth":    "",
+			"identities":       "",
+			"role_assignments": "",
+			"suspended_reason": "",
+			"suspended_at":     "",
+		},
+	}
+	if _, err := database.Mongo.Collection("users").UpdateOne(ctx, bson.M{"_id": user.ID}, update); err != nil {
+		return "", err
+	}
+	InvalidateUser(userID)
+
+	auditLogs := database.Mongo.Collection("audit_logs")
+	unset := bson.M{"ip_address": ""}
+	for _, field := range auditPIIFields {
+		unset["details."+field] = ""
+	}
+	if _, err := auditLogs.UpdateMany(ctx, bson.M{"user_id": userID}, bson.M{
+		"$set":   bson.M{"user_id": pseudonym},
+		"$unset": unset,
+	}); err != nil {
+		return "", err
+	}
+	if _, err := auditLogs.UpdateMany(ctx, bson.M{"details.target_user_id": userID}, bson.M{
+		"$set": bson.M{"details.target_user_id": pseudonym},
+	}); err != nil {
+		return "", err
+	}
+
+	if _, err := NewSessionService().RevokeAllSessions(userID); err != nil {
+		return "", err
+	}
+	NewLockService().ReleasePaymentLock(userID)
+	return pseudonym, nil
+}
@@ -0,0 +1,106 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"movie-ticket-backend/database"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+)
+
+// RateLimit is a token bucket: Capacity requests, refilled evenly over Period
+type RateLimit struct {
+	Capacity int
+	Period   time.Duration
+}
+
+// ParseRateLimit parses "<requests>/<period>", e.g. "30/1m". An empty spec returns nil (no limit).
+func ParseRateLimit(spec string) (*RateLimit, error) {
+	spec = strings.TrimSpace(spec)
+	if spec == "" {
+		return nil, nil
+	}
+	parts := strings.SplitN(spec, "/", 2)
+	if len(parts) != 2 {
+		return nil, fmt.Errorf("invalid rate limit %q, expected <requests>/<period>", spec)
+	}
+	capacity, err := strconv.Atoi(parts[0])
+	if err != nil || capacity <= 0 {
+		return nil, fmt.Errorf("invalid request count in rate limit %q", spec)
+	}
+	period, err := time.ParseDuration(parts[1])
+	if err != nil || period <= 0 {
+		return nil, fmt.Errorf("invalid period in rate limit %q", spec)
+	}
+	return &RateLimit{Capacity: capacity, Period: period}, nil
+}
+
+// tokenBucketScript refills the bucket for the elapsed time and takes one token atomically.
+// KEYS[1] bucket hash; ARGV: capacity, refill per ms, now (ms), ttl (ms)
+// Returns {allowed (0/1), retry after (ms)}
+var tokenBucketScript = redis.NewScript(`
+local capacity = tonumber(ARGV[1])
+local rate = tonumber(ARGV[2])
+local now = tonumber(ARGV[3])
+local ttl = tonumber(ARGV[4])
+
+local bucket = redis.call("HMGET", KEYS[1], "tokens", "ts")
+local tokens = tonumber(bucket[1])
+local ts = tonumber(bucket[2])
+if tokens == nil then
+	tokens = capacity
+	ts = now
+end
+
+tokens = math.min(capacity, tokens + math.max(0, now - ts) * rate)
+
+local allowed = 0
+local retry = 0
+if tokens >= 1 then
+	tokens = tokens - 1
+	allowed = 1
+else
+	retry = math.ceil((1 - tokens) / rate)
+end
+
+redis.call("HSET", KEYS[1], "tokens", tostring(tokens), "ts", tostring(now))
+redis.call("PEXPIRE", KEYS[1], ttl)
+return {allowed, retry}
+`)
+
+type RateLimiter struct {
+	RDB *redis.Client
+}
+
+func NewRateLimiter() *RateLimiter {
+	return &RateLimiter{
+		RDB: database.RDB,
+	}
+}
+
+// Allow takes a token from the bucket identified by key. When denied, retryAfter is
+// how long until the next token is available.
+func (r *RateLimiter) Allow(ctx context.Context, key string, limit RateLimit) (allowed bool, retryAfter time.Duration, err error) {
+	ratePerMs := float64(limit.Capacity) / float64(limit.Period.Milliseconds())
+	res, err := tokenBucketScript.Run(ctx, r.RDB, []string{"ratelimit:" + key},
+		limit.Capacity, ratePerMs, time.Now().UnixMilli(), limit.Period.Milliseconds()).Slice()
+	if err != nil {
+		return false, 0, err
+	}
+	if len(res) != 2 {
+		return false, 0, fmt.Errorf("unexpected rate limit script result")
+	}
+	ok, _ := res[0].(int64)
+	retryMs, _ := res[1].(int64)
+	return ok == 1, time.Duration(retryMs) * time.Millisecond, nil
+}
+
+// ShouldAuditRateLimit returns true at most once per window for a bucket so an attack
+// does not flood the audit log
+func (r *RateLimiter) ShouldAuditRateLimit(ctx context.Context, key string, window time.Duration) bool {
+	ok, err := r.RDB.SetNX(ctx, "ratelimit_audited:"+key, 1, window).Result()
+	return err == nil && ok
+}
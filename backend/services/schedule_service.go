@@ -0,0 +1,305 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"movie-ticket-backend/config"
+	"movie-ticket-backend/database"
+	"movie-ticket-backend/models"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+var ErrScheduleTemplateNotFound = errors.New("schedule template not found")
+
+// PlannedScreening is one showtime in a schedule plan
+type PlannedScreening struct {
+	ScreeningID string             `json:"screening_id,omitempty"` // Existing screening, empty for new ones
+	StartTime   time.Time          `json:"start_time"`
+	Conflicts   []ScheduleConflict `json:"conflicts,omitempty"` // A conflict without screening_id is another showtime of the same template
+}
+
+// SchedulePlan is what applying a template would do to the hall's schedule. Only future showtimes are planned.
+type SchedulePlan struct {
+	Create    []PlannedScreening `json:"create"`
+	Update    []PlannedScreening `json:"update"`    // Unsold screenings that take the template's new hall, prices, format or audio
+	Remove    []PlannedScreening `json:"remove"`    // Unsold screenings the template no longer produces
+	KeptSold  []PlannedScreening `json:"kept_sold"` // Screenings with sold seats are never changed by a template
+	Unchanged int                `json:"unchanged"`
+	Conflicts int                `json:"conflicts"` // Number of create/update entries that overlap another screening
+}
+
+type ScheduleService struct{}
+
+func NewScheduleService() *ScheduleService {
+	return &ScheduleService{}
+}
+
+func scheduleTemplatesCollection() *mongo.Collection {
+	return database.Mongo.Collection("schedule_templates")
+}
+
+// hallConflictFinder reports the hall's screenings that overlap a showtime, ignoring excludeIDs
+type hallConflictFinder func(start time.Time, excludeIDs []primitive.ObjectID) ([]ScheduleConflict, error)
+
+// Plan compares the template's future showtimes with the screenings it generated before
+// and checks every new or changed showtime against the hall's schedule
+func (s *ScheduleService) Plan(ctx context.Context, tmpl *models.ScheduleTemplate, hall *models.Hall, durationMin int) (*SchedulePlan, error) {
+	now := time.Now()
+	existing, err := s.futureScreenings(ctx, tmpl.ID, now)
+	if err != nil {
+		return nil, err
+	}
+
+	loc := time.Local
+	if cinema, err := NewCinemaService().GetCinema(ctx, hall.CinemaID.Hex()); err == nil {
+		loc = cinema.Location()
+	}
+	occupied := time.Duration(durationMin)*time.Minute + config.AppConfig.ScreeningCleaningBuffer
+
+	screeningService := NewScreeningService()
+	findConflicts := func(start time.Time, excludeIDs []primitive.ObjectID) ([]ScheduleConflict, error) {
+		return screeningService.FindHallConflicts(ctx, hall.ID, start, durationMin, excludeIDs...)
+	}
+	return planSchedule(tmpl, hall, tmpl.Occurrences(loc), existing, now, occupied, findConflicts)
+}
+
+// planSchedule is Plan without the database: occurrences are the template's showtimes in order,
+// existing the future screenings it generated before, occupied how long a showtime blocks the hall
+func planSchedule(tmpl *models.ScheduleTemplate, hall *models.Hall, occurrences []time.Time, existing []models.Screening, now time.Time, occupied time.Duration, findConflicts hallConflictFinder) (*SchedulePlan, error) {
+	plan := &SchedulePlan{Create: []PlannedScreening{}, Update: []PlannedScreening{}, Remove: []PlannedScreening{}, KeptSold: []PlannedScreening{}}
+
+	byStart := make(map[int64]models.Screening, len(existing))
+	for _, sc := range existing {
+		byStart[sc.StartTime.Unix()] = sc
+	}
+
+	// Decide what happens to each showtime first; only creates and updates need checking
+	matched := make(map[primitive.ObjectID]bool)
+	var pending []PlannedScreening
+	for _, start := range occurrences {
+		if !start.After(now) {
+			continue
+		}
+		entry := PlannedScreening{StartTime: start}
+		if current, found := byStart[start.Unix()]; found {
+			matched[current.ID] = true
+			entry.ScreeningID = current.ID.Hex()
+			if current.SoldSeats() > 0 {
+				plan.KeptSold = append(plan.KeptSold, entry)
+				continue
+			}
+			if current.HallID == hall.ID && current.Price == tmpl.Price && models.SamePrices(current.Prices, tmpl.Prices) && current.Format == tmpl.Format && current.Audio == tmpl.Audio {
+				plan.Unchanged++
+				continue
+			}
+		}
+		pending = append(pending, entry)
+	}
+
+	// Only the screenings this plan updates or removes may be ignored in the hall check;
+	// unchanged and sold ones stay where they are and must not be overlapped
+	var exclude []primitive.ObjectID
+	for _, entry := range pending {
+		if id, err := primitive.ObjectIDFromHex(entry.ScreeningID); err == nil {
+			exclude = append(exclude, id)
+		}
+	}
+	for _, sc := range existing {
+		if !matched[sc.ID] && sc.SoldSeats() == 0 {
+			exclude = append(exclude, sc.ID)
+		}
+	}
+
+	// The ignored screenings are replaced by the pending showtimes, so those are also checked
+	// against each other: the showtime before and the one after
+	for i, entry := range pending {
+		conflicts, err := findConflicts(entry.StartTime, exclude)
+		if err != nil {
+			return nil, err
+		}
+		if i > 0 {
+			if previous := pending[i-1].StartTime; entry.StartTime.Sub(previous) < occupied {
+				conflicts = append(conflicts, ScheduleConflict{MovieID: tmpl.MovieID.Hex(), StartTime: previous, FreeAt: previous.Add(occupied)})
+			}
+		}
+		if i+1 < len(pending) {
+			if next := pending[i+1].StartTime; next.Sub(entry.StartTime) < occupied {
+				conflicts = append(conflicts, ScheduleConflict{MovieID: tmpl.MovieID.Hex(), StartTime: next, FreeAt: next.Add(occupied)})
+			}
+		}
+		entry.Conflicts = conflicts
+		if len(conflicts) > 0 {
+			plan.Conflicts++
+		}
+		if entry.ScreeningID != "" {
+			plan.Update = append(plan.Update, entry)
+		} else {
+			plan.Create = append(plan.Create, entry)
+		}
+	}
+
+	addLeftovers(plan, existing, matched)
+	return plan, nil
+}
+
+// PlanRemoval is the plan for deleting a template: all of its unsold future screenings go
+func (s *ScheduleService) PlanRemoval(ctx context.Context, templateID primitive.ObjectID) (*SchedulePlan, error) {
+	plan := &SchedulePlan{Create: []PlannedScreening{}, Update: []PlannedScreening{}, Remove: []PlannedScreening{}, KeptSold: []PlannedScreening{}}
+	existing, err := s.futureScreenings(ctx, templateID, time.Now())
+	if err != nil {
+		return nil, err
+	}
+	addLeftovers(plan, existing, nil)
+	return plan, nil
+}
+
+// addLeftovers plans the screenings no showtime matched: removed if unsold, otherwise kept
+func addLeftovers(plan *SchedulePlan, existing []models.Screening, matched map[primitive.ObjectID]bool) {
+	for _, sc := range existing {
+		if matched[sc.ID] {
+			continue
+		}
+		entry := PlannedScreening{ScreeningID: sc.ID.Hex(), StartTime: sc.StartTime}
+		if sc.SoldSeats() > 0 {
+			plan.KeptSold = append(plan.KeptSold, entry)
+		} else {
+			plan.Remove = append(plan.Remove, entry)
+		}
+	}
+}
+
+// Apply carries out a plan. Entries with conflicts are skipped; unsold screenings are re-checked
+// when updated or removed, so seats sold since the plan was made are never touched.
+func (s *ScheduleService) Apply(ctx context.Context, tmpl *models.ScheduleTemplate, hall *models.Hall, plan *SchedulePlan) error {
+	now := time.Now()
+	unsold := bson.M{"$not": bson.M{"$elemMatch": bson.M{"status": models.SeatBooked}}}
+
+	var docs []interface{}
+	for _, entry := range plan.Create {
+		if len(entry.Conflicts) > 0 {
+			continue
+		}
+		screening := NewScreening(tmpl.MovieID, hall, entry.StartTime, tmpl.Price, tmpl.Prices)
+		screening.Format = tmpl.Format
+		screening.Audio = tmpl.Audio
+		screening.TemplateID = tmpl.ID
+		docs = append(docs, screening)
+	}
+	if len(docs) > 0 {
+		if _, err := screeningsCollection().InsertMany(ctx, docs); err != nil {
+			return err
+		}
+	}
+
+	for _, entry := range plan.Update {
+		if len(entry.Conflicts) > 0 {
+			continue
+		}
+		id, _ := primitive.ObjectIDFromHex(entry.ScreeningID)
+		// Always take the hall's current seat map; nothing is sold so no seat state is lost
+		fresh := NewScreening(tmpl.MovieID, hall, entry.StartTime, tmpl.Price, tmpl.Prices)
+		set := bson.M{
+			"cinema_id":    fresh.CinemaID,
+			"hall_id":      fresh.HallID,
+			"layout":       fresh.Layout,
+			"seats":        fresh.Seats,
+			"seats_total":  fresh.SeatsTotal,
+			"seats_booked": 0,
+			"price":        tmpl.Price,
+			"prices":       tmpl.Prices,
+			"format":       tmpl.Format,
+			"audio":        tmpl.Audio,
+			"updated_at":   now,
+		}
+		if _, err := screeningsCollection().UpdateOne(ctx, bson.M{"_id": id, "seats": unsold}, bson.M{"$set": set}); err != nil {
+			return err
+		}
+	}
+
+	screeningService := NewScreeningService()
+	for _, entry := range plan.Remove {
+		id, _ := primitive.ObjectIDFromHex(entry.ScreeningID)
+		if _, err := screeningService.SoftDelete(ctx, id); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// futureScreenings returns the screenings a template generated that have not started yet
+func (s *ScheduleService) futureScreenings(ctx context.Context, templateID primitive.ObjectID, now time.Time) ([]models.Screening, error) {
+	if templateID.IsZero() {
+		return nil, nil
+	}
+	filter := bson.M{"template_id": templateID, "start_time": bson.M{"$gt": now}, "deleted_at": bson.M{"$exists": false}}
+	cursor, err := screeningsCollection().Find(ctx, filter, options.Find().SetProjection(bson.M{"layout": 0}))
+	if err != nil {
+		return nil, err
+	}
+	var screenings []models.Screening
+	if err := cursor.All(ctx, &screenings); err != nil {
+		return nil, err
+	}
+	return screenings, nil
+}
+
+// --- Template management ---
+
+// ListTemplates returns the templates of the given halls, or all templates when hallIDs is nil
+func (s *ScheduleService) ListTemplates(ctx context.Context, hallIDs []primitive.ObjectID) ([]models.ScheduleTemplate, error) {
+	filter := bson.M{}
+	if hallIDs != nil {
+		filter["hall_id"] = bson.M{"$in": hallIDs}
+	}
+	opts := options.Find().SetSort(bson.D{{Key: "start_date", Value: -1}, {Key: "_id", Value: 1}})
+	cursor, err := scheduleTemplatesCollection().Find(ctx, filter, opts)
+	if err != nil {
+		return nil, err
+	}
+	templates := []models.ScheduleTemplate{}
+	if err := cursor.All(ctx, &templates); err != nil {
+		return nil, err
+	}
+	return templates, nil
+}
+
+func (s *ScheduleService) GetTemplate(ctx context.Context, templateIDHex string) (*models.ScheduleTemplate, error) {
+	objID, err := primitive.ObjectIDFromHex(templateIDHex)
+	if err != nil {
+		return nil, ErrScheduleTemplateNotFound
+	}
+	var tmpl models.ScheduleTemplate
+	err = scheduleTemplatesCollection().FindOne(ctx, bson.M{"_id": objID}).Decode(&tmpl)
+	if err == mongo.ErrNoDocuments {
+		return nil, ErrScheduleTemplateNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &tmpl, nil
+}
+
+// SaveTemplate inserts a new template (zero ID) or replaces an existing one
+func (s *ScheduleService) SaveTemplate(ctx context.Context, tmpl *models.ScheduleTemplate) error {
+	now := time.Now()
+	tmpl.UpdatedAt = now
+	if tmpl.ID.IsZero() {
+		tmpl.ID = primitive.NewObjectID()
+		tmpl.CreatedAt = now
+		_, err := scheduleTemplatesCollection().InsertOne(ctx, tmpl)
+		return err
+	}
+	_, err := scheduleTemplatesCollection().ReplaceOne(ctx, bson.M{"_id": tmpl.ID}, tmpl)
+	return err
+}
+
+// DeleteTemplate removes the template only; apply PlanRemoval first to clear its screenings
+func (s *ScheduleService) DeleteTemplate(ctx context.Context, templateID primitive.ObjectID) error {
+	_, err := scheduleTemplatesCollection().DeleteOne(ctx, bson.M{"_id": templateID})
+	return err
+}
@@ -0,0 +1,99 @@
+package services
+
+import (
+	"testing"
+	"time"
+
+	"movie-ticket-backend/models"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// hallConflictsIn stands in for FindHallConflicts over the given screenings of one hall
+func hallConflictsIn(hallScreenings []models.Screening, occupied time.Duration) hallConflictFinder {
+	return func(start time.Time, excludeIDs []primitive.ObjectID) ([]ScheduleConflict, error) {
+		excluded := make(map[primitive.ObjectID]bool)
+		for _, id := range excludeIDs {
+			excluded[id] = true
+		}
+		var conflicts []ScheduleConflict
+		for _, other := range hallScreenings {
+			freeAt := other.StartTime.Add(occupied)
+			if !excluded[other.ID] && other.StartTime.Before(start.Add(occupied)) && freeAt.After(start) {
+				conflicts = append(conflicts, ScheduleConflict{ScreeningID: other.ID.Hex(), StartTime: other.StartTime, FreeAt: freeAt})
+			}
+		}
+		return conflicts, nil
+	}
+}
+
+func TestPlanScheduleConflicts(t *testing.T) {
+	now := time.Date(2026, 10, 16, 9, 0, 0, 0, time.UTC)
+	at := func(clock string) time.Time {
+		hm, _ := time.Parse("15:04", clock)
+		return time.Date(2026, 10, 17, hm.Hour(), hm.Minute(), 0, 0, time.UTC)
+	}
+	occupied := 2 * time.Hour // Movie plus cleaning buffer
+	hall := &models.Hall{ID: primitive.NewObjectID()}
+	tmpl := &models.ScheduleTemplate{ID: primitive.NewObjectID(), MovieID: primitive.NewObjectID(), Price: 200}
+	generated := func(clock string) models.Screening {
+		return models.Screening{ID: primitive.NewObjectID(), MovieID: tmpl.MovieID, HallID: hall.ID, StartTime: at(clock), Price: tmpl.Price, TemplateID: tmpl.ID}
+	}
+
+	t.Run("edit adds a showtime overlapping an unchanged one", func(t *testing.T) {
+		// Applied with ["14:30"], then edited to ["14:00", "14:30"]
+		existing := []models.Screening{generated("14:30")}
+		plan, err := planSchedule(tmpl, hall, []time.Time{at("14:00"), at("14:30")}, existing, now, occupied, hallConflictsIn(existing, occupied))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if plan.Unchanged != 1 || len(plan.Create) != 1 {
+			t.Fatalf("unchanged = %d, create = %d, want 1 and 1", plan.Unchanged, len(plan.Create))
+		}
+		conflicts := plan.Create[0].Conflicts
+		if plan.Conflicts != 1 || len(conflicts) != 1 || conflicts[0].ScreeningID != existing[0].ID.Hex() {
+			t.Fatalf("the new 14:00 showtime should conflict with the existing 14:30 screening, got %+v", conflicts)
+		}
+	})
+
+	t.Run("new showtimes are checked against the next one", func(t *testing.T) {
+		plan, err := planSchedule(tmpl, hall, []time.Time{at("14:00"), at("14:30"), at("20:00")}, nil, now, occupied, hallConflictsIn(nil, occupied))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(plan.Create) != 3 || plan.Conflicts != 2 {
+			t.Fatalf("create = %d, conflicts = %d, want 3 and 2", len(plan.Create), plan.Conflicts)
+		}
+		if first := plan.Create[0].Conflicts; len(first) != 1 || !first[0].StartTime.Equal(at("14:30")) {
+			t.Fatalf("14:00 should conflict with the 14:30 showtime after it, got %+v", first)
+		}
+		if last := plan.Create[2].Conflicts; len(last) != 0 {
+			t.Fatalf("20:00 should be free, got %+v", last)
+		}
+	})
+
+	t.Run("removed screenings free their slot", func(t *testing.T) {
+		// Edited from ["14:30"] to ["14:00"]: the 14:30 screening goes, so 14:00 fits
+		existing := []models.Screening{generated("14:30")}
+		plan, err := planSchedule(tmpl, hall, []time.Time{at("14:00")}, existing, now, occupied, hallConflictsIn(existing, occupied))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(plan.Create) != 1 || len(plan.Remove) != 1 || plan.Conflicts != 0 {
+			t.Fatalf("create = %d, remove = %d, conflicts = %d, want 1, 1 and 0", len(plan.Create), len(plan.Remove), plan.Conflicts)
+		}
+	})
+
+	t.Run("sold screenings keep their slot", func(t *testing.T) {
+		sold := generated("14:30")
+		sold.Seats = []models.Seat{{ID: "A1", Status: models.SeatBooked}}
+		existing := []models.Screening{sold}
+		plan, err := planSchedule(tmpl, hall, []time.Time{at("14:00")}, existing, now, occupied, hallConflictsIn(existing, occupied))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(plan.KeptSold) != 1 || len(plan.Create) != 1 || len(plan.Create[0].Conflicts) != 1 {
+			t.Fatalf("the new 14:00 showtime should conflict with the sold 14:30 screening, got %+v", plan)
+		}
+	})
+}
@@ -0,0 +1,233 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"movie-ticket-backend/database"
+	"movie-ticket-backend/models"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// ScreeningCancelledEvent is published after a cancellation so the refunded customers are emailed.
+// BookingIDs only lists the bookings refunded by that run, so a retry never emails anyone twice.
+type ScreeningCancelledEvent struct {
+	ScreeningID string    `json:"screening_id"`
+	MovieID     string    `json:"movie_id"`
+	StartTime   time.Time `json:"start_time"`
+	Reason      string    `json:"reason"`
+	BookingIDs  []string  `json:"booking_ids"`
+}
+
+// ScreeningCancelledMessage tells connected clients to drop the screening
+type ScreeningCancelledMessage struct {
+	Type        string `json:"type"` // SCREENING_CANCELLED
+	ScreeningID string `json:"screening_id"`
+	MovieID     string `json:"movie_id"`
+	StartTime   string `json:"start_time"`
+}
+
+// FailedRefund is a payment the gateway did not refund; its bookings stay SUCCESS until a retry
+type FailedRefund struct {
+	PaymentID string   `json:"payment_id"`
+	UserID    string   `json:"user_id"`
+	Amount    float64  `json:"amount"`
+	SeatIDs   []string `json:"seat_ids"`
+	Error     string   `json:"error"`
+}
+
+type CancellationResult struct {
+	ScreeningID      string         `json:"screening_id"`
+	AlreadyCancelled bool           `json:"already_cancelled"` // This run only retried outstanding refunds
+	ReleasedLocks    int            `json:"released_locks"`
+	ReleasedPayments int            `json:"released_payments"`
+	RefundedBookings int            `json:"refunded_bookings"`
+	RefundedAmount   float64        `json:"refunded_amount"`
+	FailedRefunds    []FailedRefund `json:"failed_refunds,omitempty"`
+}
+
+// GetCancellable loads an active screening, or an already cancelled one so its refunds can be retried.
+// Deleted screenings are not found.
+func (s *ScreeningService) GetCancellable(ctx context.Context, screeningIDHex string) (*models.Screening, error) {
+	screeningID, err := primitive.ObjectIDFromHex(screeningIDHex)
+	if err != nil {
+		return nil, ErrScreeningNotFound
+	}
+	var screening models.Screening
+	err = screeningsCollection().FindOne(ctx, bson.M{"_id": screeningID}).Decode(&screening)
+	if err == mongo.ErrNoDocuments || (err == nil && screening.DeletedAt != nil && screening.CancelledAt == nil) {
+		return nil, ErrScreeningNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &screening, nil
+}
+
+// Cancel marks a screening cancelled (and soft deleted, so no new lock or booking can reach it),
+// releases its seat and payment locks and refunds every SUCCESS booking, one refund per payment.
+// Cancelling an already cancelled screening retries the refunds that failed before.
+// The screening comes from GetCancellable.
+func (s *ScreeningService) Cancel(ctx context.Context, screening *models.Screening, reason, adminID string) (*CancellationResult, error) {
+	screeningID := screening.ID
+	result := &CancellationResult{ScreeningID: screeningID.Hex(), AlreadyCancelled: screening.CancelledAt != nil}
+
+	if screening.CancelledAt == nil {
+		now := time.Now()
+		filter := bson.M{"_id": screeningID, "deleted_at": bson.M{"$exists": false}}
+		update := bson.M{"$set": bson.M{"cancelled_at": now, "cancel_reason": reason, "deleted_at": now, "updated_at": now}}
+		res, err := screeningsCollection().UpdateOne(ctx, filter, update)
+		if err != nil {
+			return nil, err
+		}
+		if res.MatchedCount == 0 {
+			return nil, ErrScreeningNotFound // Deleted meanwhile
+		}
+		screening.CancelledAt, screening.CancelReason, screening.DeletedAt = &now, reason, &now
+	}
+
+	if err := s.releaseLocks(screeningID.Hex(), result); err != nil {
+		return result, fmt.Errorf("release locks: %w", err)
+	}
+	if err := s.refundBookings(ctx, screening, adminID, result); err != nil {
+		return result, fmt.Errorf("refund bookings: %w", err)
+	}
+
+	if !result.AlreadyCancelled {
+		WSHub.Broadcast <- ScreeningCancelledMessage{
+			Type:        "SCREENING_CANCELLED",
+			ScreeningID: screeningID.Hex(),
+			MovieID:     screening.MovieID.Hex(),
+			StartTime:   screening.StartTime.Format(time.RFC3339Nano),
+		}
+	}
+	return result, nil
+}
+
+// releaseLocks drops the seat locks of the screening and the payment locks of their holders
+func (s *ScreeningService) releaseLocks(screeningID string, result *CancellationResult) error {
+	lockService := NewLockService()
+	locked, err := lockService.GetLockedSeats(screeningID)
+	if err != nil {
+		return err
+	}
+	holders := make(map[string]bool)
+	for seatID, holder := range locked {
+		if err := lockService.UnlockSeat(screeningID, seatID); err != nil {
+			return err
+		}
+		holders[holder] = true
+		result.ReleasedLocks++
+	}
+	for holder := range holders {
+		details, err := lockService.GetPaymentLock(holder)
+		if err != nil || details == nil || details.ScreeningID != screeningID {
+			continue
+		}
+		if err := lockService.ReleasePaymentLock(holder); err != nil {
+			return err
+		}
+		result.ReleasedPayments++
+	}
+	return nil
+}
+
+// refundBookings refunds the SUCCESS bookings payment by payment and marks them CANCELLED_BY_CINEMA
+func (s *ScreeningService) refundBookings(ctx context.Context, screening *models.Screening, adminID string, result *CancellationResult) error {
+	bookings := database.Mongo.Collection("bookings")
+	cursor, err := bookings.Find(ctx, bson.M{"screening_id": screening.ID.Hex(), "status": models.BookingStatusSuccess})
+	if err != nil {
+		return err
+	}
+	var successful []models.Booking
+	if err := cursor.All(ctx, &successful); err != nil {
+		return err
+	}
+
+	// A payment covers all the seats a user bought together
+	type paymentGroup struct {
+		userID    string
+		paymentID string
+		amount    float64
+		ids       []primitive.ObjectID
+		seatIDs   []string
+		bookings  []string
+	}
+	var order []string
+	groups := make(map[string]*paymentGroup)
+	for _, b := range successful {
+		key := b.UserID + "|" + b.PaymentID
+		g, ok := groups[key]
+		if !ok {
+			g = &paymentGroup{userID: b.UserID, paymentID: b.PaymentID}
+			groups[key] = g
+			order = append(order, key)
+		}
+		g.amount += b.Amount
+		g.ids = append(g.ids, b.ID)
+		g.seatIDs = append(g.seatIDs, b.SeatID)
+		g.bookings = append(g.bookings, b.ID.Hex())
+	}
+
+	var refunded []string
+	for _, key := range order {
+		g := groups[key]
+		paymentID := g.paymentID
+		refund, err := Payments.Refund(ctx, RefundRequest{
+			PaymentID:      paymentID,
+			Amount:         g.amount,
+			Currency:       Currency,
+			Reason:         "screening cancelled by cinema",
+			IdempotencyKey: fmt.Sprintf("cancel:%s:%s", screening.ID.Hex(), key),
+		})
+		if err != nil {
+			LogError("REFUND_FAILED", g.userID, err, map[string]interface{}{
+				"screening_id": screening.ID.Hex(),
+				"payment_id":   paymentID,
+				"amount":       g.amount,
+				"seat_ids":     g.seatIDs,
+				"admin_id":     adminID,
+			})
+			result.FailedRefunds = append(result.FailedRefunds, FailedRefund{
+				PaymentID: paymentID, UserID: g.userID, Amount: g.amount, SeatIDs: g.seatIDs, Error: err.Error(),
+			})
+			continue
+		}
+
+		filter := bson.M{"_id": bson.M{"$in": g.ids}, "status": models.BookingStatusSuccess}
+		update := bson.M{"$set": bson.M{
+			"status":      models.BookingStatusCancelledByCinema,
+			"refund_id":   refund.ID,
+			"refunded_at": refund.CreatedAt,
+		}}
+		if _, err := bookings.UpdateMany(ctx, filter, update); err != nil {
+			return fmt.Errorf("payment %s was refunded as %s but its bookings were not updated: %w", paymentID, refund.ID, err)
+		}
+		LogInfo("BOOKING_REFUNDED", g.userID, map[string]interface{}{
+			"screening_id": screening.ID.Hex(),
+			"payment_id":   paymentID,
+			"refund_id":    refund.ID,
+			"amount":       g.amount,
+			"seat_ids":     g.seatIDs,
+			"reason":       "screening_cancelled",
+			"admin_id":     adminID,
+		})
+		result.RefundedBookings += len(g.ids)
+		result.RefundedAmount += g.amount
+		refunded = append(refunded, g.bookings...)
+	}
+
+	if len(refunded) > 0 {
+		GetQueueService().PublishEvent("SCREENING_CANCELLED", ScreeningCancelledEvent{
+			ScreeningID: screening.ID.Hex(),
+			MovieID:     screening.MovieID.Hex(),
+			StartTime:   screening.StartTime,
+			Reason:      screening.CancelReason,
+			BookingIDs:  refunded,
+		})
+	}
+	return nil
+}
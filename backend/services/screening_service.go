@@ -0,0 +1,283 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"movie-ticket-backend/config"
+	"movie-ticket-backend/database"
+	"movie-ticket-backend/models"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+var ErrScreeningNotFound = errors.New("screening not found")
+
+// maxScreeningLength bounds how far back the overlap check looks for screenings still running
+const maxScreeningLength = 8 * time.Hour
+
+// ScheduleConflict is an existing screening that occupies the requested slot in the hall
+type ScheduleConflict struct {
+	ScreeningID string    `json:"screening_id"`
+	MovieID     string    `json:"movie_id"`
+	StartTime   time.Time `json:"start_time"`
+	FreeAt      time.Time `json:"free_at"` // End of the movie plus the cleaning buffer
+}
+
+// ScreeningRescheduledEvent is published when a screening with sold seats moves, so customers are told
+type ScreeningRescheduledEvent struct {
+	ScreeningID  string    `json:"screening_id"`
+	MovieID      string    `json:"movie_id"`
+	OldStartTime time.Time `json:"old_start_time"`
+	NewStartTime time.Time `json:"new_start_time"`
+}
+
+// activeScreening excludes screenings of deleted movies
+var activeScreening = bson.M{"deleted_at": bson.M{"$exists": false}}
+
+type ScreeningService struct{}
+
+func NewScreeningService() *ScreeningService {
+	return &ScreeningService{}
+}
+
+func screeningsCollection() *mongo.Collection {
+	return database.Mongo.Collection("screenings")
+}
+
+// NewScreening creates a screening from the hall's seat map, or the default layout when hall is nil.
+// price is the STANDARD price; prices optionally overrides it per seat type.
+func NewScreening(movieID primitive.ObjectID, hall *models.Hall, startTime time.Time, price float64, prices map[models.SeatType]float64) models.Screening {
+	now := time.Now()
+	layout := models.DefaultSeatMap()
+	screening := models.Screening{
+		ID:        primitive.NewObjectID(),
+		MovieID:   movieID,
+		StartTime: startTime,
+		Price:     price,
+		Prices:    prices,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+	if hall != nil {
+		screening.CinemaID = hall.CinemaID
+		screening.HallID = hall.ID
+		layout = hall.SeatMap
+	}
+	screening.Layout = &layout
+	screening.Seats = layout.Seats()
+	screening.SeatsTotal = len(screening.Seats)
+	return screening
+}
+
+// GetByID loads a screening (with seats) by its hex ID
+func (s *ScreeningService) GetByID(ctx context.Context, screeningIDHex string) (*models.Screening, error) {
+	objID, err := primitive.ObjectIDFromHex(screeningIDHex)
+	if err != nil {
+		return nil, ErrScreeningNotFound
+	}
+	return s.findOne(ctx, bson.M{"_id": objID})
+}
+
+// FindByMovieAndTime supports clients that still identify a screening by movie + start time
+func (s *ScreeningService) FindByMovieAndTime(ctx context.Context, movieIDHex, startTime string) (*models.Screening, error) {
+	movieID, err := primitive.ObjectIDFromHex(movieIDHex)
+	if err != nil {
+		return nil, ErrScreeningNotFound
+	}
+	reqTime, err := time.Parse(time.RFC3339, startTime)
+	if err != nil {
+		return nil, ErrScreeningNotFound
+	}
+	return s.findOne(ctx, bson.M{"movie_id": movieID, "start_time": reqTime})
+}
+
+// Resolve prefers the screening ID and falls back to movie ID + start time
+func (s *ScreeningService) Resolve(ctx context.Context, screeningID, movieID, startTime string) (*models.Screening, error) {
+	if screeningID != "" {
+		return s.GetByID(ctx, screeningID)
+	}
+	return s.FindByMovieAndTime(ctx, movieID, startTime)
+}
+
+func (s *ScreeningService) findOne(ctx context.Context, filter bson.M) (*models.Screening, error) {
+	for k, v := range activeScreening {
+		filter[k] = v
+	}
+	var screening models.Screening
+	err := screeningsCollection().FindOne(ctx, filter).Decode(&screening)
+	if err == mongo.ErrNoDocuments {
+		return nil, ErrScreeningNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &screening, nil
+}
+
+// ListByMovie returns the movie's screenings ordered by start time
+func (s *ScreeningService) ListByMovie(ctx context.Context, movieID primitive.ObjectID, withSeats bool) ([]models.Screening, error) {
+	filter := bson.M{"movie_id": movieID, "deleted_at": bson.M{"$exists": false}}
+	opts := options.Find().SetSort(bson.D{{Key: "start_time", Value: 1}})
+	if !withSeats {
+		opts.SetProjection(bson.M{"seats": 0, "layout": 0})
+	}
+	cursor, err := screeningsCollection().Find(ctx, filter, opts)
+	if err != nil {
+		return nil, err
+	}
+	screenings := []models.Screening{}
+	if err := cursor.All(ctx, &screenings); err != nil {
+		return nil, err
+	}
+	return screenings, nil
+}
+
+// MarkSeatBooked flips one seat from AVAILABLE to BOOKED and counts it in seats_booked in the same write.
+// Returns false if the seat was not available.
+func (s *ScreeningService) MarkSeatBooked(ctx context.Context, screeningID primitive.ObjectID, seatID string) (bool, error) {
+	filter := bson.M{
+		"_id":        screeningID,
+		"seats":      bson.M{"$elemMatch": bson.M{"id": seatID, "status": models.SeatAvailable}}, // Concurrency check
+		"deleted_at": bson.M{"$exists": false},                                                   // Cancelled while paying
+	}
+	update := bson.M{"$set": bson.M{"seats.$.status": models.SeatBooked}, "$inc": bson.M{"seats_booked": 1}}
+	res, err := screeningsCollection().UpdateOne(ctx, filter, update)
+	if err != nil {
+		return false, err
+	}
+	return res.ModifiedCount > 0, nil
+}
+
+// HasFutureSoldSeats reports whether any upcoming screening of the movie has booked seats
+func (s *ScreeningService) HasFutureSoldSeats(ctx context.Context, movieID primitive.ObjectID) (bool, error) {
+	filter := bson.M{
+		"movie_id":     movieID,
+		"start_time":   bson.M{"$gt": time.Now()},
+		"seats.status": models.SeatBooked,
+		"deleted_at":   bson.M{"$exists": false},
+	}
+	count, err := screeningsCollection().CountDocuments(ctx, filter, options.Count().SetLimit(1))
+	return count > 0, err
+}
+
+// MovieIDsWithScreenings returns the movies that have an active screening matching the filter
+func (s *ScreeningService) MovieIDsWithScreenings(ctx context.Context, filter bson.M) ([]interface{}, error) {
+	query := bson.M{"deleted_at": bson.M{"$exists": false}}
+	for k, v := range filter {
+		query[k] = v
+	}
+	return screeningsCollection().Distinct(ctx, "movie_id", query)
+}
+
+// FindHallConflicts returns the screenings in the hall that overlap [start, start+duration+buffer).
+// Existing screenings occupy the hall for their movie's duration plus the same buffer.
+// excludeIDs are screenings being moved or replaced, which must not conflict with themselves.
+func (s *ScreeningService) FindHallConflicts(ctx context.Context, hallID primitive.ObjectID, start time.Time, durationMin int, excludeIDs ...primitive.ObjectID) ([]ScheduleConflict, error) {
+	buffer := config.AppConfig.ScreeningCleaningBuffer
+	end := start.Add(time.Duration(durationMin)*time.Minute + buffer)
+
+	filter := bson.M{
+		"hall_id":    hallID,
+		"deleted_at": bson.M{"$exists": false},
+		"start_time": bson.M{"$lt": end, "$gt": start.Add(-maxScreeningLength - buffer)},
+	}
+	if len(excludeIDs) > 0 {
+		filter["_id"] = bson.M{"$nin": excludeIDs}
+	}
+	cursor, err := screeningsCollection().Find(ctx, filter, options.Find().SetProjection(bson.M{"seats": 0, "layout": 0}))
+	if err != nil {
+		return nil, err
+	}
+	var candidates []models.Screening
+	if err := cursor.All(ctx, &candidates); err != nil {
+		return nil, err
+	}
+	if len(candidates) == 0 {
+		return nil, nil
+	}
+
+	durations, err := movieDurations(ctx, candidates)
+	if err != nil {
+		return nil, err
+	}
+	var conflicts []ScheduleConflict
+	for _, other := range candidates {
+		freeAt := other.StartTime.Add(time.Duration(durations[other.MovieID])*time.Minute + buffer)
+		if other.StartTime.Before(end) && freeAt.After(start) {
+			conflicts = append(conflicts, ScheduleConflict{
+				ScreeningID: other.ID.Hex(),
+				MovieID:     other.MovieID.Hex(),
+				StartTime:   other.StartTime,
+				FreeAt:      freeAt,
+			})
+		}
+	}
+	return conflicts, nil
+}
+
+func movieDurations(ctx context.Context, screenings []models.Screening) (map[primitive.ObjectID]int, error) {
+	var ids []primitive.ObjectID
+	for _, sc := range screenings {
+		ids = append(ids, sc.MovieID)
+	}
+	cursor, err := database.Mongo.Collection("movies").Find(ctx, bson.M{"_id": bson.M{"$in": ids}}, options.Find().SetProjection(bson.M{"duration_min": 1}))
+	if err != nil {
+		return nil, err
+	}
+	var movies []models.Movie
+	if err := cursor.All(ctx, &movies); err != nil {
+		return nil, err
+	}
+	durations := make(map[primitive.ObjectID]int, len(movies))
+	for _, m := range movies {
+		durations[m.ID] = m.DurationMin
+	}
+	return durations, nil
+}
+
+// Insert stores a new screening
+func (s *ScreeningService) Insert(ctx context.Context, screening models.Screening) error {
+	_, err := screeningsCollection().InsertOne(ctx, screening)
+	return err
+}
+
+// Reschedule moves a screening to a new start time. Existing bookings get the new time too.
+// With onlyUnsold the move is refused atomically once a seat is sold; returns false if the
+// screening was not moved.
+func (s *ScreeningService) Reschedule(ctx context.Context, screening *models.Screening, startTime time.Time, onlyUnsold bool) (bool, error) {
+	now := time.Now()
+	filter := bson.M{"_id": screening.ID, "deleted_at": bson.M{"$exists": false}}
+	if onlyUnsold {
+		filter["seats"] = bson.M{"$not": bson.M{"$elemMatch": bson.M{"status": models.SeatBooked}}}
+	}
+	res, err := screeningsCollection().UpdateOne(ctx, filter, bson.M{"$set": bson.M{"start_time": startTime, "updated_at": now}})
+	if err != nil {
+		return false, err
+	}
+	if res.MatchedCount == 0 {
+		return false, nil
+	}
+	_, err = database.Mongo.Collection("bookings").UpdateMany(ctx,
+		bson.M{"screening_id": screening.ID.Hex()},
+		bson.M{"$set": bson.M{"screen_start_time": startTime.Format(time.RFC3339)}})
+	return err == nil, err
+}
+
+// SoftDelete hides a screening that has no sold seats. Returns false if seats were sold in the meantime.
+func (s *ScreeningService) SoftDelete(ctx context.Context, screeningID primitive.ObjectID) (bool, error) {
+	now := time.Now()
+	filter := bson.M{
+		"_id":        screeningID,
+		"deleted_at": bson.M{"$exists": false},
+		"seats":      bson.M{"$not": bson.M{"$elemMatch": bson.M{"status": models.SeatBooked}}},
+	}
+	res, err := screeningsCollection().UpdateOne(ctx, filter, bson.M{"$set": bson.M{"deleted_at": now, "updated_at": now}})
+	if err != nil {
+		return false, err
+	}
+	return res.ModifiedCount > 0, nil
+}
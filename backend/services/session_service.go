@@ -0,0 +1,120 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"movie-ticket-backend/config"
+	"movie-ticket-backend/database"
+
+	"github.com/go-redis/redis/v8"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+var ErrSessionRevoked = errors.New("session has been revoked")
+
+// A session is created at login and shared by every token pair rotated from it.
+// Redis layout:
+//
+//	session:<sid>          -> user ID (TTL = refresh token TTL, extended on refresh)
+//	user_sessions:<userID> -> set of sids, used for "log out all devices"
+//
+// Deleting session:<sid> revokes the access and refresh tokens of that login at once.
+type SessionService struct {
+	RDB *redis.Client
+}
+
+func NewSessionService() *SessionService {
+	return &SessionService{
+		RDB: database.RDB,
+	}
+}
+
+func sessionKey(sid string) string {
+	return fmt.Sprintf("session:%s", sid)
+}
+
+func userSessionsKey(userID string) string {
+	return fmt.Sprintf("user_sessions:%s", userID)
+}
+
+// CreateSession starts a new login session for the user
+func (s *SessionService) CreateSession(userID string) (string, error) {
+	ctx := context.Background()
+	sid := primitive.NewObjectID().Hex()
+	ttl := config.AppConfig.RefreshTokenTTL
+
+	pipe := s.RDB.TxPipeline()
+	pipe.Set(ctx, sessionKey(sid), userID, ttl)
+	pipe.SAdd(ctx, userSessionsKey(userID), sid)
+	pipe.Expire(ctx, userSessionsKey(userID), ttl)
+	if _, err := pipe.Exec(ctx); err != nil {
+		return "", err
+	}
+	return sid, nil
+}
+
+// ValidateSession returns ErrSessionRevoked unless the session exists and belongs to the user
+func (s *SessionService) ValidateSession(sid, userID string) error {
+	if sid == "" {
+		return ErrSessionRevoked
+	}
+	owner, err := s.RDB.Get(context.Background(), sessionKey(sid)).Result()
+	if err == redis.Nil || (err == nil && owner != userID) {
+		return ErrSessionRevoked
+	}
+	return err
+}
+
+// TouchSession extends the session lifetime after a successful refresh
+func (s *SessionService) TouchSession(sid, userID string) error {
+	ctx := context.Background()
+	ttl := config.AppConfig.RefreshTokenTTL
+
+	pipe := s.RDB.TxPipeline()
+	pipe.Expire(ctx, sessionKey(sid), ttl)
+	pipe.Expire(ctx, userSessionsKey(userID), ttl)
+	_, err := pipe.Exec(ctx)
+	return err
+}
+
+// RevokeSession ends a single login and closes its WebSocket connections
+func (s *SessionService) RevokeSession(sid, userID string) error {
+	ctx := context.Background()
+
+	pipe := s.RDB.TxPipeline()
+	pipe.Del(ctx, sessionKey(sid))
+	pipe.SRem(ctx, userSessionsKey(userID), sid)
+	if _, err := pipe.Exec(ctx); err != nil {
+		return err
+	}
+
+	if WSHub != nil {
+		WSHub.DisconnectSession(sid)
+	}
+	return nil
+}
+
+// RevokeAllSessions ends every login of the user and closes all their WebSocket connections.
+// Returns the number of sessions that were revoked.
+func (s *SessionService) RevokeAllSessions(userID string) (int, error) {
+	ctx := context.Background()
+
+	sids, err := s.RDB.SMembers(ctx, userSessionsKey(userID)).Result()
+	if err != nil && err != redis.Nil {
+		return 0, err
+	}
+
+	keys := []string{userSessionsKey(userID)}
+	for _, sid := range sids {
+		keys = append(keys, sessionKey(sid))
+	}
+	if err := s.RDB.Del(ctx, keys...).Err(); err != nil {
+		return 0, err
+	}
+
+	if WSHub != nil {
+		WSHub.DisconnectUser(userID)
+	}
+	return len(sids), nil
+}
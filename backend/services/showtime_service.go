@@ -0,0 +1,209 @@
+package services
+
+import (
+	"context"
+	"log"
+	"movie-ticket-backend/database"
+	"movie-ticket-backend/models"
+	"sort"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// Showtime is one screening in the showtimes grid
+type Showtime struct {
+	ScreeningID  string                  `json:"screening_id"`
+	StartTime    time.Time               `json:"start_time"`
+	EndTime      time.Time               `json:"end_time"` // Start plus the movie duration
+	Format       models.ScreeningFormat  `json:"format"`
+	Audio        models.AudioVersion     `json:"audio"`
+	MinPrice     float64                 `json:"min_price"` // Cheapest seat type after pricing rules
+	MaxPrice     float64                 `json:"max_price"`
+	Currency     string                  `json:"currency"`
+	Availability models.SeatAvailability `json:"availability"`
+
+	// Kept for clients written before availability; same numbers as Availability.Total/Available
+	SeatsTotal     int `json:"seats_total"`
+	SeatsAvailable int `json:"seats_available"`
+}
+
+type HallShowtimes struct {
+	HallID    string     `json:"hall_id"`
+	Name      string     `json:"name"`
+	Showtimes []Showtime `json:"showtimes"`
+}
+
+type MovieShowtimes struct {
+	MovieID        string           `json:"movie_id"`
+	Title          string           `json:"title"`
+	PosterURL      string           `json:"poster_url"`
+	PosterThumbURL string           `json:"poster_thumb_url,omitempty"`
+	DurationMin    int              `json:"duration_min"`
+	AgeRating      models.AgeRating `json:"age_rating,omitempty"`
+	Genres         []string         `json:"genres"`
+	Halls          []HallShowtimes  `json:"halls"`
+}
+
+// CinemaShowtimes is one cinema's programme for a local calendar day
+type CinemaShowtimes struct {
+	CinemaID string           `json:"cinema_id"`
+	Name     string           `json:"name"`
+	Timezone string           `json:"timezone"`
+	Date     string           `json:"date"` // YYYY-MM-DD in the cinema's timezone
+	Movies   []MovieShowtimes `json:"movies"`
+}
+
+type ShowtimeService struct{}
+
+func NewShowtimeService() *ShowtimeService {
+	return &ShowtimeService{}
+}
+
+// ForDay builds the showtimes grid of a cinema for a day in its own timezone (today when date is empty)
+func (s *ShowtimeService) ForDay(ctx context.Context, cinema models.Cinema, date string, rules []models.PricingRule) (*CinemaShowtimes, error) {
+	loc := cinema.Location()
+	day := time.Now().In(loc)
+	if date != "" {
+		var err error
+		if day, err = time.ParseInLocation("2006-01-02", date, loc); err != nil {
+			return nil, err
+		}
+	}
+	start := time.Date(day.Year(), day.Month(), day.Day(), 0, 0, 0, 0, loc)
+	grid := &CinemaShowtimes{
+		CinemaID: cinema.ID.Hex(),
+		Name:     cinema.Name,
+		Timezone: cinema.Timezone,
+		Date:     start.Format("2006-01-02"),
+		Movies:   []MovieShowtimes{},
+	}
+
+	filter := bson.M{
+		"cinema_id":  cinema.ID,
+		"start_time": bson.M{"$gte": start, "$lt": start.AddDate(0, 0, 1)},
+		"deleted_at": bson.M{"$exists": false},
+	}
+	opts := options.Find().SetSort(bson.D{{Key: "start_time", Value: 1}}).SetProjection(bson.M{"layout": 0})
+	cursor, err := screeningsCollection().Find(ctx, filter, opts)
+	if err != nil {
+		return nil, err
+	}
+	var screenings []models.Screening
+	if err := cursor.All(ctx, &screenings); err != nil {
+		return nil, err
+	}
+	if len(screenings) == 0 {
+		return grid, nil
+	}
+
+	movies, err := s.moviesOf(ctx, screenings)
+	if err != nil {
+		return nil, err
+	}
+	halls, err := NewCinemaService().ListHalls(ctx, cinema.ID.Hex())
+	if err != nil {
+		return nil, err
+	}
+	hallNames := make(map[primitive.ObjectID]string, len(halls))
+	for _, h := range halls {
+		hallNames[h.ID] = h.Name
+	}
+	// Like the movie listing, a Redis outage only drops the seat counts: the grid still loads as UNKNOWN
+	if err := AttachAvailability(ctx, screenings); err != nil {
+		log.Printf("Showtimes: cannot count seat locks for cinema %s: %v", cinema.ID.Hex(), err)
+	}
+
+	pricingService := NewPricingService()
+	byMovie := make(map[primitive.ObjectID]*MovieShowtimes)
+	byHall := make(map[[2]primitive.ObjectID]*HallShowtimes)
+	for i := range screenings {
+		sc := &screenings[i]
+		movie, ok := movies[sc.MovieID]
+		if !ok {
+			continue
+		}
+		entry, ok := byMovie[movie.ID]
+		if !ok {
+			entry = &MovieShowtimes{
+				MovieID:        movie.ID.Hex(),
+				Title:          movie.Title,
+				PosterURL:      movie.PosterURL,
+				PosterThumbURL: movie.PosterThumbURL,
+				DurationMin:    movie.DurationMin,
+				AgeRating:      movie.AgeRating,
+				Genres:         movie.Genres,
+			}
+			byMovie[movie.ID] = entry
+		}
+		hallKey := [2]primitive.ObjectID{movie.ID, sc.HallID}
+		hall, ok := byHall[hallKey]
+		if !ok {
+			hall = &HallShowtimes{HallID: sc.HallID.Hex(), Name: hallNames[sc.HallID]}
+			byHall[hallKey] = hall
+		}
+
+		minPrice, maxPrice := pricingService.PriceRange(sc, rules, loc)
+		availability := models.NewSeatAvailability(0, 0, 0)
+		if sc.Availability != nil {
+			availability = *sc.Availability
+		}
+		showtime := Showtime{
+			ScreeningID:    sc.ID.Hex(),
+			StartTime:      sc.StartTime,
+			EndTime:        sc.StartTime.Add(time.Duration(movie.DurationMin) * time.Minute),
+			Format:         sc.Format,
+			Audio:          sc.Audio,
+			MinPrice:       minPrice,
+			MaxPrice:       maxPrice,
+			Currency:       Currency,
+			Availability:   availability,
+			SeatsTotal:     availability.Total,
+			SeatsAvailable: availability.Available,
+		}
+		if showtime.Format == "" {
+			showtime.Format = models.Format2D
+		}
+		if showtime.Audio == "" {
+			showtime.Audio = models.AudioOriginal
+		}
+		hall.Showtimes = append(hall.Showtimes, showtime)
+	}
+
+	// Movies by title, halls by name; showtimes are already in start order
+	for movieID, entry := range byMovie {
+		for key, hall := range byHall {
+			if key[0] == movieID {
+				entry.Halls = append(entry.Halls, *hall)
+			}
+		}
+		sort.Slice(entry.Halls, func(i, j int) bool { return entry.Halls[i].Name < entry.Halls[j].Name })
+		grid.Movies = append(grid.Movies, *entry)
+	}
+	sort.Slice(grid.Movies, func(i, j int) bool { return grid.Movies[i].Title < grid.Movies[j].Title })
+	return grid, nil
+}
+
+// moviesOf loads the movies of the screenings, leaving out deleted ones
+func (s *ShowtimeService) moviesOf(ctx context.Context, screenings []models.Screening) (map[primitive.ObjectID]models.Movie, error) {
+	ids := make([]primitive.ObjectID, 0, len(screenings))
+	for _, sc := range screenings {
+		ids = append(ids, sc.MovieID)
+	}
+	filter := bson.M{"_id": bson.M{"$in": ids}, "deleted_at": bson.M{"$exists": false}}
+	cursor, err := database.Mongo.Collection("movies").Find(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+	var movies []models.Movie
+	if err := cursor.All(ctx, &movies); err != nil {
+		return nil, err
+	}
+	byID := make(map[primitive.ObjectID]models.Movie, len(movies))
+	for _, m := range movies {
+		byID[m.ID] = m
+	}
+	return byID, nil
+}
@@ -0,0 +1,102 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"movie-ticket-backend/config"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+var ErrObjectNotFound = errors.New("stored object not found")
+
+// Storage keeps uploaded files. Keys are slash separated paths, e.g. "media/<id>/thumb.jpg".
+// The local filesystem is the only implementation so far; an S3-compatible one only needs these methods.
+type Storage interface {
+	Put(ctx context.Context, key string, r io.Reader, contentType string) error
+	Get(ctx context.Context, key string) (io.ReadCloser, error)
+	Delete(ctx context.Context, key string) error
+}
+
+var MediaStorage Storage
+
+// InitStorage selects the storage backend from MEDIA_STORAGE
+func InitStorage() {
+	cfg := config.AppConfig
+	switch cfg.MediaStorage {
+	case "", "local":
+		MediaStorage = NewLocalStorage(cfg.MediaLocalDir)
+	default:
+		log.Printf("Unknown MEDIA_STORAGE %q, falling back to local storage", cfg.MediaStorage)
+		MediaStorage = NewLocalStorage(cfg.MediaLocalDir)
+	}
+}
+
+// LocalStorage stores objects as files under Root
+type LocalStorage struct {
+	Root string
+}
+
+func NewLocalStorage(root string) *LocalStorage {
+	return &LocalStorage{Root: root}
+}
+
+// path maps a key to a file under Root, refusing keys that would escape it
+func (s *LocalStorage) path(key string) (string, error) {
+	clean := filepath.Clean(filepath.FromSlash(key))
+	if clean == "." || clean == ".." || filepath.IsAbs(clean) || strings.HasPrefix(clean, ".."+string(filepath.Separator)) {
+		return "", fmt.Errorf("invalid storage key %q", key)
+	}
+	return filepath.Join(s.Root, clean), nil
+}
+
+// Put writes to a temporary file first so readers never see a partial object
+func (s *LocalStorage) Put(ctx context.Context, key string, r io.Reader, contentType string) error {
+	path, err := s.path(key)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".upload-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := io.Copy(tmp, r); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+func (s *LocalStorage) Get(ctx context.Context, key string) (io.ReadCloser, error) {
+	path, err := s.path(key)
+	if err != nil {
+		return nil, err
+	}
+	f, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return nil, ErrObjectNotFound
+	}
+	return f, err
+}
+
+func (s *LocalStorage) Delete(ctx context.Context, key string) error {
+	path, err := s.path(key)
+	if err != nil {
+		return err
+	}
+	if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,46 @@
+package services
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func TestLocalStoragePath(t *testing.T) {
+	root := filepath.Join("srv", "uploads")
+	storage := NewLocalStorage(root)
+
+	tests := []struct {
+		key     string
+		want    string // Relative to root
+		wantErr bool
+	}{
+		{key: "posters/abc.jpg", want: "posters/abc.jpg"},
+		{key: "posters//abc.jpg", want: "posters/abc.jpg"},
+		{key: "posters/./abc.jpg", want: "posters/abc.jpg"},
+		{key: "posters/../backdrops/abc.jpg", want: "backdrops/abc.jpg"},
+		{key: "..abc.jpg", want: "..abc.jpg"},
+		{key: "", wantErr: true},
+		{key: ".", wantErr: true},
+		{key: "..", wantErr: true},
+		{key: "../secret", wantErr: true},
+		{key: "posters/../../secret", wantErr: true},
+		{key: "/etc/passwd", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.key, func(t *testing.T) {
+			got, err := storage.path(tt.key)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("path(%q) = %q, want an error", tt.key, got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("path(%q) failed: %v", tt.key, err)
+			}
+			if want := filepath.Join(root, filepath.FromSlash(tt.want)); got != want {
+				t.Errorf("path(%q) = %q, want %q", tt.key, got, want)
+			}
+		})
+	}
+}
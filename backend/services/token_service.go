@@ -0,0 +1,366 @@
+package services
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"log"
+	"movie-ticket-backend/config"
+	"movie-ticket-backend/database"
+	"movie-ticket-backend/models"
+	"os"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+var (
+	ErrInvalidRefreshToken = errors.New("refresh token is invalid or expired")
+	ErrInvalidLoginCode    = errors.New("login code is invalid or expired")
+	ErrAccountSuspended    = errors.New("account suspended")
+	ErrInvalidAccessToken  = errors.New("access token is invalid or expired")
+)
+
+// loginCodeTTL is how long the frontend has to exchange the code after the OAuth redirect
+const loginCodeTTL = 60 * time.Second
+
+// AccessClaims is the payload of the access token handed to the frontend
+type AccessClaims struct {
+	RegisteredClaims
+	Role      models.Role `json:"role"`
+	SessionID string      `json:"sid"`
+}
+
+// LoginResult is what a one-time login code resolves to
+type LoginResult struct {
+	Tokens TokenPair   `json:"tokens"`
+	User   models.User `json:"user"`
+}
+
+// refreshTokenRecord is what a refresh token hash points to in Redis
+type refreshTokenRecord struct {
+	UserID    string `json:"user_id"`
+	SessionID string `json:"sid"`
+}
+
+// TokenPair is returned after login and on every refresh
+type TokenPair struct {
+	AccessToken  string    `json:"token"`
+	RefreshToken string    `json:"refresh_token"`
+	ExpiresAt    time.Time `json:"expires_at"`
+}
+
+// signingKeys holds the keys loaded once by InitTokenService
+type signingKeys struct {
+	alg        string
+	secret     []byte
+	privateKey *rsa.PrivateKey
+	publicKey  *rsa.PublicKey
+}
+
+var tokenKeys *signingKeys
+
+type TokenService struct {
+	RDB *redis.Client
+}
+
+func NewTokenService() *TokenService {
+	return &TokenService{
+		RDB: database.RDB,
+	}
+}
+
+// InitTokenService loads the JWT signing keys from config. Misconfiguration is fatal.
+func InitTokenService() {
+	keys, err := loadSigningKeys()
+	if err != nil {
+		log.Fatalf("Failed to load JWT signing keys: %v", err)
+	}
+	tokenKeys = keys
+	fmt.Printf("Token Service initialized (%s)...\n", keys.alg)
+}
+
+func loadSigningKeys() (*signingKeys, error) {
+	cfg := config.AppConfig
+	switch cfg.JWTAlgorithm {
+	case "HS256":
+		secret := []byte(cfg.JWTSecret)
+		if len(secret) == 0 {
+			// Dev fallback: tokens will not survive a restart
+			log.Println("WARNING: JWT_SECRET is not set, generating a random secret for this process")
+			secret = make([]byte, 32)
+			if _, err := rand.Read(secret); err != nil {
+				return nil, err
+			}
+		} else if len(secret) < 32 {
+			return nil, fmt.Errorf("JWT_SECRET must be at least 32 bytes")
+		}
+		return &signingKeys{alg: "HS256", secret: secret}, nil
+	case "RS256":
+		privateKey, err := readRSAPrivateKey(cfg.JWTPrivateKeyPath)
+		if err != nil {
+			return nil, err
+		}
+		publicKey := &privateKey.PublicKey
+		if cfg.JWTPublicKeyPath != "" {
+			if publicKey, err = readRSAPublicKey(cfg.JWTPublicKeyPath); err != nil {
+				return nil, err
+			}
+		}
+		return &signingKeys{alg: "RS256", privateKey: privateKey, publicKey: publicKey}, nil
+	default:
+		return nil, fmt.Errorf("unsupported JWT_ALGORITHM %q", cfg.JWTAlgorithm)
+	}
+}
+
+func readRSAPrivateKey(path string) (*rsa.PrivateKey, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, fmt.Errorf("no PEM data in %s", path)
+	}
+	if key, err := x509.ParsePKCS1PrivateKey(block.Bytes); err == nil {
+		return key, nil
+	}
+	parsed, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, err
+	}
+	key, ok := parsed.(*rsa.PrivateKey)
+	if !ok {
+		return nil, fmt.Errorf("%s is not an RSA private key", path)
+	}
+	return key, nil
+}
+
+func readRSAPublicKey(path string) (*rsa.PublicKey, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, fmt.Errorf("no PEM data in %s", path)
+	}
+	parsed, err := x509.ParsePKIXPublicKey(block.Bytes)
+	if err != nil {
+		return nil, err
+	}
+	key, ok := parsed.(*rsa.PublicKey)
+	if !ok {
+		return nil, fmt.Errorf("%s is not an RSA public key", path)
+	}
+	return key, nil
+}
+
+// IssueTokenPair starts a new session and creates its first access + refresh token
+func (s *TokenService) IssueTokenPair(user models.User) (*TokenPair, error) {
+	sid, err := NewSessionService().CreateSession(user.ID.Hex())
+	if err != nil {
+		return nil, err
+	}
+	return s.issueSessionTokens(user, sid)
+}
+
+// issueSessionTokens creates a token pair bound to an existing session
+func (s *TokenService) issueSessionTokens(user models.User, sid string) (*TokenPair, error) {
+	now := time.Now()
+	expiresAt := now.Add(config.AppConfig.AccessTokenTTL)
+
+	claims := AccessClaims{
+		RegisteredClaims: RegisteredClaims{
+			Issuer:    config.AppConfig.JWTIssuer,
+			Subject:   user.ID.Hex(),
+			IssuedAt:  now.Unix(),
+			NotBefore: now.Unix(),
+			ExpiresAt: expiresAt.Unix(),
+			ID:        primitive.NewObjectID().Hex(),
+		},
+		Role:      user.Role,
+		SessionID: sid,
+	}
+
+	var signingKey interface{} = tokenKeys.secret
+	if tokenKeys.alg == "RS256" {
+		signingKey = tokenKeys.privateKey
+	}
+	accessToken, err := signJWT(tokenKeys.alg, "", signingKey, claims)
+	if err != nil {
+		return nil, err
+	}
+
+	refreshToken, err := s.createRefreshToken(refreshTokenRecord{UserID: user.ID.Hex(), SessionID: sid})
+	if err != nil {
+		return nil, err
+	}
+
+	return &TokenPair{
+		AccessToken:  accessToken,
+		RefreshToken: refreshToken,
+		ExpiresAt:    expiresAt,
+	}, nil
+}
+
+// ParseAccessToken verifies signature, issuer and expiry of an access token
+func (s *TokenService) ParseAccessToken(token string) (*AccessClaims, error) {
+	var claims AccessClaims
+	err := parseJWT(token, func(header jwtHeader) (interface{}, error) {
+		// Never let the token choose the algorithm
+		if header.Alg != tokenKeys.alg {
+			return nil, ErrTokenSignature
+		}
+		if tokenKeys.alg == "RS256" {
+			return tokenKeys.publicKey, nil
+		}
+		return tokenKeys.secret, nil
+	}, &claims)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := claims.Valid(time.Now()); err != nil {
+		return nil, err
+	}
+	if claims.Issuer != config.AppConfig.JWTIssuer {
+		return nil, fmt.Errorf("unexpected token issuer")
+	}
+	return &claims, nil
+}
+
+// AuthenticateAccessToken is the single entry point for request authentication: it verifies
+// the token, checks the session has not been revoked and loads the user through the cache.
+func (s *TokenService) AuthenticateAccessToken(ctx context.Context, token string) (*AccessClaims, *models.User, error) {
+	claims, err := s.ParseAccessToken(token)
+	if err != nil {
+		return nil, nil, ErrInvalidAccessToken
+	}
+	if err := NewSessionService().ValidateSession(claims.SessionID, claims.Subject); err != nil {
+		return nil, nil, err
+	}
+	user, err := GetCachedUser(ctx, claims.Subject)
+	if err != nil {
+		return nil, nil, err
+	}
+	if user.IsSuspended() {
+		return nil, nil, ErrAccountSuspended
+	}
+	return claims, user, nil
+}
+
+// RotateRefreshToken consumes a refresh token (single use) and issues a fresh pair
+func (s *TokenService) RotateRefreshToken(refreshToken string) (*TokenPair, *models.User, error) {
+	ctx := context.Background()
+	key := refreshTokenKey(refreshToken)
+
+	// GETDEL makes the old token unusable even if two refreshes race
+	val, err := s.RDB.GetDel(ctx, key).Result()
+	if err == redis.Nil {
+		return nil, nil, ErrInvalidRefreshToken
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var record refreshTokenRecord
+	if err := json.Unmarshal([]byte(val), &record); err != nil {
+		return nil, nil, ErrInvalidRefreshToken
+	}
+	objID, err := primitive.ObjectIDFromHex(record.UserID)
+	if err != nil {
+		return nil, nil, ErrInvalidRefreshToken
+	}
+
+	// Logout / revocation deletes the session, which invalidates its refresh tokens too
+	sessions := NewSessionService()
+	if err := sessions.ValidateSession(record.SessionID, record.UserID); err == ErrSessionRevoked {
+		return nil, nil, ErrInvalidRefreshToken
+	} else if err != nil {
+		return nil, nil, err
+	}
+
+	// Reload the user so the new access token carries the current role
+	var user models.User
+	err = database.Mongo.Collection("users").FindOne(ctx, bson.M{"_id": objID}).Decode(&user)
+	if err != nil || user.IsSuspended() {
+		return nil, nil, ErrInvalidRefreshToken
+	}
+
+	if err := sessions.TouchSession(record.SessionID, record.UserID); err != nil {
+		return nil, nil, err
+	}
+	pair, err := s.issueSessionTokens(user, record.SessionID)
+	if err != nil {
+		return nil, nil, err
+	}
+	return pair, &user, nil
+}
+
+func (s *TokenService) createRefreshToken(record refreshTokenRecord) (string, error) {
+	token, err := randomToken(32)
+	if err != nil {
+		return "", err
+	}
+	val, err := json.Marshal(record)
+	if err != nil {
+		return "", err
+	}
+
+	// Only the hash is stored so a Redis dump does not leak usable tokens
+	err = s.RDB.Set(context.Background(), refreshTokenKey(token), val, config.AppConfig.RefreshTokenTTL).Err()
+	if err != nil {
+		return "", err
+	}
+	return token, nil
+}
+
+func refreshTokenKey(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return fmt.Sprintf("refresh_token:%s", hex.EncodeToString(sum[:]))
+}
+
+// CreateLoginCode stores the login result under a one-time code so tokens never appear in a redirect URL
+func (s *TokenService) CreateLoginCode(tokens TokenPair, user models.User) (string, error) {
+	code, err := randomToken(32)
+	if err != nil {
+		return "", err
+	}
+	val, err := json.Marshal(LoginResult{Tokens: tokens, User: user})
+	if err != nil {
+		return "", err
+	}
+	key := fmt.Sprintf("login_code:%s", code)
+	if err := s.RDB.Set(context.Background(), key, val, loginCodeTTL).Err(); err != nil {
+		return "", err
+	}
+	return code, nil
+}
+
+// ExchangeLoginCode resolves and deletes a login code (single use)
+func (s *TokenService) ExchangeLoginCode(code string) (*LoginResult, error) {
+	key := fmt.Sprintf("login_code:%s", code)
+	val, err := s.RDB.GetDel(context.Background(), key).Result()
+	if err == redis.Nil {
+		return nil, ErrInvalidLoginCode
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var result LoginResult
+	if err := json.Unmarshal([]byte(val), &result); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
@@ -0,0 +1,89 @@
+package services
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"movie-ticket-backend/config"
+)
+
+func TestParseAccessToken(t *testing.T) {
+	secret := []byte("0123456789abcdef0123456789abcdef")
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	publicDER := x509.MarshalPKCS1PublicKey(&rsaKey.PublicKey)
+
+	defer func(issuer string) { config.AppConfig.JWTIssuer = issuer }(config.AppConfig.JWTIssuer)
+	config.AppConfig.JWTIssuer = "movie-ticket-backend"
+	now := time.Now()
+	claims := func(issuer string, expiresAt, notBefore time.Time) AccessClaims {
+		return AccessClaims{RegisteredClaims: RegisteredClaims{
+			Issuer: issuer, Subject: "u1", IssuedAt: now.Unix(), NotBefore: notBefore.Unix(), ExpiresAt: expiresAt.Unix(),
+		}}
+	}
+	valid := claims("movie-ticket-backend", now.Add(time.Minute), now)
+	sign := func(alg string, key interface{}, c AccessClaims) string {
+		token, err := signJWT(alg, "", key, c)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return token
+	}
+	// Same claims, "alg": "none" and no signature
+	unsigned := func(c AccessClaims) string {
+		parts := strings.Split(sign("HS256", secret, c), ".")
+		header := base64.RawURLEncoding.EncodeToString([]byte(`{"alg":"none","typ":"JWT"}`))
+		return header + "." + parts[1] + "."
+	}
+
+	hs256 := &signingKeys{alg: "HS256", secret: secret}
+	rs256 := &signingKeys{alg: "RS256", privateKey: rsaKey, publicKey: &rsaKey.PublicKey}
+
+	tests := []struct {
+		name    string
+		keys    *signingKeys
+		token   string
+		wantErr error // nil = accepted; errAny = any error
+	}{
+		{"valid HS256", hs256, sign("HS256", secret, valid), nil},
+		{"valid RS256", rs256, sign("RS256", rsaKey, valid), nil},
+		{"wrong HS256 secret", hs256, sign("HS256", []byte("another-secret-another-secret-xx"), valid), ErrTokenSignature},
+		{"RS256 token when HS256 is configured", hs256, sign("RS256", rsaKey, valid), ErrTokenSignature},
+		{"HS256 signed with the public key when RS256 is configured", rs256, sign("HS256", publicDER, valid), ErrTokenSignature},
+		{"alg none", hs256, unsigned(valid), ErrTokenSignature},
+		{"bad issuer", hs256, sign("HS256", secret, claims("someone-else", now.Add(time.Minute), now)), errAny},
+		{"expired", hs256, sign("HS256", secret, claims("movie-ticket-backend", now.Add(-time.Minute), now.Add(-time.Hour))), ErrTokenExpired},
+		{"within the clock skew leeway", hs256, sign("HS256", secret, claims("movie-ticket-backend", now.Add(-10*time.Second), now.Add(-time.Hour))), nil},
+		{"not valid yet", hs256, sign("HS256", secret, claims("movie-ticket-backend", now.Add(time.Hour), now.Add(10*time.Minute))), errAny},
+		{"malformed", hs256, "not-a-token", ErrTokenMalformed},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tokenKeys = tt.keys
+			defer func() { tokenKeys = nil }()
+
+			parsed, err := NewTokenService().ParseAccessToken(tt.token)
+			switch {
+			case tt.wantErr == nil && err != nil:
+				t.Fatalf("ParseAccessToken rejected a valid token: %v", err)
+			case tt.wantErr == nil && parsed.Subject != "u1":
+				t.Fatalf("subject = %q, want u1", parsed.Subject)
+			case tt.wantErr == errAny && err == nil:
+				t.Fatal("ParseAccessToken accepted the token")
+			case tt.wantErr != nil && tt.wantErr != errAny && !errors.Is(err, tt.wantErr):
+				t.Fatalf("err = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+// errAny marks cases where any error is acceptable
+var errAny = errors.New("any error")
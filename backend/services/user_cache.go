@@ -0,0 +1,215 @@
+package services
+
+import (
+	"container/list"
+	"context"
+	"fmt"
+	"log"
+	"movie-ticket-backend/database"
+	"movie-ticket-backend/models"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+const (
+	// localUserTTL is short because another instance may miss an invalidation message
+	localUserTTL       = 30 * time.Second
+	localUserCacheSize = 10000
+	redisUserTTL       = 5 * time.Minute
+
+	userInvalidateChannel = "user_cache_invalidate"
+)
+
+// UserCache sits in front of the users collection for the auth middleware.
+// Lookup order: in-process LRU -> Redis (user_cache:<id>, BSON) -> Mongo.
+// Every write to a user must call InvalidateUser. Cached users never carry credentials.
+type UserCache struct {
+	RDB *redis.Client
+
+	mu            sync.Mutex
+	entries       map[string]*list.Element
+	order         *list.List // front = most recently used
+	invalidations uint64     // Bumped on every local removal; a load that saw a bump is not kept
+}
+
+type cachedUser struct {
+	userID    string
+	user      models.User
+	expiresAt time.Time
+}
+
+// sessionUser is the Redis form of a user: the user itself with the password hash cleared, which
+// omitempty then leaves out. Generation is the user's invalidation count when it was loaded; an
+// entry from an older generation was loaded before the last write and is ignored.
+type sessionUser struct {
+	Generation int64       `bson:"generation"`
+	User       models.User `bson:"user"`
+}
+
+func newSessionUser(u models.User, generation int64) sessionUser {
+	u.PasswordHash = ""
+	return sessionUser{Generation: generation, User: u}
+}
+
+var userCache *UserCache
+
+// InitUserCache creates the shared cache and listens for invalidations from other instances
+func InitUserCache() {
+	userCache = &UserCache{
+		RDB:     database.RDB,
+		entries: make(map[string]*list.Element),
+		order:   list.New(),
+	}
+	go userCache.listenForInvalidations()
+	fmt.Println("User Cache initialized...")
+}
+
+func userCacheKey(userID string) string {
+	return fmt.Sprintf("user_cache:%s", userID)
+}
+
+// userGenerationKey counts the invalidations of a user. It outlives any entry written before
+// its last bump, so an expired counter can never make a stale entry current again.
+func userGenerationKey(userID string) string {
+	return fmt.Sprintf("user_cache_gen:%s", userID)
+}
+
+// GetCachedUser returns the user, loading it from Mongo on a miss. The password hash is not
+// included; credential checks read the users collection directly.
+func GetCachedUser(ctx context.Context, userID string) (*models.User, error) {
+	if userCache == nil {
+		return NewUserService().GetUserByID(ctx, userID)
+	}
+	return userCache.Get(ctx, userID)
+}
+
+// InvalidateUser drops the user from every cache layer on every instance
+func InvalidateUser(userID string) {
+	if userCache == nil {
+		return
+	}
+	userCache.Invalidate(userID)
+}
+
+func (uc *UserCache) Get(ctx context.Context, userID string) (*models.User, error) {
+	if user, ok := uc.getLocal(userID); ok {
+		return &user, nil
+	}
+	localGen := uc.localGeneration()
+
+	// One round trip for the entry and the generation it must match
+	var generation int64
+	values, err := uc.RDB.MGet(ctx, userCacheKey(userID), userGenerationKey(userID)).Result()
+	if err == nil {
+		if gen, ok := values[1].(string); ok {
+			generation, _ = strconv.ParseInt(gen, 10, 64)
+		}
+		if data, ok := values[0].(string); ok {
+			var cached sessionUser
+			// The ID check also skips entries written in an older layout
+			if err := bson.Unmarshal([]byte(data), &cached); err == nil && cached.Generation == generation && cached.User.ID.Hex() == userID {
+				user := cached.User
+				uc.setLocal(userID, user, localGen)
+				return &user, nil
+			}
+		}
+	}
+
+	user, err := NewUserService().GetUserByID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	user.PasswordHash = ""
+	// Tagged with the generation read before the load: if the user was written meanwhile the
+	// counter has moved on and this entry is never served
+	if data, err := bson.Marshal(newSessionUser(*user, generation)); err == nil {
+		uc.RDB.Set(ctx, userCacheKey(userID), data, redisUserTTL)
+	}
+	uc.setLocal(userID, *user, localGen)
+	return user, nil
+}
+
+func (uc *UserCache) Invalidate(userID string) {
+	ctx := context.Background()
+	uc.removeLocal(userID)
+	pipe := uc.RDB.TxPipeline()
+	pipe.Incr(ctx, userGenerationKey(userID))
+	pipe.Expire(ctx, userGenerationKey(userID), 2*redisUserTTL)
+	pipe.Del(ctx, userCacheKey(userID))
+	if _, err := pipe.Exec(ctx); err != nil {
+		log.Printf("Failed to invalidate cached user %s: %v", userID, err)
+	}
+	uc.RDB.Publish(ctx, userInvalidateChannel, userID)
+}
+
+func (uc *UserCache) listenForInvalidations() {
+	pubsub := uc.RDB.Subscribe(context.Background(), userInvalidateChannel)
+	for msg := range pubsub.Channel() {
+		uc.removeLocal(msg.Payload)
+	}
+}
+
+func (uc *UserCache) getLocal(userID string) (models.User, bool) {
+	uc.mu.Lock()
+	defer uc.mu.Unlock()
+
+	elem, ok := uc.entries[userID]
+	if !ok {
+		return models.User{}, false
+	}
+	entry := elem.Value.(*cachedUser)
+	if time.Now().After(entry.expiresAt) {
+		uc.order.Remove(elem)
+		delete(uc.entries, userID)
+		return models.User{}, false
+	}
+	uc.order.MoveToFront(elem)
+	return entry.user, true
+}
+
+func (uc *UserCache) localGeneration() uint64 {
+	uc.mu.Lock()
+	defer uc.mu.Unlock()
+	return uc.invalidations
+}
+
+// setLocal keeps the user unless some user was invalidated since generation was read, in which
+// case the copy may predate that write. Invalidations are rare next to reads, so the odd skipped
+// entry is cheaper than tracking a counter per user.
+func (uc *UserCache) setLocal(userID string, user models.User, generation uint64) {
+	uc.mu.Lock()
+	defer uc.mu.Unlock()
+
+	if uc.invalidations != generation {
+		return
+	}
+
+	entry := &cachedUser{userID: userID, user: user, expiresAt: time.Now().Add(localUserTTL)}
+	if elem, ok := uc.entries[userID]; ok {
+		elem.Value = entry
+		uc.order.MoveToFront(elem)
+		return
+	}
+	uc.entries[userID] = uc.order.PushFront(entry)
+
+	if uc.order.Len() > localUserCacheSize {
+		oldest := uc.order.Back()
+		uc.order.Remove(oldest)
+		delete(uc.entries, oldest.Value.(*cachedUser).userID)
+	}
+}
+
+func (uc *UserCache) removeLocal(userID string) {
+	uc.mu.Lock()
+	defer uc.mu.Unlock()
+
+	uc.invalidations++
+	if elem, ok := uc.entries[userID]; ok {
+		uc.order.Remove(elem)
+		delete(uc.entries, userID)
+	}
+}
@@ -0,0 +1,68 @@
+package services
+
+import (
+	"container/list"
+	"testing"
+
+	"movie-ticket-backend/models"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestSessionUserDropsCredentials(t *testing.T) {
+	user := models.User{
+		ID:              primitive.NewObjectID(),
+		Email:           "a@example.com",
+		Role:            models.RoleUser,
+		RoleAssignments: []models.RoleAssignment{{Role: models.RoleUsher, CinemaID: "c1"}},
+		PasswordHash:    "$2a$10$secret",
+		Identities:      []models.LinkedIdentity{{Provider: "local", Subject: "a@example.com"}},
+	}
+
+	data, err := bson.Marshal(newSessionUser(user, 3))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var raw struct {
+		User bson.M `bson:"user"`
+	}
+	if err := bson.Unmarshal(data, &raw); err != nil {
+		t.Fatal(err)
+	}
+	if _, found := raw.User["password_hash"]; found {
+		t.Fatal("password_hash was written to the cache")
+	}
+	if user.PasswordHash == "" {
+		t.Fatal("caching cleared the caller's password hash")
+	}
+
+	var cached sessionUser
+	if err := bson.Unmarshal(data, &cached); err != nil {
+		t.Fatal(err)
+	}
+	got := cached.User
+	if cached.Generation != 3 || got.ID != user.ID || got.Email != user.Email || len(got.RoleAssignments) != 1 || len(got.Identities) != 1 {
+		t.Fatalf("round trip lost fields: %+v", got)
+	}
+	if got.PasswordHash != "" {
+		t.Fatal("password hash came back from the cache")
+	}
+}
+
+func TestSetLocalSkipsLoadsOlderThanAnInvalidation(t *testing.T) {
+	uc := &UserCache{entries: make(map[string]*list.Element), order: list.New()}
+	user := models.User{Email: "a@example.com"}
+
+	gen := uc.localGeneration()
+	uc.removeLocal("u1") // A write lands while u1 is being loaded
+	uc.setLocal("u1", user, gen)
+	if _, ok := uc.getLocal("u1"); ok {
+		t.Fatal("stale load was cached after an invalidation")
+	}
+
+	uc.setLocal("u1", user, uc.localGeneration())
+	if _, ok := uc.getLocal("u1"); !ok {
+		t.Fatal("fresh load was not cached")
+	}
+}
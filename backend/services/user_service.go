@@ -0,0 +1,267 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"movie-ticket-backend/config"
+	"movie-ticket-backend/database"
+	"movie-ticket-backend/models"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+var (
+	ErrUnverifiedEmailConflict = errors.New("an account with this email already exists and the provider did not verify the email")
+	ErrUserNotFound            = errors.New("user not found")
+)
+
+type UserService struct{}
+
+func NewUserService() *UserService {
+	return &UserService{}
+}
+
+// IsBootstrapAdminEmail reports whether the email is listed in BOOTSTRAP_ADMIN_EMAILS
+func IsBootstrapAdminEmail(email string) bool {
+	email = normalizeEmail(email)
+	for _, e := range config.AppConfig.BootstrapAdminEmails {
+		if email != "" && normalizeEmail(e) == email {
+			return true
+		}
+	}
+	return false
+}
+
+func normalizeEmail(email string) string {
+	return strings.ToLower(strings.TrimSpace(email))
+}
+
+// UpsertUserFromIdentity finds the user linked to the identity (or with the same verified email)
+// and refreshes the profile, or creates a new user in the shared users collection.
+// Linking to an unverified account drops its password and sessions (see claimed below).
+func (s *UserService) UpsertUserFromIdentity(ctx context.Context, identity *ExternalIdentity) (models.User, error) {
+	return s.upsertUserFromIdentity(ctx, identity, false)
+}
+
+func (s *UserService) upsertUserFromIdentity(ctx context.Context, identity *ExternalIdentity, retried bool) (models.User, error) {
+	collection := database.Mongo.Collection("users")
+	link := models.LinkedIdentity{Provider: identity.Provider, Subject: identity.Subject}
+	email := normalizeEmail(identity.Email)
+
+	// 1. Already linked to this provider account
+	var user models.User
+	claimed := false
+	err := collection.FindOne(ctx, bson.M{"identities": bson.M{"$elemMatch": bson.M{"provider": link.Provider, "subject": link.Subject}}}).Decode(&user)
+	if err == mongo.ErrNoDocuments {
+		// 2. Same email (only trusted when the provider verified it, otherwise anyone could claim the account)
+		err = collection.FindOne(ctx, bson.M{"email": email}).Decode(&user)
+		if err == nil && !identity.EmailVerified {
+			return models.User{}, ErrUnverifiedEmailConflict
+		}
+		// An unverified account with this email may have been registered by someone else:
+		// the verified owner takes it over and whoever set the password loses access
+		claimed = err == nil && !user.EmailVerified
+	}
+
+	if err == mongo.ErrNoDocuments {
+		role := models.RoleUser
+		if identity.EmailVerified && IsBootstrapAdminEmail(email) {
+			role = models.RoleAdmin
+		}
+
+		user = models.User{
+			ID:            primitive.NewObjectID(),
+			Email:         email,
+			EmailVerified: identity.EmailVerified,
+			Name:          identity.Name,
+			PictureURL:    identity.PictureURL,
+			Role:          role,
+			Identities:    []models.LinkedIdentity{link},
+			Status:        models.UserActive,
+			CreatedAt:     time.Now(),
+			UpdatedAt:     time.Now(),
+		}
+		if _, err := collection.InsertOne(ctx, user); err != nil {
+			if mongo.IsDuplicateKeyError(err) && !retried {
+				// The email was registered concurrently; link to that account instead
+				return s.upsertUserFromIdentity(ctx, identity, true)
+			}
+			return models.User{}, err
+		}
+		return user, nil
+	}
+	if err != nil {
+		return models.User{}, err
+	}
+
+	// Update existing user info
+	set := bson.M{"updated_at": time.Now()}
+	if identity.Name != "" {
+		set["name"] = identity.Name
+		user.Name = identity.Name
+	}
+	if identity.PictureURL != "" {
+		set["picture_url"] = identity.PictureURL
+		user.PictureURL = identity.PictureURL
+	}
+	if identity.EmailVerified {
+		set["email_verified"] = true
+		user.EmailVerified = true
+
+		if IsBootstrapAdminEmail(user.Email) && user.Role != models.RoleAdmin {
+			set["role"] = models.RoleAdmin
+			user.Role = models.RoleAdmin
+			LogInfo("ROLE_BOOTSTRAPPED", user.ID.Hex(), map[string]interface{}{"role": models.RoleAdmin})
+		}
+	}
+	if claimed {
+		// Separate write: $pull and $addToSet cannot touch identities in one update
+		update := bson.M{
+			"$unset": bson.M{"password_hash": ""},
+			"$pull":  bson.M{"identities": bson.M{"provider": "local"}},
+		}
+		if _, err := collection.UpdateOne(ctx, bson.M{"_id": user.ID}, update); err != nil {
+			return models.User{}, err
+		}
+		user.PasswordHash = ""
+	}
+	update := bson.M{
+		"$set":      set,
+		"$addToSet": bson.M{"identities": link},
+	}
+	if _, err := collection.UpdateOne(ctx, bson.M{"_id": user.ID}, update); err != nil {
+		return models.User{}, err
+	}
+	InvalidateUser(user.ID.Hex())
+	if claimed {
+		revoked, err := NewSessionService().RevokeAllSessions(user.ID.Hex())
+		if err != nil {
+			return models.User{}, err
+		}
+		LogWarn("UNVERIFIED_ACCOUNT_CLAIMED", user.ID.Hex(), map[string]interface{}{
+			"provider":         identity.Provider,
+			"sessions_revoked": revoked,
+		})
+	}
+	return user, nil
+}
+
+// GetUserByID loads a user by its hex ObjectID
+func (s *UserService) GetUserByID(ctx context.Context, userIDHex string) (*models.User, error) {
+	objID, err := primitive.ObjectIDFromHex(userIDHex)
+	if err != nil {
+		return nil, ErrUserNotFound
+	}
+	var user models.User
+	err = database.Mongo.Collection("users").FindOne(ctx, bson.M{"_id": objID}).Decode(&user)
+	if err == mongo.ErrNoDocuments {
+		return nil, ErrUserNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &user, nil
+}
+
+// UpdateRoles replaces the primary role and the per-cinema role assignments
+func (s *UserService) UpdateRoles(ctx context.Context, userID primitive.ObjectID, role models.Role, assignments []models.RoleAssignment) error {
+	if assignments == nil {
+		assignments = []models.RoleAssignment{}
+	}
+	update := bson.M{"$set": bson.M{
+		"role":             role,
+		"role_assignments": assignments,
+		"updated_at":       time.Now(),
+	}}
+	res, err := database.Mongo.Collection("users").UpdateOne(ctx, bson.M{"_id": userID}, update)
+	if err != nil {
+		return err
+	}
+	if res.MatchedCount == 0 {
+		return ErrUserNotFound
+	}
+	InvalidateUser(userID.Hex())
+	return nil
+}
+
+// SetStatus suspends or reactivates an account
+func (s *UserService) SetStatus(ctx context.Context, userID primitive.ObjectID, status models.UserStatus, reason string) error {
+	var update bson.M
+	if status == models.UserSuspended {
+		update = bson.M{"$set": bson.M{
+			"status":           status,
+			"suspended_reason": reason,
+			"suspended_at":     time.Now(),
+			"updated_at":       time.Now(),
+		}}
+	} else {
+		update = bson.M{
+			"$set":   bson.M{"status": status, "updated_at": time.Now()},
+			"$unset": bson.M{"suspended_reason": "", "suspended_at": ""},
+		}
+	}
+	res, err := database.Mongo.Collection("users").UpdateOne(ctx, bson.M{"_id": userID}, update)
+	if err != nil {
+		return err
+	}
+	if res.MatchedCount == 0 {
+		return ErrUserNotFound
+	}
+	InvalidateUser(userID.Hex())
+	return nil
+}
+
+// UpdateProfile sets the fields users manage themselves. An empty dateOfBirth clears it.
+func (s *UserService) UpdateProfile(ctx context.Context, userID primitive.ObjectID, name, dateOfBirth string) (*models.User, error) {
+	update := bson.M{"$set": bson.M{"name": name, "updated_at": time.Now()}}
+	if dateOfBirth == "" {
+		update["$unset"] = bson.M{"date_of_birth": ""}
+	} else {
+		update["$set"].(bson.M)["date_of_birth"] = dateOfBirth
+	}
+	var user models.User
+	opts := options.FindOneAndUpdate().SetReturnDocument(options.After)
+	err := database.Mongo.Collection("users").FindOneAndUpdate(ctx, bson.M{"_id": userID}, update, opts).Decode(&user)
+	if err == mongo.ErrNoDocuments {
+		return nil, ErrUserNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	InvalidateUser(userID.Hex())
+	return &user, nil
+}
+
+// CreatePartnerAccount creates a PARTNER account that is only used through API keys (no login identities)
+func (s *UserService) CreatePartnerAccount(ctx context.Context, name, email string) (*models.User, error) {
+	collection := database.Mongo.Collection("users")
+	email = normalizeEmail(email)
+
+	if err := collection.FindOne(ctx, bson.M{"email": email}).Err(); err == nil {
+		return nil, ErrEmailAlreadyRegistered
+	} else if err != mongo.ErrNoDocuments {
+		return nil, err
+	}
+
+	user := models.User{
+		ID:        primitive.NewObjectID(),
+		Email:     email,
+		Name:      name,
+		Role:      models.RolePartner,
+		Status:    models.UserActive,
+		CreatedAt: time.Now(),
+		UpdatedAt: time.Now(),
+	}
+	if _, err := collection.InsertOne(ctx, user); err != nil {
+		if mongo.IsDuplicateKeyError(err) {
+			return nil, ErrEmailAlreadyRegistered
+		}
+		return nil, err
+	}
+	return &user, nil
+}
@@ -1,10 +1,14 @@
 package services
 
 import (
+	"context"
 	"encoding/json"
+	"fmt"
 	"log"
+	"movie-ticket-backend/database"
 	"net/http"
 	"sync"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/gorilla/websocket"
@@ -25,10 +29,17 @@ type SeatUpdateMessage struct {
 	Status      string `json:"status"` // AVAILABLE, LOCKED, BOOKED
 }
 
+// WSClient is an open connection. UserID/SessionID are empty for anonymous viewers.
+type WSClient struct {
+	Conn      *websocket.Conn
+	UserID    string
+	SessionID string
+}
+
 type Hub struct {
-	Clients    map[*websocket.Conn]bool
-	Broadcast  chan SeatUpdateMessage
-	Register   chan *websocket.Conn
+	Clients    map[*websocket.Conn]*WSClient
+	Broadcast  chan interface{} // SeatUpdateMessage or SeatCountsMessage
+	Register   chan *WSClient
 	Unregister chan *websocket.Conn
 	Mutex      sync.Mutex
 }
@@ -37,9 +48,9 @@ var WSHub *Hub
 
 func InitWSHub() {
 	WSHub = &Hub{
-		Clients:    make(map[*websocket.Conn]bool),
-		Broadcast:  make(chan SeatUpdateMessage),
-		Register:   make(chan *websocket.Conn),
+		Clients:    make(map[*websocket.Conn]*WSClient),
+		Broadcast:  make(chan interface{}),
+		Register:   make(chan *WSClient),
 		Unregister: make(chan *websocket.Conn),
 	}
 	go WSHub.Run()
@@ -50,7 +61,7 @@ func (h *Hub) Run() {
 		select {
 		case client := <-h.Register:
 			h.Mutex.Lock()
-			h.Clients[client] = true
+			h.Clients[client.Conn] = client
 			h.Mutex.Unlock()
 		case client := <-h.Unregister:
 			h.Mutex.Lock()
@@ -75,11 +86,98 @@ func (h *Hub) Run() {
 	}
 }
 
+// DisconnectUser closes every connection authenticated as the user
+func (h *Hub) DisconnectUser(userID string) {
+	h.disconnect(func(client *WSClient) bool { return client.UserID == userID })
+}
+
+// DisconnectSession closes the connections opened with tokens of one session
+func (h *Hub) DisconnectSession(sid string) {
+	h.disconnect(func(client *WSClient) bool { return client.SessionID == sid })
+}
+
+func (h *Hub) disconnect(match func(*WSClient) bool) {
+	closeMsg := websocket.FormatCloseMessage(websocket.ClosePolicyViolation, "session revoked")
+	h.Mutex.Lock()
+	defer h.Mutex.Unlock()
+	for conn, client := range h.Clients {
+		if client.UserID == "" || !match(client) {
+			continue
+		}
+		conn.WriteControl(websocket.CloseMessage, closeMsg, time.Now().Add(time.Second))
+		conn.Close()
+		delete(h.Clients, conn)
+	}
+}
+
+// wsTicketTTL is how long a client has to open the socket after asking for a ticket
+const wsTicketTTL = 30 * time.Second
+
+// wsTicket is what a WebSocket ticket resolves to in Redis
+type wsTicket struct {
+	UserID    string `json:"user_id"`
+	SessionID string `json:"sid"`
+}
+
+func wsTicketKey(ticket string) string {
+	return fmt.Sprintf("ws_ticket:%s", ticket)
+}
+
+// IssueWSTicket hands an authenticated client a single-use ticket for ServeWS. Browsers cannot
+// set headers on a WebSocket, and an access token in the URL would end up in request logs.
+func IssueWSTicket(c *gin.Context) {
+	ticket, err := randomToken(32)
+	if err != nil {
+		c.JSON(500, gin.H{"error": "Failed to create ticket"})
+		return
+	}
+	val, err := json.Marshal(wsTicket{UserID: c.GetString("userID"), SessionID: c.GetString("sessionID")})
+	if err != nil {
+		c.JSON(500, gin.H{"error": "Failed to create ticket"})
+		return
+	}
+	if err := database.RDB.Set(c.Request.Context(), wsTicketKey(ticket), val, wsTicketTTL).Err(); err != nil {
+		c.JSON(500, gin.H{"error": "Failed to create ticket"})
+		return
+	}
+	c.JSON(200, gin.H{"ticket": ticket, "expires_in": int(wsTicketTTL.Seconds())})
+}
+
+// redeemWSTicket resolves and deletes a ticket, and checks its session is still open
+func redeemWSTicket(ctx context.Context, ticket string) (*wsTicket, error) {
+	val, err := database.RDB.GetDel(ctx, wsTicketKey(ticket)).Result()
+	if err != nil {
+		return nil, err
+	}
+	var t wsTicket
+	if err := json.Unmarshal([]byte(val), &t); err != nil {
+		return nil, err
+	}
+	if err := NewSessionService().ValidateSession(t.SessionID, t.UserID); err != nil {
+		return nil, err
+	}
+	return &t, nil
+}
+
+// ServeWS upgrades the connection. Seat updates are public, but a client may pass
+// ?ticket=<ticket from IssueWSTicket> so the connection is closed when its session is revoked.
 func ServeWS(c *gin.Context) {
+	client := &WSClient{}
+	if ticket := c.Query("ticket"); ticket != "" {
+		t, err := redeemWSTicket(c.Request.Context(), ticket)
+		if err != nil {
+			c.JSON(401, gin.H{"error": "Invalid or expired ticket"})
+			return
+		}
+		client.UserID = t.UserID
+		client.SessionID = t.SessionID
+	}
+
 	ws, err := upgrader.Upgrade(c.Writer, c.Request, nil)
 	if err != nil {
 		log.Println(err)
 		return
 	}
-	WSHub.Register <- ws
+	client.Conn = ws
+	WSHub.Register <- client
 }
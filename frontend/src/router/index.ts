@@ -42,8 +42,8 @@ router.beforeEach((to, _from, next) => {
       return
     }
     // Check role
-    if (authStore.user.role !== 'ADMIN') {
-      toast.error("Access Denied: Admins only")
+    if (!authStore.canAccessAdmin()) {
+      toast.error("Access Denied: Staff only")
       next('/') // Wrong role -> Home
       return
     }
@@ -25,10 +25,49 @@ api.interceptors.request.use(
   (error) => Promise.reject(error)
 );
 
+// Single in-flight refresh shared by all requests that hit 401 at the same time
+let refreshPromise: Promise<string> | null = null;
+
+const refreshAccessToken = (refreshToken: string) => {
+  if (!refreshPromise) {
+    refreshPromise = axios
+      .post(`${api.defaults.baseURL}/auth/refresh`, { refresh_token: refreshToken })
+      .then((res) => {
+        useAuthStore().setTokens(res.data.token, res.data.refresh_token);
+        return res.data.token as string;
+      })
+      .finally(() => {
+        refreshPromise = null;
+      });
+  }
+  return refreshPromise;
+};
+
 // Response interceptor for global error handling
 api.interceptors.response.use(
   (response) => response,
-  (error) => {
+  async (error) => {
+    const original = error.config;
+    const storedRefreshToken = localStorage.getItem('refresh_token');
+
+    // Access token expired -> try once with a rotated token pair
+    if (error.response && error.response.status === 401 && storedRefreshToken && original && !original._retry) {
+      original._retry = true;
+      try {
+        const newToken = await refreshAccessToken(storedRefreshToken);
+        original.headers.Authorization = `Bearer ${newToken}`;
+        return api(original);
+      } catch (refreshError) {
+        // Fall through to the session expired handling below
+      }
+    }
+
+    if (error.response && error.response.status === 429) {
+      const retryAfter = error.response.headers['retry-after'];
+      const { showToast } = useToast();
+      showToast(`Too many requests. Please try again in ${retryAfter || 'a few'} seconds.`, 'error');
+    }
+
     if (error.response && error.response.status === 401) {
       // 401 Unauthorized -> Session Expired or Invalid Token
       const authStore = useAuthStore();
@@ -49,8 +88,30 @@ api.interceptors.response.use(
   }
 );
 
+export const authApi = {
+  exchange: (code: string) => api.post('/auth/exchange', { code }),
+  localLogin: (email: string, password: string) => api.post('/auth/local/login', { email, password }),
+  register: (email: string, password: string, name: string) =>
+    api.post('/auth/local/register', { email, password, name }),
+  logout: () => api.post('/auth/logout'),
+  logoutAll: () => api.post('/auth/logout-all'),
+};
+
+export const meApi = {
+  get: () => api.get('/me'),
+  // date_of_birth: YYYY-MM-DD, "" clears it
+  update: (data: { name?: string; date_of_birth?: string }) => api.put('/me', data),
+};
+
 export const movieApi = {
-  getAll: () => api.get('/movies'),
+  // params: q, genre, age_rating, language, subtitle, cast, director, release_from, release_to,
+  // date (YYYY-MM-DD), format, audio, status (now_showing | coming_soon), sort, page, limit
+  list: (params: Record<string, string | number> = {}) => api.get('/movies', { params }),
+};
+
+export const showtimeApi = {
+  // Grouped by cinema -> movie -> hall. date (YYYY-MM-DD) is read in each cinema's timezone, default today
+  list: (params: { date?: string; cinema?: string } = {}) => api.get('/showtimes', { params }),
 };
 
 export const paymentApi = {
@@ -58,6 +119,10 @@ export const paymentApi = {
     api.post('/payment/start', { user_id: userId, movie_id: movieId, start_time: startTime, seat_ids: seatIds }),
   cancel: (reason?: string) => api.post('/payment/cancel', { reason: reason || 'user_cancelled' }),
 };
+export const wsApi = {
+  // Single-use ticket for /ws, so the access token never appears in the socket URL
+  ticket: () => api.post('/ws/ticket'),
+};
 export const screeningApi = {
   getDetails: (movieId: string, startTime: string) => api.post('/screenings/details', { movie_id: movieId, start_time: startTime }),
 };
@@ -80,7 +145,17 @@ export const adminApi = {
     if (params.date) queryParams.append('date', params.date);
     if (params.user) queryParams.append('user', params.user);
     return api.get(`/admin/bookings?${queryParams.toString()}`);
-  }
+  },
+  // kind: POSTER | BACKDROP. Returns the media ID to send as poster_media_id / backdrop_media_id
+  uploadMedia: (file: File, kind: 'POSTER' | 'BACKDROP') => {
+    const form = new FormData();
+    form.append('file', file);
+    form.append('kind', kind);
+    return api.post('/admin/media', form);
+  },
+  // Releases locks, refunds every ticket and emails the customers; call again to retry failed refunds
+  cancelScreening: (screeningId: string, reason: string) =>
+    api.post(`/admin/screenings/${screeningId}/cancel`, { reason })
 };
 
 export default api;
@@ -9,10 +9,12 @@ export const useAuthStore = defineStore('auth', () => {
     email: string
     role: string
     picture: string
+    permissions?: string[]
   }
 
   const user = ref<User | null>(null)
   const token = ref<string | null>(null)
+  const refreshToken = ref<string | null>(null)
   const showLoginModal = ref(false)
   const toast = useToast()
 
@@ -39,11 +41,12 @@ export const useAuthStore = defineStore('auth', () => {
       if (checkSession()) {
         user.value = JSON.parse(storedUser)
         token.value = storedToken
+        refreshToken.value = localStorage.getItem('refresh_token')
       }
     }
   }
 
-  const login = (userData: User, userToken: string) => {
+  const login = (userData: User, userToken: string, userRefreshToken?: string) => {
     user.value = userData
     token.value = userToken
     
@@ -52,20 +55,39 @@ export const useAuthStore = defineStore('auth', () => {
     localStorage.setItem('user', JSON.stringify(userData))
     localStorage.setItem('token', userToken)
     localStorage.setItem('auth_expiry', expiryTime.toString())
+    if (userRefreshToken) {
+      refreshToken.value = userRefreshToken
+      localStorage.setItem('refresh_token', userRefreshToken)
+    }
     
     showLoginModal.value = false
     toast.success(`Welcome back, ${userData.name}!`)
   }
 
+  // Called after /auth/refresh rotated the token pair
+  const setTokens = (userToken: string, userRefreshToken: string) => {
+    token.value = userToken
+    refreshToken.value = userRefreshToken
+    localStorage.setItem('token', userToken)
+    localStorage.setItem('refresh_token', userRefreshToken)
+    localStorage.setItem('auth_expiry', (Date.now() + SESSION_DURATION).toString())
+  }
+
   const logout = () => {
     user.value = null
     token.value = null
+    refreshToken.value = null
     localStorage.removeItem('user')
     localStorage.removeItem('token')
+    localStorage.removeItem('refresh_token')
     localStorage.removeItem('auth_expiry')
     toast.info("Logged out successfully")
   }
 
+  // Back office access is permission based (ADMIN, box office, manager, usher, finance)
+  const canAccessAdmin = () =>
+    !!user.value && (user.value.role === 'ADMIN' || (user.value.permissions || []).includes('admin:access'))
+
   const openLoginModal = () => showLoginModal.value = true
   const closeLoginModal = () => showLoginModal.value = false
 
@@ -74,10 +96,13 @@ export const useAuthStore = defineStore('auth', () => {
   return { 
     user, 
     token, 
+    refreshToken,
     showLoginModal, 
     login, 
+    setTokens,
     logout, 
     checkSession,
+    canAccessAdmin,
     openLoginModal, 
     closeLoginModal 
   }